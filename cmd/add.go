@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 	vfs "github.com/twpayne/go-vfs"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 var addCmd = &cobra.Command{
@@ -23,10 +24,19 @@ var addCmd = &cobra.Command{
 	PostRunE: config.autoCommitAndAutoPush,
 }
 
+// Ways of handling possible secrets in added files.
+const (
+	addSecretsError   = "error"
+	addSecretsIgnore  = "ignore"
+	addSecretsPrompt  = "prompt"
+	addSecretsWarning = "warning"
+)
+
 type addCmdConfig struct {
 	force     bool
 	recursive bool
 	prompt    bool
+	secrets   string
 	options   chezmoi.AddOptions
 }
 
@@ -40,6 +50,7 @@ func init() {
 	persistentFlags.BoolVarP(&config.add.options.Exact, "exact", "x", false, "add directories exactly")
 	persistentFlags.BoolVarP(&config.add.prompt, "prompt", "p", false, "prompt before adding")
 	persistentFlags.BoolVarP(&config.add.recursive, "recursive", "r", false, "recurse in to subdirectories")
+	persistentFlags.StringVar(&config.add.secrets, "secrets", "", "how to handle possible secrets (error, ignore, prompt, or warning)")
 	persistentFlags.BoolVarP(&config.add.options.Template, "template", "T", false, "add files as templates")
 	persistentFlags.BoolVarP(&config.add.options.AutoTemplate, "autotemplate", "a", false, "auto generate the template when adding files as templates")
 
@@ -47,6 +58,18 @@ func init() {
 }
 
 func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
+	switch c.add.secrets {
+	case "":
+		// By default, prompt if possible, otherwise refuse to add secrets.
+		if stdin, ok := c.Stdin.(*os.File); ok && terminal.IsTerminal(int(stdin.Fd())) {
+			c.add.secrets = addSecretsPrompt
+		} else {
+			c.add.secrets = addSecretsError
+		}
+	case addSecretsError, addSecretsIgnore, addSecretsPrompt, addSecretsWarning:
+	default:
+		return fmt.Errorf("%s: invalid --secrets value", c.add.secrets)
+	}
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
@@ -104,7 +127,7 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 						c.add.prompt = false
 					}
 				}
-				return ts.Add(c.fs, c.add.options, path, info, c.Follow, c.mutator)
+				return c.addTarget(ts, destDirPrefix, path, info)
 			}); err != nil {
 				return err
 			}
@@ -138,10 +161,144 @@ func (c *Config) runAddCmd(cmd *cobra.Command, args []string) (err error) {
 					c.add.prompt = false
 				}
 			}
-			if err := ts.Add(c.fs, c.add.options, path, nil, c.Follow, c.mutator); err != nil {
+			if err := c.addTarget(ts, destDirPrefix, path, nil); err != nil {
 				return err
 			}
 		}
 	}
 	return nil
 }
+
+// addTarget adds the target at path to ts. Unless they are encrypted or allowed
+// by .chezmoiallowsecrets, regular files are first checked for possible
+// secrets, which are handled according to c.add.secrets.
+func (c *Config) addTarget(ts *chezmoi.TargetState, destDirPrefix, path string, info os.FileInfo) error {
+	options := c.add.options
+	if options.AutoTemplate {
+		options.AutoTemplateOptions = chezmoi.AutoTemplateOptions{
+			MinMatchLength: c.AutoTemplate.MinMatchLength,
+			Accept:         c.acceptAutoTemplateSubstitutionFunc(path),
+		}
+	}
+	if c.add.secrets != addSecretsIgnore && !options.Encrypt && !ts.TargetAllow.Match(strings.TrimPrefix(path, destDirPrefix)) {
+		findings, err := c.scanSecrets(path, info)
+		if err != nil {
+			return err
+		}
+		if len(findings) != 0 {
+			var ok bool
+			options, ok, err = c.handleSecrets(path, findings, options)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+		}
+	}
+	return ts.Add(c.fs, options, path, info, c.Follow, c.mutator)
+}
+
+// acceptAutoTemplateSubstitutionFunc returns a function that reports each
+// substitution made when generating the template for the file at path, or, if
+// c.add.prompt is true, prompts whether to make it.
+func (c *Config) acceptAutoTemplateSubstitutionFunc(path string) func(*chezmoi.AutoTemplateSubstitution) (bool, error) {
+	prompt := c.add.prompt
+	return func(substitution *chezmoi.AutoTemplateSubstitution) (bool, error) {
+		location := fmt.Sprintf("%s:%d:%d", path, substitution.Line, substitution.Column)
+		if !prompt {
+			_, err := fmt.Fprintf(c.Stdout, "%s: replaced %q with {{ %s }}\n", location, substitution.Value, substitution.Expr)
+			return true, err
+		}
+		choice, err := c.prompt(fmt.Sprintf("%s: replace %q with {{ %s }}", location, substitution.Value, substitution.Expr), "yna")
+		if err != nil {
+			return false, err
+		}
+		switch choice {
+		case 'n':
+			return false, nil
+		case 'a':
+			prompt = false
+		}
+		return true, nil
+	}
+}
+
+// handleSecrets handles the possible secrets findings in the file at path. It
+// returns the options with which to add the file, and whether the file should
+// be added at all.
+func (c *Config) handleSecrets(path string, findings []chezmoi.SecretFinding, options chezmoi.AddOptions) (chezmoi.AddOptions, bool, error) {
+	descriptions := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		descriptions = append(descriptions, fmt.Sprintf("line %d: %s (%s)", finding.Line, finding.Kind, finding.MaskedValue()))
+	}
+
+	switch c.add.secrets {
+	case addSecretsError:
+		return options, false, fmt.Errorf("%s: possible secrets found: %s", path, strings.Join(descriptions, ", "))
+	case addSecretsWarning:
+		fmt.Fprintf(c.Stderr, "warning: %s: possible secrets found: %s\n", path, strings.Join(descriptions, ", "))
+		return options, true, nil
+	}
+
+	fmt.Fprintf(c.Stdout, "%s: possible secrets found:\n", path)
+	for _, description := range descriptions {
+		fmt.Fprintf(c.Stdout, "  %s\n", description)
+	}
+	choice, err := c.prompt("Add anyway (y), skip (n), add encrypted (e), or add as template (t)", "ynet")
+	if err != nil {
+		return options, false, err
+	}
+	switch choice {
+	case 'n':
+		return options, false, nil
+	case 'e':
+		options.Encrypt = true
+	case 't':
+		secretTemplates := make(map[string]string)
+		for i, finding := range findings {
+			if _, ok := secretTemplates[finding.Value]; ok {
+				continue
+			}
+			expr, err := c.readLine(fmt.Sprintf("Template expression for %s, for example pass \"name\" (empty to keep)", descriptions[i]))
+			if err != nil {
+				return options, false, err
+			}
+			if expr != "" {
+				secretTemplates[finding.Value] = expr
+			}
+		}
+		if len(secretTemplates) != 0 {
+			options.Template = true
+			options.SecretTemplates = secretTemplates
+		}
+	}
+	return options, true, nil
+}
+
+// scanSecrets returns the possible secrets in the file at path. Only regular,
+// non-binary files are scanned.
+func (c *Config) scanSecrets(path string, info os.FileInfo) ([]chezmoi.SecretFinding, error) {
+	if info == nil {
+		var err error
+		if c.Follow {
+			info, err = c.fs.Stat(path)
+		} else {
+			info, err = c.fs.Lstat(path)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil
+	}
+	contents, err := c.fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if chezmoi.IsBinary(contents) {
+		return nil, nil
+	}
+	return chezmoi.ScanSecrets(contents), nil
+}
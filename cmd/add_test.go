@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"bytes"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -505,3 +507,248 @@ func TestIssue192(t *testing.T) {
 		),
 	)
 }
+
+func TestAddSecrets(t *testing.T) {
+	const netrc = "machine example.com login user password hunter2\n"
+	for _, tc := range []struct {
+		name        string
+		secrets     string
+		root        map[string]interface{}
+		stdin       string
+		expectedErr bool
+		tests       []vfst.Test
+	}{
+		{
+			name:        "error",
+			secrets:     "error",
+			expectedErr: true,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_netrc",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name:    "error_allowed",
+			secrets: "error",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/.chezmoiallowsecrets": ".netrc\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_netrc",
+					vfst.TestContentsString(netrc),
+				),
+			},
+		},
+		{
+			name:        "default",
+			expectedErr: true,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_netrc",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name:    "warning",
+			secrets: "warning",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_netrc",
+					vfst.TestContentsString(netrc),
+				),
+			},
+		},
+		{
+			name:    "ignore",
+			secrets: "ignore",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_netrc",
+					vfst.TestContentsString(netrc),
+				),
+			},
+		},
+		{
+			name:    "prompt_no",
+			secrets: "prompt",
+			stdin:   "n\n",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_netrc",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name:    "prompt_yes",
+			secrets: "prompt",
+			stdin:   "y\n",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_netrc",
+					vfst.TestContentsString(netrc),
+				),
+			},
+		},
+		{
+			name:    "prompt_template",
+			secrets: "prompt",
+			stdin:   "t\npass \"example.com\"\n",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_netrc",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_netrc.tmpl",
+					vfst.TestContentsString("machine example.com login user password {{ pass \"example.com\" }}\n"),
+				),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			root := map[string]interface{}{
+				"/home/user":                      &vfst.Dir{Perm: 0755},
+				"/home/user/.local/share/chezmoi": &vfst.Dir{Perm: 0700},
+				"/home/user/.netrc":               netrc,
+			}
+			for path, contents := range tc.root {
+				root[path] = contents
+			}
+			fs, cleanup, err := vfst.NewTestFS(root)
+			require.NoError(t, err)
+			defer cleanup()
+			c := newTestConfig(
+				fs,
+				withAddCmdConfig(addCmdConfig{
+					secrets: tc.secrets,
+				}),
+				withStdin(strings.NewReader(tc.stdin)),
+				withStdout(&bytes.Buffer{}),
+			)
+			err = c.runAddCmd(&cobra.Command{}, []string{"/home/user/.netrc"})
+			if tc.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			vfst.RunTests(t, fs, "", tc.tests)
+		})
+	}
+}
+
+func TestAddAutoTemplate(t *testing.T) {
+	const gitconfig = "[user]\n\tname = John Smith\n\temail = john.smith@company.com\n\tid = 42\n"
+	for _, tc := range []struct {
+		name             string
+		prompt           bool
+		minMatchLength   int
+		stdin            string
+		expectedContents string
+		expectedStdout   []string
+	}{
+		{
+			name:             "report",
+			expectedContents: "[user]\n\tname = {{ .name }}\n\temail = {{ .email }}\n\tid = {{ .id }}\n",
+			expectedStdout: []string{
+				"/home/user/.gitconfig:2:9: replaced \"John Smith\" with {{ .name }}\n",
+				"/home/user/.gitconfig:3:10: replaced \"john.smith@company.com\" with {{ .email }}\n",
+				"/home/user/.gitconfig:4:7: replaced \"42\" with {{ .id }}\n",
+			},
+		},
+		{
+			name:             "min_match_length",
+			minMatchLength:   3,
+			expectedContents: "[user]\n\tname = {{ .name }}\n\temail = {{ .email }}\n\tid = 42\n",
+		},
+		{
+			name:             "prompt",
+			prompt:           true,
+			stdin:            "y\nn\ny\n",
+			expectedContents: "[user]\n\tname = {{ .name }}\n\temail = john.smith@company.com\n\tid = {{ .id }}\n",
+			expectedStdout: []string{
+				"/home/user/.gitconfig:3:10: replace \"john.smith@company.com\" with {{ .email }} [y,n,a]? ",
+			},
+		},
+		{
+			name:             "prompt_all",
+			prompt:           true,
+			stdin:            "y\na\n",
+			expectedContents: "[user]\n\tname = {{ .name }}\n\temail = {{ .email }}\n\tid = {{ .id }}\n",
+			expectedStdout: []string{
+				"/home/user/.gitconfig:4:7: replaced \"42\" with {{ .id }}\n",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user":                      &vfst.Dir{Perm: 0755},
+				"/home/user/.local/share/chezmoi": &vfst.Dir{Perm: 0700},
+				"/home/user/.gitconfig":           gitconfig,
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			stdin := strings.NewReader(tc.stdin)
+			if tc.prompt {
+				// Accept the prompt to add the file.
+				stdin = strings.NewReader("y\n" + tc.stdin)
+			}
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(
+				fs,
+				withAddCmdConfig(addCmdConfig{
+					prompt: tc.prompt,
+					options: chezmoi.AddOptions{
+						Template:     true,
+						AutoTemplate: true,
+					},
+				}),
+				withData(map[string]interface{}{
+					"email": "john.smith@company.com",
+					"id":    42,
+					"name":  "John Smith",
+				}),
+				withStdin(stdin),
+				withStdout(stdout),
+			)
+			c.AutoTemplate.MinMatchLength = tc.minMatchLength
+			require.NoError(t, c.runAddCmd(&cobra.Command{}, []string{"/home/user/.gitconfig"}))
+			vfst.RunTests(t, fs, "",
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_gitconfig.tmpl",
+					vfst.TestContentsString(tc.expectedContents),
+				),
+			)
+			for _, expectedStdout := range tc.expectedStdout {
+				assert.Contains(t, stdout.String(), expectedStdout)
+			}
+		})
+	}
+}
+
+func TestAddAutoTemplateSmallNumbers(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user":                      &vfst.Dir{Perm: 0755},
+		"/home/user/.local/share/chezmoi": &vfst.Dir{Perm: 0700},
+		"/home/user/.config/app.conf":     "workers = 1\nretries = 22\nport = 8080\nversion = 1.22\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(
+		fs,
+		withAddCmdConfig(addCmdConfig{
+			options: chezmoi.AddOptions{
+				Template:     true,
+				AutoTemplate: true,
+			},
+		}),
+		withData(map[string]interface{}{
+			"port":    8080,
+			"retries": 22,
+			"workers": 1,
+		}),
+		withStdout(&bytes.Buffer{}),
+	)
+	require.NoError(t, c.runAddCmd(&cobra.Command{}, []string{"/home/user/.config/app.conf"}))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_config/app.conf.tmpl",
+			vfst.TestContentsString("workers = 1\nretries = 22\nport = {{ .port }}\nversion = 1.22\n"),
+		),
+	)
+}
@@ -13,9 +13,16 @@ var applyCmd = &cobra.Command{
 	RunE:    config.runApplyCmd,
 }
 
+type applyCmdConfig struct {
+	interactive bool
+}
+
 func init() {
 	rootCmd.AddCommand(applyCmd)
 
+	persistentFlags := applyCmd.PersistentFlags()
+	persistentFlags.BoolVarP(&config.apply.interactive, "interactive", "i", false, "prompt before each change")
+
 	markRemainingZshCompPositionalArgumentsAsFiles(applyCmd, 1)
 }
 
@@ -26,5 +33,5 @@ func (c *Config) runApplyCmd(cmd *cobra.Command, args []string) error {
 	}
 	defer persistentState.Close()
 
-	return c.applyArgs(args, persistentState)
+	return c.applyArgsWithHistory(args, persistentState)
 }
@@ -4,8 +4,10 @@ package cmd
 
 import (
 	"path/filepath"
+	"runtime"
 	"strings"
 
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 	"github.com/twpayne/go-vfs/vfst"
 )
 
@@ -74,6 +76,84 @@ func getApplyScriptTestCases(tempDir string) []scriptTestCase {
 				),
 			},
 		},
+		{
+			name: "environment",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/run_env": strings.Join([]string{
+					"#!/bin/sh",
+					"echo $CHEZMOI $CHEZMOI_OS $CHEZMOI_SOURCE_DIR >>" + filepath.Join(tempDir, "evidence"),
+					"grep -o '\"foo\":\"bar\"' $CHEZMOI_DATA_FILE >>" + filepath.Join(tempDir, "evidence"),
+				}, "\n"),
+			},
+			data: map[string]interface{}{
+				"foo": "bar",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath(filepath.Join(tempDir, "evidence"),
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString(strings.Repeat(
+						"1 "+runtime.GOOS+" "+filepath.Join(tempDir, "home", "user", ".local", "share", "chezmoi")+"\n"+
+							`"foo":"bar"`+"\n",
+						3,
+					)),
+				),
+			},
+		},
+		{
+			// Lazily computed machine facts are in the data file even if no
+			// template referenced them.
+			name: "data_file_lazy_facts",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/run_facts": strings.Join([]string{
+					"#!/bin/sh",
+					"grep -o '\"cpuCount\":' $CHEZMOI_DATA_FILE >>" + filepath.Join(tempDir, "evidence"),
+				}, "\n"),
+			},
+			tests: []vfst.Test{
+				vfst.TestPath(filepath.Join(tempDir, "evidence"),
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString(strings.Repeat(`"cpuCount":`+"\n", 3)),
+				),
+			},
+		},
+		{
+			name: "interpreter",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_dir/run_once_foo.sh2": "echo interpreted $(pwd) >>" + filepath.Join(tempDir, "evidence") + "\n",
+			},
+			interpreters: map[string]chezmoi.Interpreter{
+				"sh2": {
+					Command: "sh",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath(filepath.Join(tempDir, "evidence"),
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("interpreted "+filepath.Join(tempDir, ".dir")+"\n"),
+				),
+			},
+		},
+		{
+			name: "triggers",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoitriggers": strings.Join([]string{
+						"# comment",
+						".bar echo bar >>" + filepath.Join(tempDir, "evidence"),
+						".foo echo foo >>" + filepath.Join(tempDir, "evidence"),
+					}, "\n"),
+					"dot_dir/.chezmoitriggers": "* echo dir >>" + filepath.Join(tempDir, "evidence") + "\n",
+					"dot_dir/file":             "# contents of .dir/file\n",
+					"dot_foo":                  "# contents of .foo\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath(filepath.Join(tempDir, "evidence"),
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("foo\ndir\n"),
+				),
+			},
+		},
 	}
 }
 
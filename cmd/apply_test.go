@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -8,15 +9,17 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 	vfs "github.com/twpayne/go-vfs"
 	"github.com/twpayne/go-vfs/vfst"
 )
 
 type scriptTestCase struct {
-	name  string
-	root  interface{}
-	data  map[string]interface{}
-	tests []vfst.Test
+	name         string
+	root         interface{}
+	data         map[string]interface{}
+	interpreters map[string]chezmoi.Interpreter
+	tests        []vfst.Test
 }
 
 func TestApplyCommand(t *testing.T) {
@@ -250,6 +253,94 @@ func TestApplyFollow(t *testing.T) {
 	}
 }
 
+func TestApplyModeSymlink(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		root  interface{}
+		tests []vfst.Test
+	}{
+		{
+			name: "file",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_bashrc": "# contents of .bashrc\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestModeType(os.ModeSymlink),
+					vfst.TestContentsString("# contents of .bashrc\n"),
+				),
+			},
+		},
+		{
+			name: "replace_file",
+			root: map[string]interface{}{
+				"/home/user/.bashrc":                         "# old contents of .bashrc\n",
+				"/home/user/.local/share/chezmoi/dot_bashrc": "# contents of .bashrc\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestModeType(os.ModeSymlink),
+					vfst.TestContentsString("# contents of .bashrc\n"),
+				),
+			},
+		},
+		{
+			name: "template",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_bashrc.tmpl": "# contents of {{ \".bashrc\" }}\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of .bashrc\n"),
+				),
+			},
+		},
+		{
+			name: "private",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/private_dot_netrc": "# contents of .netrc\n",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.netrc",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of .netrc\n"),
+				),
+			},
+		},
+		{
+			name: "empty",
+			root: map[string]interface{}{
+				"/home/user/.bashrc":                         "# contents of .bashrc\n",
+				"/home/user/.local/share/chezmoi/dot_bashrc": "",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			c := newTestConfig(
+				fs,
+				withMode(chezmoi.ModeSymlink),
+			)
+			assert.NoError(t, c.runApplyCmd(nil, nil))
+			vfst.RunTests(t, fs, "", tc.tests)
+
+			// A second apply should not make any changes.
+			mutator := chezmoi.NewAnyMutator(chezmoi.NullMutator{})
+			c.mutator = mutator
+			assert.NoError(t, c.runApplyCmd(nil, nil))
+			assert.False(t, mutator.Mutated())
+		})
+	}
+}
+
 func TestApplyRemove(t *testing.T) {
 	for _, tc := range []struct {
 		name     string
@@ -385,6 +476,7 @@ func TestApplyScript(t *testing.T) {
 					fs,
 					withDestDir("/"),
 					withData(tc.data),
+					withInterpreters(tc.interpreters),
 				)
 				require.NoError(t, c.runApplyCmd(nil, nil))
 			}
@@ -485,3 +577,26 @@ func TestApplyRemoveEmptySymlink(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyDryRunTriggers(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoitriggers": ".bar echo bar\n.foo echo foo\n",
+			"dot_foo":          "# contents of .foo\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(fs, withMutator(chezmoi.NullMutator{}), withStdout(stdout))
+	c.DryRun = true
+	c.Verbose = false
+	require.NoError(t, c.runApplyCmd(nil, nil))
+	assert.Equal(t, "echo foo\n", stdout.String())
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.foo",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
@@ -19,6 +19,8 @@ var archiveCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(archiveCmd)
+
+	addDataOverrideFlags(archiveCmd.PersistentFlags())
 }
 
 func (c *Config) runArchiveCmd(cmd *cobra.Command, args []string) error {
@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:     "cache",
+	Args:    cobra.NoArgs,
+	Short:   "Interact with the template cache",
+	Long:    mustGetLongHelp("cache"),
+	Example: getExample("cache"),
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+}
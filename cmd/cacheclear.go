@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheClearCmd = &cobra.Command{
+	Use:     "clear",
+	Args:    cobra.NoArgs,
+	Short:   "Clear the template cache",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runCacheClearCmd,
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+}
+
+func (c *Config) runCacheClearCmd(cmd *cobra.Command, args []string) error {
+	templateCacheFile := c.getTemplateCacheFile()
+	if _, err := c.fs.Stat(templateCacheFile); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return c.mutator.RemoveAll(templateCacheFile)
+}
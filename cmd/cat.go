@@ -24,6 +24,7 @@ func init() {
 }
 
 func (c *Config) runCatCmd(cmd *cobra.Command, args []string) error {
+	c.redactSecrets()
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
@@ -39,6 +40,11 @@ func (c *Config) runCatCmd(cmd *cobra.Command, args []string) error {
 			if err != nil {
 				return err
 			}
+			if c.redactor != nil && entry.Encrypted {
+				contents = []byte(chezmoi.RedactedText + "\n")
+			} else {
+				contents = c.redactor.Redact(contents)
+			}
 			if _, err := c.Stdout.Write(contents); err != nil {
 				return err
 			}
@@ -47,7 +53,7 @@ func (c *Config) runCatCmd(cmd *cobra.Command, args []string) error {
 			if err != nil {
 				return err
 			}
-			fmt.Println(linkname)
+			fmt.Fprintln(c.Stdout, string(c.redactor.Redact([]byte(linkname))))
 		default:
 			return fmt.Errorf("%s: not a file or symlink", args[i])
 		}
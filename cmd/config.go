@@ -11,10 +11,12 @@ import (
 	"os/exec"
 	"os/user"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"runtime"
 	"strings"
 	"text/template"
+	"time"
 	"unicode"
 
 	"github.com/Masterminds/sprig"
@@ -44,55 +46,95 @@ type templateConfig struct {
 	Options []string
 }
 
+type autoTemplateConfig struct {
+	MinMatchLength int
+}
+
+type scriptConfig struct {
+	RecordOutput bool
+	Timeout      time.Duration
+}
+
 // A Config represents a configuration.
 type Config struct {
-	configFile        string
-	err               error
-	fs                vfs.FS
-	mutator           chezmoi.Mutator
-	SourceDir         string
-	DestDir           string
-	Umask             permValue
-	DryRun            bool
-	Follow            bool
-	Remove            bool
-	Verbose           bool
-	Color             string
-	Debug             bool
-	GPG               chezmoi.GPG
-	GPGRecipient      string
-	SourceVCS         sourceVCSConfig
-	Template          templateConfig
-	Merge             mergeConfig
-	Bitwarden         bitwardenCmdConfig
-	CD                cdCmdConfig
-	GenericSecret     genericSecretCmdConfig
-	Gopass            gopassCmdConfig
-	KeePassXC         keePassXCCmdConfig
-	Lastpass          lastpassCmdConfig
-	Onepassword       onepasswordCmdConfig
-	Vault             vaultCmdConfig
-	Pass              passCmdConfig
-	Data              map[string]interface{}
-	colored           bool
-	maxDiffDataSize   int
-	templateFuncs     template.FuncMap
-	add               addCmdConfig
-	data              dataCmdConfig
-	dump              dumpCmdConfig
-	edit              editCmdConfig
-	_import           importCmdConfig
-	init              initCmdConfig
-	keyring           keyringCmdConfig
-	purge             purgeCmdConfig
-	remove            removeCmdConfig
-	update            updateCmdConfig
-	upgrade           upgradeCmdConfig
-	Stdin             io.Reader
-	Stdout            io.Writer
-	Stderr            io.Writer
-	bds               *xdg.BaseDirectorySpecification
-	scriptStateBucket []byte
+	configFile                   string
+	err                          error
+	fs                           vfs.FS
+	mutator                      chezmoi.Mutator
+	SourceDir                    string
+	DestDir                      string
+	Mode                         chezmoi.Mode
+	Umask                        permValue
+	DryRun                       bool
+	Follow                       bool
+	Remove                       bool
+	Verbose                      bool
+	Color                        string
+	Debug                        bool
+	GPG                          chezmoi.GPG
+	GPGRecipient                 string
+	SourceVCS                    sourceVCSConfig
+	Script                       scriptConfig
+	Template                     templateConfig
+	AutoTemplate                 autoTemplateConfig
+	Interpreters                 map[string]chezmoi.Interpreter
+	Merge                        mergeConfig
+	Bitwarden                    bitwardenCmdConfig
+	CD                           cdCmdConfig
+	GenericSecret                genericSecretCmdConfig
+	Gopass                       gopassCmdConfig
+	KeePassXC                    keePassXCCmdConfig
+	Lastpass                     lastpassCmdConfig
+	Onepassword                  onepasswordCmdConfig
+	Vault                        vaultCmdConfig
+	Pass                         passCmdConfig
+	SecretPlugin                 secretPluginConfig
+	SOPS                         sopsConfig
+	Data                         map[string]interface{}
+	colored                      bool
+	maxDiffDataSize              int
+	templateFuncs                template.FuncMap
+	noCache                      bool
+	secretTemplateFuncs          map[string]struct{}
+	showSecrets                  bool
+	dataOverride                 string
+	profile                      string
+	redactor                     *chezmoi.Redactor
+	recordedSecrets              *chezmoi.Redactor
+	templateCache                chezmoi.TemplateCache
+	templateCacheBucket          []byte
+	templateCachePersistentState chezmoi.PersistentState
+	secretCache                  *persistentSecretCache
+	secretCacheBucket            []byte
+	secretPlugins                []*secretPlugin
+	sourceRefFS                  vfs.FS
+	sourceRefDir                 string
+	vaultClient                  *vaultClient
+	add                          addCmdConfig
+	apply                        applyCmdConfig
+	data                         dataCmdConfig
+	diff                         diffCmdConfig
+	dump                         dumpCmdConfig
+	factsExport                  factsExportCmdConfig
+	edit                         editCmdConfig
+	_import                      importCmdConfig
+	init                         initCmdConfig
+	keyring                      keyringCmdConfig
+	purge                        purgeCmdConfig
+	remove                       removeCmdConfig
+	update                       updateCmdConfig
+	upgrade                      upgradeCmdConfig
+	watch                        watchCmdConfig
+	Stdin                        io.Reader
+	bufferedStdin                *bufio.Reader
+	Stdout                       io.Writer
+	Stderr                       io.Writer
+	bds                          *xdg.BaseDirectorySpecification
+	scriptStateBucket            []byte
+	historyBucket                []byte
+	history                      historyCmdConfig
+	ignored                      ignoredCmdConfig
+	managed                      managedCmdConfig
 }
 
 // A configOption sets an option on a Config.
@@ -106,6 +148,16 @@ var (
 			return e.Encode(value)
 		},
 		"toml": func(w io.Writer, value interface{}) error {
+			// go-toml's Encoder only encodes structs, so encode maps via a
+			// Tree.
+			if m, ok := value.(map[string]interface{}); ok {
+				tree, err := toml.TreeFromMap(m)
+				if err != nil {
+					return err
+				}
+				_, err = tree.WriteTo(w)
+				return err
+			}
 			return toml.NewEncoder(w).Encode(value)
 		},
 		"yaml": func(w io.Writer, value interface{}) error {
@@ -129,22 +181,33 @@ var (
 func newConfig(options ...configOption) *Config {
 	c := &Config{
 		Umask: permValue(getUmask()),
+		Mode:  chezmoi.ModeFile,
 		Color: "auto",
 		SourceVCS: sourceVCSConfig{
 			Command: "git",
 		},
+		AutoTemplate: autoTemplateConfig{
+			MinMatchLength: 3,
+		},
+		Script: scriptConfig{
+			RecordOutput: true,
+		},
 		Template: templateConfig{
 			Options: chezmoi.DefaultTemplateOptions,
 		},
 		Merge: mergeConfig{
 			Command: "vimdiff",
 		},
-		maxDiffDataSize:   1 * 1024 * 1024, // 1MB
-		templateFuncs:     sprig.TxtFuncMap(),
-		scriptStateBucket: []byte("script"),
-		Stdin:             os.Stdin,
-		Stdout:            os.Stdout,
-		Stderr:            os.Stderr,
+		Interpreters:        getDefaultInterpreters(),
+		maxDiffDataSize:     1 * 1024 * 1024, // 1MB
+		templateFuncs:       sprig.TxtFuncMap(),
+		scriptStateBucket:   []byte("script"),
+		historyBucket:       []byte("history"),
+		templateCacheBucket: []byte("template"),
+		secretCacheBucket:   []byte("secret"),
+		Stdin:               os.Stdin,
+		Stdout:              os.Stdout,
+		Stderr:              os.Stderr,
 	}
 	for _, option := range options {
 		option(c)
@@ -162,32 +225,86 @@ func (c *Config) addTemplateFunc(key string, value interface{}) {
 	c.templateFuncs[key] = value
 }
 
+// addSecretTemplateFunc adds a template function whose output is secret. The
+// values that it returns are recorded so that they can be redacted from output.
+func (c *Config) addSecretTemplateFunc(key string, value interface{}) {
+	c.addTemplateFunc(key, c.recordSecrets(value))
+	if c.recordedSecrets == nil {
+		c.recordedSecrets = chezmoi.NewRedactor()
+	}
+	if c.secretTemplateFuncs == nil {
+		c.secretTemplateFuncs = make(map[string]struct{})
+	}
+	c.secretTemplateFuncs[key] = struct{}{}
+}
+
+// applyArgsWithHistory calls applyArgs and records the result in the history
+// in persistentState.
+func (c *Config) applyArgsWithHistory(args []string, persistentState chezmoi.PersistentState) error {
+	startedAt := time.Now()
+	err := c.applyArgs(args, persistentState)
+	if c.DryRun {
+		return err
+	}
+	historyEntry := &chezmoi.HistoryEntry{
+		Type:      chezmoi.HistoryTypeApply,
+		Name:      strings.Join(append([]string{"apply"}, args...), " "),
+		StartedAt: startedAt,
+		Duration:  time.Since(startedAt),
+	}
+	if err != nil {
+		historyEntry.ExitCode = 1
+		historyEntry.Error = err.Error()
+	}
+	if historyErr := chezmoi.AddHistoryEntry(persistentState, c.historyBucket, historyEntry); err == nil {
+		err = historyErr
+	}
+	return err
+}
+
 func (c *Config) applyArgs(args []string, persistentState chezmoi.PersistentState) error {
-	fs := vfs.NewReadOnlyFS(c.fs)
 	ts, err := c.getTargetState(nil)
 	if err != nil {
 		return err
 	}
-	applyOptions := &chezmoi.ApplyOptions{
-		DestDir:           ts.DestDir,
-		DryRun:            c.DryRun,
-		Ignore:            ts.TargetIgnore.Match,
-		PersistentState:   persistentState,
-		Remove:            c.Remove,
-		ScriptStateBucket: c.scriptStateBucket,
-		Stdout:            c.Stdout,
-		Umask:             ts.Umask,
-		Verbose:           c.Verbose,
+	return c.applyTargetStateArgs(ts, args, persistentState)
+}
+
+// applyTargetStateArgs applies the entries in ts for args, or all of ts if args
+// is empty, and runs any triggers.
+func (c *Config) applyTargetStateArgs(ts *chezmoi.TargetState, args []string, persistentState chezmoi.PersistentState) error {
+	return c.applyTargetStateArgsWithOptions(vfs.NewReadOnlyFS(c.fs), ts, args, c.getApplyOptions(ts, persistentState))
+}
+
+// applyTargetStateArgsWithOptions is like applyTargetStateArgs but applies ts
+// to fs with applyOptions.
+func (c *Config) applyTargetStateArgsWithOptions(fs vfs.FS, ts *chezmoi.TargetState, args []string, applyOptions *chezmoi.ApplyOptions) error {
+	mutator := chezmoi.NewRecordingMutator(c.mutator)
+	var entryMutator chezmoi.Mutator = mutator
+	if c.apply.interactive {
+		interactiveMutator := newInteractiveMutator(c, ts, mutator)
+		defer interactiveMutator.printSummary()
+		entryMutator = interactiveMutator
+		applyOptions.ConfirmScript = interactiveMutator.confirmScript
 	}
+	if err := c.applyEntries(fs, ts, args, entryMutator, applyOptions); err != nil && !errors.Is(err, errInteractiveQuit) {
+		return err
+	}
+	return c.runTriggers(ts, mutator)
+}
+
+// applyEntries applies the entries in ts for args, or all of ts if args is
+// empty.
+func (c *Config) applyEntries(fs vfs.FS, ts *chezmoi.TargetState, args []string, mutator chezmoi.Mutator, applyOptions *chezmoi.ApplyOptions) error {
 	if len(args) == 0 {
-		return ts.Apply(fs, c.mutator, c.Follow, applyOptions)
+		return ts.Apply(fs, mutator, c.Follow, applyOptions)
 	}
 	entries, err := c.getEntries(ts, args)
 	if err != nil {
 		return err
 	}
 	for _, entry := range entries {
-		if err := entry.Apply(fs, c.mutator, c.Follow, applyOptions); err != nil {
+		if err := entry.Apply(fs, mutator, c.Follow, applyOptions); err != nil {
 			return err
 		}
 	}
@@ -289,7 +406,49 @@ func (c *Config) ensureSourceDirectory() error {
 	}
 }
 
+func (c *Config) getApplyOptions(ts *chezmoi.TargetState, persistentState chezmoi.PersistentState) *chezmoi.ApplyOptions {
+	return &chezmoi.ApplyOptions{
+		DestDir:            ts.DestDir,
+		DryRun:             c.DryRun,
+		HistoryBucket:      c.historyBucket,
+		Ignore:             ts.TargetIgnore.Match,
+		Interpreters:       c.Interpreters,
+		Mode:               c.Mode,
+		PersistentState:    persistentState,
+		RecordScriptOutput: c.Script.RecordOutput,
+		Redactor:           c.redactor,
+		Remove:             c.Remove,
+		ScriptStateBucket:  c.scriptStateBucket,
+		ScriptTimeout:      c.Script.Timeout,
+		SourceDir:          ts.SourceDir,
+		Stdout:             c.Stdout,
+		TemplateData:       ts.TemplateData,
+		Umask:              ts.Umask,
+		Verbose:            c.Verbose,
+	}
+}
+
 func (c *Config) getData() (map[string]interface{}, error) {
+	return c.getDataWithSOPS(true)
+}
+
+// getDataWithSOPS returns the template data, including the data in
+// sops-encrypted data files only if includeSOPS is true.
+func (c *Config) getDataWithSOPS(includeSOPS bool) (map[string]interface{}, error) {
+	var sopsData map[string]interface{}
+	if includeSOPS {
+		var err error
+		sopsData, err = c.getSOPSData()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.getDataWithSOPSData(sopsData)
+}
+
+// getDataWithSOPSData returns the template data with sopsData in place of the
+// data in sops-encrypted data files.
+func (c *Config) getDataWithSOPSData(sopsData map[string]interface{}) (map[string]interface{}, error) {
 	defaultData, err := c.getDefaultData()
 	if err != nil {
 		return nil, err
@@ -300,6 +459,14 @@ func (c *Config) getData() (map[string]interface{}, error) {
 	for key, value := range c.Data {
 		data[key] = value
 	}
+	for key, value := range sopsData {
+		data[key] = value
+	}
+	dataOverride, err := c.getDataOverride()
+	if err != nil {
+		return nil, err
+	}
+	mergeData(data, dataOverride)
 	return data, nil
 }
 
@@ -361,6 +528,15 @@ func (c *Config) getDefaultData() (map[string]interface{}, error) {
 		return nil, err
 	}
 
+	facts := &machineFacts{
+		fs:       c.fs,
+		getenv:   os.Getenv,
+		lookPath: exec.LookPath,
+	}
+	for key, value := range facts.lazyData() {
+		data[key] = value
+	}
+
 	return data, nil
 }
 
@@ -420,8 +596,11 @@ func (c *Config) getPersistentStateFile() string {
 }
 
 func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chezmoi.TargetState, error) {
-	fs := vfs.NewReadOnlyFS(c.fs)
+	return c.getTargetStateFromFS(vfs.NewReadOnlyFS(c.fs), c.SourceDir, populateOptions)
+}
 
+// getTargetStateFromFS returns the target state populated from sourceDir in fs.
+func (c *Config) getTargetStateFromFS(fs vfs.FS, sourceDir string, populateOptions *chezmoi.PopulateOptions) (*chezmoi.TargetState, error) {
 	data, err := c.getData()
 	if err != nil {
 		return nil, err
@@ -435,15 +614,16 @@ func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chez
 		}
 	}
 
-	// For backwards compatibility, prioritize gpgRecipient over gpg.recipient.
-	if c.GPGRecipient != "" {
-		c.GPG.Recipient = c.GPGRecipient
+	templateCache, err := c.getTemplateCache()
+	if err != nil {
+		return nil, err
 	}
 
 	ts := chezmoi.NewTargetState(
 		chezmoi.WithDestDir(destDir),
-		chezmoi.WithGPG(&c.GPG),
-		chezmoi.WithSourceDir(c.SourceDir),
+		chezmoi.WithGPG(c.getGPG()),
+		chezmoi.WithSourceDir(sourceDir),
+		chezmoi.WithTemplateCache(templateCache),
 		chezmoi.WithTemplateData(data),
 		chezmoi.WithTemplateFuncs(c.templateFuncs),
 		chezmoi.WithTemplateOptions(c.Template.Options),
@@ -455,9 +635,25 @@ func (c *Config) getTargetState(populateOptions *chezmoi.PopulateOptions) (*chez
 	if Version != nil && ts.MinVersion != nil && Version.LessThan(*ts.MinVersion) {
 		return nil, fmt.Errorf("chezmoi version %s too old, source state requires at least %s", Version, ts.MinVersion)
 	}
+	if c.redactor != nil {
+		walkEntries(ts.Entries, func(entry chezmoi.Entry) {
+			if file, ok := entry.(*chezmoi.File); ok && file.Encrypted {
+				c.redactor.AddSecretPath(filepath.Join(ts.DestDir, file.TargetName()))
+			}
+		})
+	}
 	return ts, nil
 }
 
+// getGPG returns the configured GPG.
+func (c *Config) getGPG() *chezmoi.GPG {
+	// For backwards compatibility, prioritize gpgRecipient over gpg.recipient.
+	if c.GPGRecipient != "" {
+		c.GPG.Recipient = c.GPGRecipient
+	}
+	return &c.GPG
+}
+
 func (c *Config) getVCS() (VCS, error) {
 	vcs, ok := vcses[filepath.Base(c.SourceVCS.Command)]
 	if !ok {
@@ -480,9 +676,9 @@ func (c *Config) output(dir, name string, argv ...string) ([]byte, error) {
 
 //nolint:unparam
 func (c *Config) prompt(s, choices string) (byte, error) {
-	r := bufio.NewReader(c.Stdin)
+	r := c.getBufferedStdin()
 	for {
-		_, err := fmt.Printf("%s [%s]? ", s, strings.Join(strings.Split(choices, ""), ","))
+		_, err := fmt.Fprintf(c.Stdout, "%s [%s]? ", s, strings.Join(strings.Split(choices, ""), ","))
 		if err != nil {
 			return 0, err
 		}
@@ -497,6 +693,57 @@ func (c *Config) prompt(s, choices string) (byte, error) {
 	}
 }
 
+// readLine prompts the user with s and returns the line that they enter,
+// without surrounding whitespace.
+func (c *Config) readLine(s string) (string, error) {
+	if _, err := fmt.Fprintf(c.Stdout, "%s? ", s); err != nil {
+		return "", err
+	}
+	line, err := c.getBufferedStdin().ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// getBufferedStdin returns a bufio.Reader that reads from c.Stdin. The same
+// bufio.Reader is reused for all prompts so that input buffered while reading
+// one response is available to the next.
+func (c *Config) getBufferedStdin() *bufio.Reader {
+	if c.bufferedStdin == nil {
+		c.bufferedStdin = bufio.NewReader(c.Stdin)
+	}
+	return c.bufferedStdin
+}
+
+// recordSecrets returns a function with the same signature as fn that calls fn
+// and records the values that it returns in c's redactor, if any.
+func (c *Config) recordSecrets(fn interface{}) interface{} {
+	fnValue := reflect.ValueOf(fn)
+	return reflect.MakeFunc(fnValue.Type(), func(args []reflect.Value) []reflect.Value {
+		var results []reflect.Value
+		if fnValue.Type().IsVariadic() {
+			results = fnValue.CallSlice(args)
+		} else {
+			results = fnValue.Call(args)
+		}
+		for _, result := range results {
+			c.redactor.AddSecret(result.Interface())
+			c.recordedSecrets.AddSecret(result.Interface())
+		}
+		return results
+	}).Interface()
+}
+
+// redactSecrets enables the redaction of secrets from output, unless
+// --show-secrets was passed. It must be called before the target state is
+// read.
+func (c *Config) redactSecrets() {
+	if !c.showSecrets && c.redactor == nil {
+		c.redactor = chezmoi.NewRedactor()
+	}
+}
+
 // run runs name argv... in dir.
 func (c *Config) run(dir, name string, argv ...string) error {
 	cmd := exec.Command(name, argv...)
@@ -518,6 +765,33 @@ func (c *Config) runEditor(argv ...string) error {
 	return c.run("", editorName, append(editorArgs, argv...)...)
 }
 
+// runTriggers runs the triggers in ts that match any of the paths mutated by
+// mutator.
+func (c *Config) runTriggers(ts *chezmoi.TargetState, mutator *chezmoi.RecordingMutator) error {
+	for _, trigger := range ts.Triggered(mutator.Paths()) {
+		cmd := shellCmd(trigger.Command)
+		var err error
+		cmd.Dir, err = c.fs.RawPath(ts.DestDir)
+		if err != nil {
+			return err
+		}
+		cmd.Stdin = c.Stdin
+		cmd.Stdout = c.Stdout
+		cmd.Stderr = c.Stderr
+		// In dry run mode, always print the commands that would be run, so that
+		// triggers can be audited. In verbose mode the mutator prints them.
+		if c.DryRun && !c.Verbose {
+			if _, err := fmt.Fprintln(c.Stdout, trigger.Command); err != nil {
+				return err
+			}
+		}
+		if err := mutator.RunCmd(cmd); err != nil {
+			return fmt.Errorf("%s: %w", trigger.Command, err)
+		}
+	}
+	return nil
+}
+
 func (c *Config) validateData() error {
 	return validateKeys(config.Data, identifierRegexp)
 }
@@ -544,6 +818,25 @@ func getDefaultConfigFile(bds *xdg.BaseDirectorySpecification) string {
 	return filepath.Join(bds.ConfigHome, "chezmoi", "chezmoi.toml")
 }
 
+func getDefaultInterpreters() map[string]chezmoi.Interpreter {
+	powershell := "pwsh"
+	if runtime.GOOS == "windows" {
+		powershell = "powershell"
+	}
+	return map[string]chezmoi.Interpreter{
+		"ps1": {
+			Command: powershell,
+			Args:    []string{"-NoLogo", "-File"},
+		},
+		"py": {
+			Command: "python3",
+		},
+		"rb": {
+			Command: "ruby",
+		},
+	}
+}
+
 func getDefaultSourceDir(bds *xdg.BaseDirectorySpecification) string {
 	// Check for XDG Base Directory Specification data directories first.
 	for _, dataDir := range bds.DataDirs {
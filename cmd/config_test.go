@@ -136,6 +136,7 @@ func TestValidateKeys(t *testing.T) {
 func newTestConfig(fs vfs.FS, options ...configOption) *Config {
 	return newConfig(append(
 		[]configOption{
+			withNoCache(true),
 			withTestFS(fs),
 			withTestUser("user"),
 		},
@@ -149,6 +150,12 @@ func withAddCmdConfig(add addCmdConfig) configOption {
 	}
 }
 
+func withApplyCmdConfig(apply applyCmdConfig) configOption {
+	return func(c *Config) {
+		c.apply = apply
+	}
+}
+
 func withData(data map[string]interface{}) configOption {
 	return func(c *Config) {
 		c.Data = data
@@ -179,12 +186,32 @@ func withGenericSecretCmdConfig(genericSecretCmdConfig genericSecretCmdConfig) c
 	}
 }
 
+func withInterpreters(interpreters map[string]chezmoi.Interpreter) configOption {
+	return func(c *Config) {
+		for extension, interpreter := range interpreters {
+			c.Interpreters[extension] = interpreter
+		}
+	}
+}
+
+func withMode(mode chezmoi.Mode) configOption {
+	return func(c *Config) {
+		c.Mode = mode
+	}
+}
+
 func withMutator(mutator chezmoi.Mutator) configOption {
 	return func(c *Config) {
 		c.mutator = mutator
 	}
 }
 
+func withNoCache(noCache bool) configOption {
+	return func(c *Config) {
+		c.noCache = noCache
+	}
+}
+
 func withRemove(remove bool) configOption {
 	return func(c *Config) {
 		c.Remove = remove
@@ -212,7 +239,7 @@ func withStdout(stdout io.Writer) configOption {
 func withTestFS(fs vfs.FS) configOption {
 	return func(c *Config) {
 		c.fs = fs
-		c.mutator = chezmoi.NewVerboseMutator(os.Stdout, chezmoi.NewFSMutator(fs), false, 0)
+		c.mutator = chezmoi.NewVerboseMutator(os.Stdout, chezmoi.NewFSMutator(fs), false, 0, nil)
 		c.Verbose = true
 	}
 }
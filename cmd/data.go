@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 type dataCmdConfig struct {
@@ -33,9 +34,17 @@ func (c *Config) runDataCmd(cmd *cobra.Command, args []string) error {
 	if !ok {
 		return fmt.Errorf("%s: unknown format", c.data.format)
 	}
+	c.redactSecrets()
 	data, err := c.getData()
 	if err != nil {
 		return err
 	}
+	if c.redactor != nil {
+		data = chezmoi.RedactTemplateData(data)
+	}
+	data, err = chezmoi.ResolveTemplateData(data, nil)
+	if err != nil {
+		return err
+	}
 	return format(c.Stdout, data)
 }
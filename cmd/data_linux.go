@@ -99,3 +99,121 @@ func parseOSRelease(r io.Reader) (map[string]string, error) {
 	}
 	return result, s.Err()
 }
+
+// getContainer returns the container runtime that chezmoi is running in, or
+// the empty string if chezmoi is not running in a container.
+func getContainer(fs vfs.FS) (string, error) {
+	for _, marker := range []struct {
+		path      string
+		container string
+	}{
+		{"/.dockerenv", "docker"},
+		{"/run/.containerenv", "podman"},
+	} {
+		if _, err := fs.Stat(marker.path); err == nil {
+			return marker.container, nil
+		}
+	}
+
+	// Otherwise, look for the container runtime in the control groups of
+	// init.
+	data, err := fs.ReadFile("/proc/1/cgroup")
+	switch {
+	case os.IsNotExist(err), os.IsPermission(err):
+		return "", nil
+	case err != nil:
+		return "", err
+	}
+	for _, cgroup := range []struct {
+		substr    string
+		container string
+	}{
+		{"kubepods", "kubernetes"},
+		{"docker", "docker"},
+		{"libpod", "podman"},
+		{"lxc", "lxc"},
+	} {
+		if strings.Contains(string(data), cgroup.substr) {
+			return cgroup.container, nil
+		}
+	}
+	return "", nil
+}
+
+// getTotalMemory returns the total usable memory in bytes, or zero if it
+// cannot be determined.
+func getTotalMemory(fs vfs.FS) (uint64, error) {
+	f, err := fs.Open("/proc/meminfo")
+	switch {
+	case os.IsNotExist(err), os.IsPermission(err):
+		return 0, nil
+	case err != nil:
+		return 0, err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// The line is of the form "MemTotal:       16314372 kB".
+		fields := strings.Fields(s.Text())
+		if len(fields) != 3 || fields[0] != "MemTotal:" || fields[2] != "kB" {
+			continue
+		}
+		kB, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("/proc/meminfo: %w", err)
+		}
+		return 1024 * kB, nil
+	}
+	return 0, s.Err()
+}
+
+// getVirtualization returns the hypervisor of the virtual machine that chezmoi
+// is running in, or the empty string if no virtual machine is detected.
+func getVirtualization(fs vfs.FS) (string, error) {
+	var dmiID []string
+	for _, filename := range []string{
+		"/sys/class/dmi/id/sys_vendor",
+		"/sys/class/dmi/id/product_name",
+	} {
+		data, err := fs.ReadFile(filename)
+		switch {
+		case os.IsNotExist(err), os.IsPermission(err):
+			continue
+		case err != nil:
+			return "", err
+		}
+		dmiID = append(dmiID, string(bytes.TrimSpace(data)))
+	}
+	s := strings.Join(dmiID, " ")
+	for _, hypervisor := range []struct {
+		substr         string
+		virtualization string
+	}{
+		{"KVM", "kvm"},
+		{"QEMU", "qemu"},
+		{"VMware", "vmware"},
+		{"VirtualBox", "virtualbox"},
+		{"innotek", "virtualbox"},
+		{"Xen", "xen"},
+		{"Parallels", "parallels"},
+		{"Microsoft Corporation Virtual Machine", "hyperv"},
+		{"Amazon EC2", "amazon"},
+	} {
+		if strings.Contains(s, hypervisor.substr) {
+			return hypervisor.virtualization, nil
+		}
+	}
+	return "", nil
+}
+
+// isWSL returns whether chezmoi is running in Windows Subsystem for Linux.
+func isWSL(fs vfs.FS) (bool, error) {
+	data, err := fs.ReadFile("/proc/sys/kernel/osrelease")
+	switch {
+	case os.IsNotExist(err), os.IsPermission(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return strings.Contains(strings.ToLower(string(data)), "microsoft"), nil
+}
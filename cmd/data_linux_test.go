@@ -198,3 +198,182 @@ UBUNTU_CODENAME=bionic`,
 		assert.Equal(t, tc.want, got)
 	}
 }
+
+func TestGetContainer(t *testing.T) {
+	for _, tc := range []struct {
+		name              string
+		root              interface{}
+		expectedContainer string
+	}{
+		{
+			name: "docker",
+			root: map[string]interface{}{
+				"/.dockerenv": "",
+			},
+			expectedContainer: "docker",
+		},
+		{
+			name: "podman",
+			root: map[string]interface{}{
+				"/run/.containerenv": "",
+			},
+			expectedContainer: "podman",
+		},
+		{
+			name: "kubernetes",
+			root: map[string]interface{}{
+				"/proc/1/cgroup": "12:pids:/kubepods/besteffort/pod1234\n",
+			},
+			expectedContainer: "kubernetes",
+		},
+		{
+			name: "none",
+			root: map[string]interface{}{
+				"/proc/1/cgroup": "0::/init.scope\n",
+			},
+		},
+		{
+			name: "proc_missing",
+			root: map[string]interface{}{
+				"/etc": &vfst.Dir{Perm: 0755},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			container, err := getContainer(fs)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedContainer, container)
+		})
+	}
+}
+
+func TestGetTotalMemory(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		root                interface{}
+		expectedTotalMemory uint64
+	}{
+		{
+			name: "meminfo",
+			root: map[string]interface{}{
+				"/proc/meminfo": "MemTotal:       16314372 kB\nMemFree:         1261532 kB\n",
+			},
+			expectedTotalMemory: 16705916928,
+		},
+		{
+			name: "meminfo_missing",
+			root: map[string]interface{}{
+				"/proc": &vfst.Dir{Perm: 0755},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			totalMemory, err := getTotalMemory(fs)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedTotalMemory, totalMemory)
+		})
+	}
+}
+
+func TestGetVirtualization(t *testing.T) {
+	for _, tc := range []struct {
+		name                   string
+		root                   interface{}
+		expectedVirtualization string
+	}{
+		{
+			name: "kvm",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id": map[string]interface{}{
+					"product_name": "Standard PC (Q35 + ICH9, 2009)\n",
+					"sys_vendor":   "QEMU\n",
+				},
+			},
+			expectedVirtualization: "qemu",
+		},
+		{
+			name: "virtualbox",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id": map[string]interface{}{
+					"product_name": "VirtualBox\n",
+					"sys_vendor":   "innotek GmbH\n",
+				},
+			},
+			expectedVirtualization: "virtualbox",
+		},
+		{
+			name: "bare_metal",
+			root: map[string]interface{}{
+				"/sys/class/dmi/id": map[string]interface{}{
+					"product_name": "ThinkPad X1 Carbon\n",
+					"sys_vendor":   "LENOVO\n",
+				},
+			},
+		},
+		{
+			name: "dmi_missing",
+			root: map[string]interface{}{
+				"/sys": &vfst.Dir{Perm: 0755},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			virtualization, err := getVirtualization(fs)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedVirtualization, virtualization)
+		})
+	}
+}
+
+func TestIsWSL(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		root        interface{}
+		expectedWSL bool
+	}{
+		{
+			name: "wsl2",
+			root: map[string]interface{}{
+				"/proc/sys/kernel/osrelease": "4.19.81-microsoft-standard\n",
+			},
+			expectedWSL: true,
+		},
+		{
+			name: "wsl1",
+			root: map[string]interface{}{
+				"/proc/sys/kernel/osrelease": "4.4.0-19041-Microsoft\n",
+			},
+			expectedWSL: true,
+		},
+		{
+			name: "linux",
+			root: map[string]interface{}{
+				"/proc/sys/kernel/osrelease": "5.4.0-42-generic\n",
+			},
+		},
+		{
+			name: "proc_sys_kernel_missing",
+			root: map[string]interface{}{
+				"/proc/sys": &vfst.Dir{Perm: 0755},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			wsl, err := isWSL(fs)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedWSL, wsl)
+		})
+	}
+}
@@ -11,3 +11,19 @@ func getKernelInfo(fs vfs.FS) (map[string]string, error) {
 func getOSRelease(fs vfs.FS) (map[string]string, error) {
 	return nil, nil
 }
+
+func getContainer(fs vfs.FS) (string, error) {
+	return "", nil
+}
+
+func getTotalMemory(fs vfs.FS) (uint64, error) {
+	return 0, nil
+}
+
+func getVirtualization(fs vfs.FS) (string, error) {
+	return "", nil
+}
+
+func isWSL(fs vfs.FS) (bool, error) {
+	return false, nil
+}
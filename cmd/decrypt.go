@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+)
+
+var decryptCmd = &cobra.Command{
+	Use:     "decrypt",
+	Args:    cobra.NoArgs,
+	Short:   "Decrypt stdin",
+	Long:    mustGetLongHelp("decrypt"),
+	Example: getExample("decrypt"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runDecryptCmd,
+}
+
+var decryptCache = make(map[string]string)
+
+func init() {
+	rootCmd.AddCommand(decryptCmd)
+
+	config.addSecretTemplateFunc("decrypt", config.decryptFunc)
+}
+
+func (c *Config) runDecryptCmd(cmd *cobra.Command, args []string) error {
+	ciphertext, err := ioutil.ReadAll(c.Stdin)
+	if err != nil {
+		return err
+	}
+	plaintext, err := c.getGPG().Decrypt("stdin", ciphertext)
+	if err != nil {
+		return err
+	}
+	_, err = c.Stdout.Write(plaintext)
+	return err
+}
+
+func (c *Config) decryptFunc(ciphertext string) string {
+	if plaintext, ok := decryptCache[ciphertext]; ok {
+		return plaintext
+	}
+	plaintext, err := c.getGPG().Decrypt("decrypt", []byte(ciphertext))
+	if err != nil {
+		panic(fmt.Errorf("decrypt: %w", err))
+	}
+	decryptCache[ciphertext] = string(plaintext)
+	return string(plaintext)
+}
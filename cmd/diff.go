@@ -1,11 +1,24 @@
 package cmd
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
 	"github.com/spf13/cobra"
 	"github.com/twpayne/chezmoi/internal/chezmoi"
+	vfs "github.com/twpayne/go-vfs"
 	bolt "go.etcd.io/bbolt"
 )
 
+type diffCmdConfig struct {
+	sourceRefs []string
+}
+
 var diffCmd = &cobra.Command{
 	Use:     "diff [targets...]",
 	Short:   "Print the diff between the target state and the destination state",
@@ -18,16 +31,29 @@ var diffCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(diffCmd)
 
+	persistentFlags := diffCmd.PersistentFlags()
+	persistentFlags.StringArrayVar(&config.diff.sourceRefs, "source-ref", nil, "use the source state at revision (may be given twice)")
+	addDataOverrideFlags(persistentFlags)
+
 	markRemainingZshCompPositionalArgumentsAsFiles(diffCmd, 1)
 }
 
 func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
+	if len(c.diff.sourceRefs) > 2 {
+		return fmt.Errorf("--source-ref: at most two revisions can be compared")
+	}
+
+	c.redactSecrets()
 	c.DryRun = true
 	c.mutator = chezmoi.NullMutator{}
 	if c.Debug {
 		c.mutator = chezmoi.NewDebugMutator(c.mutator)
 	}
-	c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize)
+	c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize, c.redactor)
+
+	if len(c.diff.sourceRefs) == 2 {
+		return c.diffSourceRefs(c.diff.sourceRefs[0], c.diff.sourceRefs[1], args)
+	}
 
 	persistentState, err := c.getPersistentState(&bolt.Options{
 		ReadOnly: true,
@@ -37,5 +63,204 @@ func (c *Config) runDiffCmd(cmd *cobra.Command, args []string) error {
 	}
 	defer persistentState.Close()
 
+	if len(c.diff.sourceRefs) == 1 {
+		ts, cleanup, err := c.getTargetStateAtRef(c.diff.sourceRefs[0])
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		applyOptions := c.getApplyOptions(ts, persistentState)
+		fs, err := c.getSourceRefDestFS(applyOptions)
+		if err != nil {
+			return err
+		}
+		return c.applyTargetStateArgsWithOptions(fs, ts, args, applyOptions)
+	}
+
 	return c.applyArgs(args, persistentState)
 }
+
+// diffSourceRefs prints the diff between the target states of the source
+// directory at the revisions ref1 and ref2 for the targets in args, or all
+// targets if args is empty.
+func (c *Config) diffSourceRefs(ref1, ref2 string, args []string) error {
+	ts1, cleanup1, err := c.getTargetStateAtRef(ref1)
+	if err != nil {
+		return err
+	}
+	defer cleanup1()
+	ts2, cleanup2, err := c.getTargetStateAtRef(ref2)
+	if err != nil {
+		return err
+	}
+	defer cleanup2()
+
+	selected, err := getTargetNameSelector(ts2.DestDir, args)
+	if err != nil {
+		return err
+	}
+	scripts1 := getScripts(ts1)
+	scripts2 := getScripts(ts2)
+
+	// Render ts1 into a temporary directory at the same path as the
+	// destination directory, so that the diff uses the real target paths.
+	// Scripts are compared separately as they do not have a destination
+	// state.
+	tempDir, err := ioutil.TempDir("", "chezmoi-dest")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+	fs := vfs.NewPathFS(vfs.OSFS, tempDir)
+	if err := vfs.MkdirAll(fs, ts1.DestDir, 0777); err != nil {
+		return err
+	}
+	applyOptions1 := c.getSourceRefApplyOptions(ts1)
+	applyOptions1.Ignore = func(targetName string) bool {
+		return ts1.TargetIgnore.Match(targetName) || scripts1[targetName] != nil
+	}
+	if err := ts1.Apply(fs, chezmoi.NewFSMutator(fs), false, applyOptions1); err != nil {
+		return err
+	}
+
+	applyOptions2 := c.getSourceRefApplyOptions(ts2)
+	applyOptions2.DryRun = true
+	applyOptions2.Ignore = func(targetName string) bool {
+		return ts2.TargetIgnore.Match(targetName) || scripts2[targetName] != nil || !selected(targetName)
+	}
+	if err := ts2.Apply(vfs.NewReadOnlyFS(fs), c.mutator, false, applyOptions2); err != nil {
+		return err
+	}
+
+	// Entries that are only in ts1 are removed, unless their parent directory
+	// is exact, in which case applying ts2 has already removed them.
+	var removeErr error
+	walkRemovedEntries(ts1.Entries, ts2.Entries, func(entry chezmoi.Entry) {
+		targetName := entry.TargetName()
+		if removeErr != nil || applyOptions1.Ignore(targetName) || !selected(targetName) {
+			return
+		}
+		removeErr = c.mutator.RemoveAll(filepath.Join(ts1.DestDir, targetName))
+	})
+	if removeErr != nil {
+		return removeErr
+	}
+
+	return c.diffScripts(scripts1, scripts2, selected)
+}
+
+// diffScripts prints the diff between the contents of the scripts in scripts1
+// and scripts2 for which selected returns true.
+func (c *Config) diffScripts(scripts1, scripts2 map[string]*chezmoi.Script, selected func(string) bool) error {
+	targetNames := make([]string, 0, len(scripts1)+len(scripts2))
+	for targetName := range scripts1 {
+		targetNames = append(targetNames, targetName)
+	}
+	for targetName := range scripts2 {
+		if _, ok := scripts1[targetName]; !ok {
+			targetNames = append(targetNames, targetName)
+		}
+	}
+	sort.Strings(targetNames)
+	for _, targetName := range targetNames {
+		if !selected(targetName) {
+			continue
+		}
+		var contents1, contents2 []byte
+		var name string
+		if script, ok := scripts1[targetName]; ok {
+			var err error
+			if contents1, err = script.Contents(); err != nil {
+				return err
+			}
+			name = script.SourceName()
+		}
+		if script, ok := scripts2[targetName]; ok {
+			var err error
+			if contents2, err = script.Contents(); err != nil {
+				return err
+			}
+			name = script.SourceName()
+		}
+		if bytes.Equal(contents1, contents2) {
+			continue
+		}
+		if err := chezmoi.NewLineDiff(contents1, contents2, 3).WriteUnified(c.Stdout, name, c.colored); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getSourceRefApplyOptions returns the options used to render ts, a target
+// state at a source revision. Files are always rendered with their contents,
+// whatever the mode, so that they can be compared.
+func (c *Config) getSourceRefApplyOptions(ts *chezmoi.TargetState) *chezmoi.ApplyOptions {
+	applyOptions := c.getApplyOptions(ts, nil)
+	applyOptions.DryRun = false
+	applyOptions.HistoryBucket = nil
+	applyOptions.Mode = chezmoi.ModeFile
+	applyOptions.Remove = false
+	applyOptions.Verbose = false
+	return applyOptions
+}
+
+// getScripts returns the scripts in ts that are not ignored, indexed by target
+// name.
+func getScripts(ts *chezmoi.TargetState) map[string]*chezmoi.Script {
+	scripts := make(map[string]*chezmoi.Script)
+	walkEntriesNotIgnored(ts.Entries, ts.TargetIgnore.Match, func(entry chezmoi.Entry) {
+		if script, ok := entry.(*chezmoi.Script); ok {
+			scripts[script.TargetName()] = script
+		}
+	})
+	return scripts
+}
+
+// getTargetNameSelector returns a function that returns whether a target name
+// is selected by args, i.e. is, contains, or is contained by one of the targets
+// in args. If args is empty then all target names are selected.
+func getTargetNameSelector(destDir string, args []string) (func(string) bool, error) {
+	if len(args) == 0 {
+		return func(string) bool { return true }, nil
+	}
+	argTargetNames := make([]string, 0, len(args))
+	for _, arg := range args {
+		targetPath, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, err
+		}
+		targetName, ok := relPathWithin(destDir, targetPath)
+		if !ok {
+			return nil, fmt.Errorf("%s: outside target directory", arg)
+		}
+		argTargetNames = append(argTargetNames, targetName)
+	}
+	return func(targetName string) bool {
+		for _, argTargetName := range argTargetNames {
+			if targetName == argTargetName ||
+				strings.HasPrefix(targetName, argTargetName+string(filepath.Separator)) ||
+				strings.HasPrefix(argTargetName, targetName+string(filepath.Separator)) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// walkRemovedEntries calls f on every entry in entries1 that is not in
+// entries2. The contents of removed directories are not walked.
+func walkRemovedEntries(entries1, entries2 map[string]chezmoi.Entry, f func(chezmoi.Entry)) {
+	for name, entry1 := range entries1 {
+		entry2, ok := entries2[name]
+		if !ok {
+			f(entry1)
+			continue
+		}
+		dir1, ok1 := entry1.(*chezmoi.Dir)
+		dir2, ok2 := entry2.(*chezmoi.Dir)
+		if ok1 && ok2 && !dir2.Exact {
+			walkRemovedEntries(dir1.Entries, dir2.Entries, f)
+		}
+	}
+}
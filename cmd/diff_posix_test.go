@@ -3,6 +3,7 @@
 package cmd
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 	vfs "github.com/twpayne/go-vfs"
 	"github.com/twpayne/go-vfs/vfst"
 )
@@ -35,3 +37,199 @@ func TestDiffDoesNotRunScript(t *testing.T) {
 		),
 	)
 }
+
+func TestDiffSourceRef(t *testing.T) {
+	skipIfGitNotFound(t)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# current\n",
+			".local/share/chezmoi": map[string]interface{}{
+				"dot_bashrc":       "# one\n",
+				"dot_profile":      "# profile\n",
+				"run_once_foo.sh":  "#!/bin/sh\necho one\n",
+				"dot_vimrc.tmpl":   "\" {{ \"one\" }}\n",
+				"dot_inputrc.tmpl": "{{ include \"inputrc\" }}",
+				"inputrc":          "# included one\n",
+				".chezmoiignore":   "inputrc\n",
+				"dot_config/.keep": "",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "init", "--quiet")
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "add", ".")
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "commit", "--quiet", "--message", "One")
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "tag", "one")
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_bashrc", []byte("# two\n"), 0666))
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/run_once_foo.sh", []byte("#!/bin/sh\necho two\n"), 0666))
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "rm", "--quiet", "dot_profile")
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "commit", "--quiet", "--all", "--message", "Two")
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_bashrc", []byte("# working copy\n"), 0666))
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/inputrc", []byte("# included working copy\n"), 0666))
+
+	for _, tc := range []struct {
+		name        string
+		sourceRefs  []string
+		args        []string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:        "one_ref",
+			sourceRefs:  []string{"one"},
+			contains:    []string{"-# current\n", "+# one\n", "+# included one\n"},
+			notContains: []string{"working copy"},
+		},
+		{
+			name:       "two_refs",
+			sourceRefs: []string{"one", "HEAD"},
+			contains: []string{
+				"-# one\n", "+# two\n",
+				"rm -rf /home/user/.profile\n",
+				"+++ b/run_once_foo.sh\n", "-echo one\n", "+echo two\n",
+			},
+			notContains: []string{"current", "working copy", ".vimrc", ".config"},
+		},
+		{
+			name:        "two_refs_with_args",
+			sourceRefs:  []string{"one", "HEAD"},
+			args:        []string{"/home/user/.profile"},
+			contains:    []string{"rm -rf /home/user/.profile\n"},
+			notContains: []string{".bashrc", "run_once_foo.sh"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withDestDir("/home/user"), withStdout(stdout))
+			c.addTemplateFunc("include", c.includeFunc)
+			c.diff.sourceRefs = tc.sourceRefs
+			require.NoError(t, c.runDiffCmd(nil, tc.args))
+			for _, s := range tc.contains {
+				assert.Contains(t, stdout.String(), s)
+			}
+			for _, s := range tc.notContains {
+				assert.NotContains(t, stdout.String(), s)
+			}
+		})
+	}
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(fs, withDestDir("/home/user"), withStdout(stdout))
+	c.diff.sourceRefs = []string{"one", "two", "three"}
+	assert.Error(t, c.runDiffCmd(nil, nil))
+
+	for _, sourceRef := range []string{"--output=/home/user/archive", "nonexistent"} {
+		c := newTestConfig(fs, withDestDir("/home/user"), withStdout(&bytes.Buffer{}))
+		c.diff.sourceRefs = []string{sourceRef}
+		assert.Error(t, c.runDiffCmd(nil, nil))
+	}
+	_, err = fs.Stat("/home/user/archive")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDiffSourceRefSymlinkMode(t *testing.T) {
+	skipIfGitNotFound(t)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc":  &vfst.Symlink{Target: "/home/user/.local/share/chezmoi/dot_bashrc"},
+			".profile": &vfst.Symlink{Target: "/home/user/.local/share/chezmoi/dot_profile"},
+			".local/share/chezmoi": map[string]interface{}{
+				"dot_bashrc":  "# one\n",
+				"dot_profile": "# profile\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "init", "--quiet")
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "add", ".")
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "commit", "--quiet", "--message", "One")
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "tag", "one")
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_bashrc", []byte("# two\n"), 0666))
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "commit", "--quiet", "--all", "--message", "Two")
+
+	for _, tc := range []struct {
+		sourceRef   string
+		contains    []string
+		notContains []string
+	}{
+		{
+			sourceRef:   "one",
+			contains:    []string{"-# two\n", "+# one\n"},
+			notContains: []string{".profile"},
+		},
+		{
+			sourceRef:   "HEAD",
+			notContains: []string{".bashrc", ".profile"},
+		},
+	} {
+		t.Run(tc.sourceRef, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withDestDir("/home/user"), withMode(chezmoi.ModeSymlink), withStdout(stdout))
+			c.diff.sourceRefs = []string{tc.sourceRef}
+			require.NoError(t, c.runDiffCmd(nil, nil))
+			for _, s := range tc.contains {
+				assert.Contains(t, stdout.String(), s)
+			}
+			for _, s := range tc.notContains {
+				assert.NotContains(t, stdout.String(), s)
+			}
+		})
+	}
+}
+
+func TestDiffRedactsSecrets(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".netrc": "password old\n",
+			".local/share/chezmoi": map[string]interface{}{
+				"dot_netrc.tmpl": "password {{ secret \"hunter2-secret\" | toString }}\n",
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	newSecretTestConfig := func(stdout *bytes.Buffer) *Config {
+		c := newTestConfig(
+			fs,
+			withDestDir("/home/user"),
+			withStdout(stdout),
+			withGenericSecretCmdConfig(genericSecretCmdConfig{
+				Command: "echo",
+			}),
+		)
+		c.addSecretTemplateFunc("secret", c.secretFunc)
+		return c
+	}
+
+	stdout := &bytes.Buffer{}
+	c := newSecretTestConfig(stdout)
+	require.NoError(t, c.runDiffCmd(nil, nil))
+	assert.Contains(t, stdout.String(), "+password [redacted]\n")
+	assert.NotContains(t, stdout.String(), "hunter2-secret")
+
+	stdout.Reset()
+	c = newSecretTestConfig(stdout)
+	c.dump.format = "json"
+	c.dump.recursive = true
+	require.NoError(t, c.runDumpCmd(nil, nil))
+	assert.Contains(t, stdout.String(), "password [redacted]")
+	assert.NotContains(t, stdout.String(), "hunter2-secret")
+
+	stdout.Reset()
+	c = newSecretTestConfig(stdout)
+	require.NoError(t, c.runCatCmd(nil, []string{"/home/user/.netrc"}))
+	assert.Equal(t, "password [redacted]\n", stdout.String())
+
+	stdout.Reset()
+	c = newSecretTestConfig(stdout)
+	c.showSecrets = true
+	require.NoError(t, c.runDiffCmd(nil, nil))
+	assert.Contains(t, stdout.String(), "+password hunter2-secret\n")
+}
@@ -1,4 +1,5 @@
 // Code generated by github.com/twpayne/chezmoi/internal/generate-assets. DO NOT EDIT.
+//go:build !noembeddocs
 // +build !noembeddocs
 
 package cmd
@@ -465,8 +466,10 @@ func init() {
 		"  * [Use LastPass to keep your secrets](#use-lastpass-to-keep-your-secrets)\n" +
 		"  * [Use 1Password to keep your secrets](#use-1password-to-keep-your-secrets)\n" +
 		"  * [Use pass to keep your secrets](#use-pass-to-keep-your-secrets)\n" +
+		"  * [Use sops to keep your secrets](#use-sops-to-keep-your-secrets)\n" +
 		"  * [Use Vault to keep your secrets](#use-vault-to-keep-your-secrets)\n" +
 		"  * [Use a generic tool to keep your secrets](#use-a-generic-tool-to-keep-your-secrets)\n" +
+		"  * [Write a secret plugin to keep your secrets](#write-a-secret-plugin-to-keep-your-secrets)\n" +
 		"  * [Use templates variables to keep your secrets](#use-templates-variables-to-keep-your-secrets)\n" +
 		"* [Use scripts to perform actions](#use-scripts-to-perform-actions)\n" +
 		"  * [Understand how scripts work](#understand-how-scripts-work)\n" +
@@ -604,7 +607,8 @@ func init() {
 		"\n" +
 		"The `--autotemplate` flag to `chezmoi add` above instructs chezmoi to generate a\n" +
 		"template by substituting variables from the `data` section of your\n" +
-		"`~/.config/chezmoi/chezmoi.toml` file.\n" +
+		"`~/.config/chezmoi/chezmoi.toml` file. chezmoi prints each substitution that it\n" +
+		"makes. Add the `--prompt` flag to choose which substitutions to make.\n" +
 		"\n" +
 		"Templates are often used to capture machine-specifc differences. For example, in\n" +
 		"your `~/.local/share/chezmoi/dot_bashrc.tmpl` you might have:\n" +
@@ -896,6 +900,22 @@ func init() {
 		"\n" +
 		"    gpg --armor --symmetric\n" +
 		"\n" +
+		"#### Encrypt individual values\n" +
+		"\n" +
+		"To keep a single secret in an otherwise readable template, encrypt just the\n" +
+		"secret with `chezmoi encrypt`:\n" +
+		"\n" +
+		"    echo -n \"my-password\" | chezmoi encrypt\n" +
+		"\n" +
+		"and paste the ASCII-armored output into your template as the argument to the\n" +
+		"`decrypt` template function:\n" +
+		"\n" +
+		"    password = {{ decrypt `-----BEGIN PGP MESSAGE-----\n" +
+		"    ...\n" +
+		"    -----END PGP MESSAGE-----` }}\n" +
+		"\n" +
+		"`chezmoi decrypt` decrypts ASCII-armored ciphertext read from stdin.\n" +
+		"\n" +
 		"### Use KeePassXC to keep your secrets\n" +
 		"\n" +
 		"chezmoi includes support for [KeePassXC](https://keepassxc.org) using the\n" +
@@ -1030,6 +1050,42 @@ func init() {
 		"\n" +
 		"    {{ pass \"<pass-name>\" }}\n" +
 		"\n" +
+		"### Use sops to keep your secrets\n" +
+		"\n" +
+		"chezmoi can read data files encrypted with [sops](https://github.com/mozilla/sops)\n" +
+		"using age or PGP keys. Encrypt your secrets with sops and save them as\n" +
+		"`.chezmoidata.sops.json`, `.chezmoidata.sops.yaml`, or `.chezmoidata.sops.yml`\n" +
+		"in the root of your source directory, for example:\n" +
+		"\n" +
+		"    sops --encrypt --pgp <fingerprint> secrets.yaml > ~/.local/share/chezmoi/.chezmoidata.sops.yaml\n" +
+		"\n" +
+		"The decrypted data is available as template data, in the same way as the `data`\n" +
+		"section of your configuration file:\n" +
+		"\n" +
+		"    {{ .github.token }}\n" +
+		"\n" +
+		"To read other sops-encrypted files, list them in `sops.files` in your\n" +
+		"configuration file. Relative paths are relative to the source directory. To\n" +
+		"keep the decrypted data separate from the rest of your template data, set\n" +
+		"`sops.dataKey`:\n" +
+		"\n" +
+		"    [sops]\n" +
+		"      dataKey = \"secrets\"\n" +
+		"      files = [\"work.sops.yaml\"]\n" +
+		"\n" +
+		"The data would then be available as `{{ .secrets.github.token }}`.\n" +
+		"\n" +
+		"chezmoi decrypts each file only when a template first uses its data, so\n" +
+		"commands that do not use the data do not need your key. The data key is\n" +
+		"decrypted with `gpg` for PGP keys and with `age` for age keys, using the\n" +
+		"identities in `$SOPS_AGE_KEY_FILE` or `~/.config/sops/age/keys.txt`, like sops.\n" +
+		"The decrypted data is never written to disk, and it is not included in the\n" +
+		"output of `chezmoi facts export`.\n" +
+		"\n" +
+		"The `sops` template function decrypts any sops-encrypted document, for example:\n" +
+		"\n" +
+		"    {{ (include \".secrets.sops.yaml\" | sops).github.token }}\n" +
+		"\n" +
 		"### Use Vault to keep your secrets\n" +
 		"\n" +
 		"chezmoi includes support for [Vault](https://www.vaultproject.io/) using the\n" +
@@ -1048,6 +1104,15 @@ func init() {
 		"\n" +
 		"    {{ (vault \"<key>\").data.data.password }}\n" +
 		"\n" +
+		"If you do not have the Vault CLI installed, chezmoi can use Vault's HTTP API\n" +
+		"directly instead. Set `vault.client` to `http` in your configuration file:\n" +
+		"\n" +
+		"    [vault]\n" +
+		"      client = \"http\"\n" +
+		"\n" +
+		"chezmoi uses the same `VAULT_ADDR`, `VAULT_NAMESPACE`, and `VAULT_TOKEN`\n" +
+		"environment variables and `~/.vault-token` file as the Vault CLI.\n" +
+		"\n" +
 		"### Use a generic tool to keep your secrets\n" +
 		"\n" +
 		"You can use any command line tool that outputs secrets either as a string or in\n" +
@@ -1066,6 +1131,49 @@ func init() {
 		"| KeePassXC       | `keepassxc-cli`         | Not possible (interactive command only)           |\n" +
 		"| pass            | `pass`                  | `{{ secret \"show\" <id> }}`                        |\n" +
 		"\n" +
+		"### Write a secret plugin to keep your secrets\n" +
+		"\n" +
+		"If your secret manager is not supported, you can write a plugin for it. A\n" +
+		"plugin is an executable called `chezmoi-secret-<name>` in your `$PATH`, where\n" +
+		"*name* contains only letters, digits, and underscores. chezmoi adds the `<name>`\n" +
+		"and `<name>JSON` template functions, and the `chezmoi secret <name>` command,\n" +
+		"for each plugin that it finds. `chezmoi doctor` lists the plugins found and\n" +
+		"checks that each one is healthy.\n" +
+		"\n" +
+		"For each request, chezmoi runs the plugin with no arguments and writes the\n" +
+		"request as a single line of JSON to its standard input, for example:\n" +
+		"\n" +
+		"    {\"version\":1,\"command\":\"get\",\"args\":[\"github-token\"]}\n" +
+		"\n" +
+		"The plugin must write a single JSON object to its standard output. On success,\n" +
+		"the object has a `value` field containing the result. On failure, the object has\n" +
+		"an `error` field containing an error message, for example:\n" +
+		"\n" +
+		"    {\"value\":\"ghp_xxxxxxxxxxxxxxxx\"}\n" +
+		"    {\"error\":\"github-token: not found\"}\n" +
+		"\n" +
+		"The plugin's standard error is passed through to the terminal. The commands are:\n" +
+		"\n" +
+		"| Command    | Value                                                            |\n" +
+		"| ---------- | ---------------------------------------------------------------- |\n" +
+		"| `get`      | The secret identified by `args`, as a string                     |\n" +
+		"| `get-json` | The secret identified by `args`, as any JSON value               |\n" +
+		"| `health`   | A short status message, or an error if the plugin cannot be used |\n" +
+		"| `list`     | The identifiers of the available secrets, as a list of strings   |\n" +
+		"\n" +
+		"The template functions return the results of `get` and `get-json`, for example:\n" +
+		"\n" +
+		"    {{ mystore \"github-token\" }}\n" +
+		"    {{ (mystoreJSON \"login\").password }}\n" +
+		"\n" +
+		"and all commands can be run directly, for example:\n" +
+		"\n" +
+		"    chezmoi secret mystore list\n" +
+		"    chezmoi secret mystore get github-token\n" +
+		"\n" +
+		"Set `secretPlugin.cacheTTL` to cache the results of `get` and `get-json` in the\n" +
+		"secret cache.\n" +
+		"\n" +
 		"### Use templates variables to keep your secrets\n" +
 		"\n" +
 		"Typically, `~/.config/chezmoi/chezmoi.toml` is not checked in to version control\n" +
@@ -1210,6 +1318,17 @@ func init() {
 		"of the `~/.bashrc` symlink, rather than the symlink itself. When you run\n" +
 		"`chezmoi apply`, chezmoi will replace the `~/.bashrc` symlink with the file\n" +
 		"contents.\n" +
+		"\n" +
+		"If you prefer to keep symlinks so that edits to files in your home directory\n" +
+		"are made directly in the source directory, set the `mode` configuration\n" +
+		"variable to `symlink`:\n" +
+		"\n" +
+		"    mode = \"symlink\"\n" +
+		"\n" +
+		"In symlink mode, `chezmoi apply` replaces each regular file that is not\n" +
+		"encrypted, executable, private, or a template with a symlink to its file in the\n" +
+		"source directory. All other files are copied as normal. `chezmoi diff` and\n" +
+		"`chezmoi verify` consider such symlinks to be in the target state.\n" +
 		"\n")
 	assets["docs/INSTALL.md"] = []byte("" +
 		"# chezmoi Install Guide\n" +
@@ -1398,7 +1517,9 @@ func init() {
 		"  * [`-f`, `--follow`](#-f---follow)\n" +
 		"  * [`-n`, `--dry-run`](#-n---dry-run)\n" +
 		"  * [`-h`, `--help`](#-h---help)\n" +
+		"  * [`--no-cache`](#--no-cache)\n" +
 		"  * [`-r`. `--remove`](#-r---remove)\n" +
+		"  * [`--show-secrets`](#--show-secrets)\n" +
 		"  * [`-S`, `--source` *directory*](#-s---source-directory)\n" +
 		"  * [`-v`, `--verbose`](#-v---verbose)\n" +
 		"  * [`--version`](#--version)\n" +
@@ -1407,63 +1528,91 @@ func init() {
 		"* [Source state attributes](#source-state-attributes)\n" +
 		"* [Special files and directories](#special-files-and-directories)\n" +
 		"  * [`.chezmoi.<format>.tmpl`](#chezmoiformattmpl)\n" +
+		"  * [`.chezmoiallowsecrets`](#chezmoiallowsecrets)\n" +
+		"  * [`.chezmoidata.sops.<format>`](#chezmoidatasopsformat)\n" +
 		"  * [`.chezmoiignore`](#chezmoiignore)\n" +
+		"  * [`.chezmoiprofiles`](#chezmoiprofiles)\n" +
 		"  * [`.chezmoiremove`](#chezmoiremove)\n" +
 		"  * [`.chezmoitemplates`](#chezmoitemplates)\n" +
+		"  * [`.chezmoitriggers`](#chezmoitriggers)\n" +
 		"  * [`.chezmoiversion`](#chezmoiversion)\n" +
 		"* [Commands](#commands)\n" +
 		"  * [`add` *targets*](#add-targets)\n" +
 		"  * [`apply` [*targets*]](#apply-targets)\n" +
 		"  * [`archive`](#archive)\n" +
+		"  * [`cache` `clear`](#cache-clear)\n" +
 		"  * [`cat` targets](#cat-targets)\n" +
 		"  * [`cd`](#cd)\n" +
 		"  * [`chattr` *attributes* *targets*](#chattr-attributes-targets)\n" +
 		"  * [`completion` *shell*](#completion-shell)\n" +
 		"  * [`data`](#data)\n" +
+		"  * [`decrypt`](#decrypt)\n" +
 		"  * [`diff` [*targets*]](#diff-targets)\n" +
 		"  * [`docs` [*regexp*]](#docs-regexp)\n" +
 		"  * [`doctor`](#doctor)\n" +
 		"  * [`dump` [*targets*]](#dump-targets)\n" +
 		"  * [`edit` [*targets*]](#edit-targets)\n" +
 		"  * [`edit-config`](#edit-config)\n" +
+		"  * [`encrypt`](#encrypt)\n" +
 		"  * [`execute-template` [*templates*]](#execute-template-templates)\n" +
+		"  * [`facts` `export`](#facts-export)\n" +
 		"  * [`forget` *targets*](#forget-targets)\n" +
 		"  * [`git` [*arguments*]](#git-arguments)\n" +
 		"  * [`help` *command*](#help-command)\n" +
 		"  * [`hg` [*arguments]](#hg-arguments)\n" +
+		"  * [`history`](#history)\n" +
+		"  * [`ignored`](#ignored)\n" +
 		"  * [`init` [*repo*]](#init-repo)\n" +
 		"  * [`import` *filename*](#import-filename)\n" +
+		"  * [`lint`](#lint)\n" +
 		"  * [`manage` *targets*](#manage-targets)\n" +
+		"  * [`managed`](#managed)\n" +
 		"  * [`merge` *targets*](#merge-targets)\n" +
+		"  * [`mv` *target* *destination*](#mv-target-destination)\n" +
 		"  * [`purge`](#purge)\n" +
 		"  * [`remove` *targets*](#remove-targets)\n" +
 		"  * [`rm` *targets*](#rm-targets)\n" +
 		"  * [`secret`](#secret)\n" +
 		"  * [`source` [*args*]](#source-args)\n" +
 		"  * [`source-path` [*targets*]](#source-path-targets)\n" +
+		"  * [`target-path` [*source-paths*]](#target-path-source-paths)\n" +
 		"  * [`unmanage` *targets*](#unmanage-targets)\n" +
 		"  * [`unmanaged`](#unmanaged)\n" +
 		"  * [`update`](#update)\n" +
 		"  * [`upgrade`](#upgrade)\n" +
 		"  * [`verify` [*targets*]](#verify-targets)\n" +
+		"  * [`watch`](#watch)\n" +
 		"* [Editor configuration](#editor-configuration)\n" +
 		"* [Umask configuration](#umask-configuration)\n" +
+		"* [Script execution](#script-execution)\n" +
 		"* [Template execution](#template-execution)\n" +
 		"* [Template variables](#template-variables)\n" +
 		"* [Template functions](#template-functions)\n" +
 		"  * [`bitwarden` [*args*]](#bitwarden-args)\n" +
+		"  * [`decrypt` *ciphertext*](#decrypt-ciphertext)\n" +
+		"  * [`fromJson` *string*](#fromjson-string)\n" +
+		"  * [`fromToml` *string*](#fromtoml-string)\n" +
+		"  * [`fromYaml` *string*](#fromyaml-string)\n" +
 		"  * [`gopass` *gopass-name*](#gopass-gopass-name)\n" +
+		"  * [`include` *filename*](#include-filename)\n" +
+		"  * [`joinPath` *elements*](#joinpath-elements)\n" +
 		"  * [`keepassxc` *entry*](#keepassxc-entry)\n" +
 		"  * [`keepassxcAttribute` *entry* *attribute*](#keepassxcattribute-entry-attribute)\n" +
 		"  * [`keyring` *service* *user*](#keyring-service-user)\n" +
 		"  * [`lastpass` *id*](#lastpass-id)\n" +
 		"  * [`lastpassRaw` *id*](#lastpassraw-id)\n" +
+		"  * [`lookPath` *file*](#lookpath-file)\n" +
 		"  * [`onepassword` *uuid*](#onepassword-uuid)\n" +
 		"  * [`onepasswordDocument` *uuid*](#onepassworddocument-uuid)\n" +
+		"  * [`output` *name* [*args*]](#output-name-args)\n" +
 		"  * [`pass` *pass-name*](#pass-pass-name)\n" +
 		"  * [`promptString` *prompt*](#promptstring-prompt)\n" +
 		"  * [`secret` [*args*]](#secret-args)\n" +
 		"  * [`secretJSON` [*args*]](#secretjson-args)\n" +
+		"  * [`sops` *document*](#sops-document)\n" +
+		"  * [`stat` *name*](#stat-name)\n" +
+		"  * [`toToml` *value*](#totoml-value)\n" +
+		"  * [`toYaml` *value*](#toyaml-value)\n" +
 		"  * [`vault` *key*](#vault-key)\n" +
 		"\n" +
 		"## Concepts\n" +
@@ -1526,10 +1675,26 @@ func init() {
 		"\n" +
 		"Print help.\n" +
 		"\n" +
+		"### `--no-cache`\n" +
+		"\n" +
+		"Do not read or write the template or secret caches. See [template\n" +
+		"execution](#template-execution).\n" +
+		"\n" +
 		"### `-r`. `--remove`\n" +
 		"\n" +
 		"Also remove targets according to `.chezmoiremove`.\n" +
 		"\n" +
+		"### `--show-secrets`\n" +
+		"\n" +
+		"Do not redact secrets from output. By default, the `cat`, `data`, `diff`, and\n" +
+		"`dump` commands, `update --preview`, and verbose mode replace values returned\n" +
+		"by secret manager template functions like `secret`, `onepassword`, or `vault`\n" +
+		"with `[redacted]`, and do not show the contents of files from `encrypted_` sources\n" +
+		"at all, so that their output can be safely shared. Values are only redacted\n" +
+		"where they appear unchanged, so secrets transformed in templates, for example\n" +
+		"by `b64enc`, are not redacted. Values shorter than four characters are never\n" +
+		"redacted.\n" +
+		"\n" +
 		"### `-S`, `--source` *directory*\n" +
 		"\n" +
 		"Use *directory* as the source directory.\n" +
@@ -1538,7 +1703,8 @@ func init() {
 		"\n" +
 		"Set verbose mode. In verbose mode, chezmoi prints the changes that it is making\n" +
 		"as approximate shell commands, and any differences in files between the target\n" +
-		"state and the destination set are printed as unified diffs.\n" +
+		"state and the destination set are printed as unified diffs. Secrets are\n" +
+		"redacted unless `--show-secrets` is also given.\n" +
 		"\n" +
 		"### `--version`\n" +
 		"\n" +
@@ -1558,36 +1724,57 @@ func init() {
 		"\n" +
 		"The following configuration variables are available:\n" +
 		"\n" +
-		"| Variable                | Type     | Default value             | Description                                         |\n" +
-		"| ----------------------- | -------- | ------------------------- | --------------------------------------------------- |\n" +
-		"| `bitwarden.command`     | string   | `bw`                      | Bitwarden CLI command                               |\n" +
-		"| `cd.command`            | string   | *none*                    | Shell to run in `cd` command                        |\n" +
-		"| `color`                 | string   | `auto`                    | Colorize diffs                                      |\n" +
-		"| `data`                  | any      | *none*                    | Template data                                       |\n" +
-		"| `destDir`               | string   | `~`                       | Destination directory                               |\n" +
-		"| `dryRun`                | bool     | `false`                   | Dry run mode                                        |\n" +
-		"| `follow`                | bool     | `false`                   | Follow symlinks                                     |\n" +
-		"| `genericSecret.command` | string   | *none*                    | Generic secret command                              |\n" +
-		"| `gopass.command`        | string   | `gopass`                  | gopass CLI command                                  |\n" +
-		"| `gpg.recipient`         | string   | *none*                    | GPG recipient                                       |\n" +
-		"| `gpg.symmetric`         | bool     | `false`                   | Use symmetric GPG encryption                        |\n" +
-		"| `keepassxc.args`        | []string | *none*                    | Extra args to KeePassXC CLI command                 |\n" +
-		"| `keepassxc.command`     | string   | `keepassxc-cli`           | KeePassXC CLI command                               |\n" +
-		"| `keepassxc.database`    | string   | *none*                    | KeePassXC database                                  |\n" +
-		"| `lastpass.command`      | string   | `lpass`                   | Lastpass CLI command                                |\n" +
-		"| `merge.args`            | []string | *none*                    | Extra args to 3-way merge command                   |\n" +
-		"| `merge.command`         | string   | `vimdiff`                 | 3-way merge command                                 |\n" +
-		"| `onepassword.command`   | string   | `op`                      | 1Password CLI command                               |\n" +
-		"| `pass.command`          | string   | `pass`                    | Pass CLI command                                    |\n" +
-		"| `remove`                | bool     | `false`                   | Remove targets                                      |\n" +
-		"| `sourceDir`             | string   | `~/.local/share/chezmoi`  | Source directory                                    |\n" +
-		"| `sourceVCS.autoCommit`  | bool     | `false`                   | Commit changes to the source state after any change |\n" +
-		"| `sourceVCS.autoPush`    | bool     | `false`                   | Push changes to the source state after any change   |\n" +
-		"| `sourceVCS.command`     | string   | `git`                     | Source version control system                       |\n" +
-		"| `template.options`      | []string | `[\"missingkey=error\"]`    | Template options                                    |\n" +
-		"| `umask`                 | int      | *from system*             | Umask                                               |\n" +
-		"| `vault.command`         | string   | `vault`                   | Vault CLI command                                   |\n" +
-		"| `verbose`               | bool     | `false`                   | Verbose mode                                        |\n" +
+		"| Variable                      | Type     | Default value            | Description                                               |\n" +
+		"| ----------------------------- | -------- | ------------------------ | --------------------------------------------------------- |\n" +
+		"| `autoTemplate.minMatchLength` | int      | `3`                      | Minimum length of values replaced by `add --autotemplate` |\n" +
+		"| `bitwarden.cacheTTL`          | duration | *none*                   | Time to cache Bitwarden CLI command output                |\n" +
+		"| `bitwarden.command`           | string   | `bw`                     | Bitwarden CLI command                                     |\n" +
+		"| `cd.command`                  | string   | *none*                   | Shell to run in `cd` command                              |\n" +
+		"| `color`                       | string   | `auto`                   | Colorize diffs                                            |\n" +
+		"| `data`                        | any      | *none*                   | Template data                                             |\n" +
+		"| `destDir`                     | string   | `~`                      | Destination directory                                     |\n" +
+		"| `dryRun`                      | bool     | `false`                  | Dry run mode                                              |\n" +
+		"| `follow`                      | bool     | `false`                  | Follow symlinks                                           |\n" +
+		"| `genericSecret.cacheTTL`      | duration | *none*                   | Time to cache generic secret command output               |\n" +
+		"| `genericSecret.command`       | string   | *none*                   | Generic secret command                                    |\n" +
+		"| `gopass.cacheTTL`             | duration | *none*                   | Time to cache gopass CLI command output                   |\n" +
+		"| `gopass.command`              | string   | `gopass`                 | gopass CLI command                                        |\n" +
+		"| `gpg.recipient`               | string   | *none*                   | GPG recipient                                             |\n" +
+		"| `gpg.symmetric`               | bool     | `false`                  | Use symmetric GPG encryption                              |\n" +
+		"| `interpreters.<ext>.args`     | []string | *see below*              | Extra args to the interpreter for *ext* scripts           |\n" +
+		"| `interpreters.<ext>.command`  | string   | *see below*              | Interpreter for *ext* scripts                             |\n" +
+		"| `keepassxc.args`              | []string | *none*                   | Extra args to KeePassXC CLI command                       |\n" +
+		"| `keepassxc.cacheTTL`          | duration | *none*                   | Time to cache KeePassXC CLI command output                |\n" +
+		"| `keepassxc.command`           | string   | `keepassxc-cli`          | KeePassXC CLI command                                     |\n" +
+		"| `keepassxc.database`          | string   | *none*                   | KeePassXC database                                        |\n" +
+		"| `lastpass.cacheTTL`           | duration | *none*                   | Time to cache Lastpass CLI command output                 |\n" +
+		"| `lastpass.command`            | string   | `lpass`                  | Lastpass CLI command                                      |\n" +
+		"| `merge.args`                  | []string | *none*                   | Extra args to 3-way merge command                         |\n" +
+		"| `merge.command`               | string   | `vimdiff`                | 3-way merge command                                       |\n" +
+		"| `mode`                        | string   | `file`                   | Mode, either `file` or `symlink`                          |\n" +
+		"| `onepassword.cacheTTL`        | duration | *none*                   | Time to cache 1Password CLI command output                |\n" +
+		"| `onepassword.command`         | string   | `op`                     | 1Password CLI command                                     |\n" +
+		"| `pass.cacheTTL`               | duration | *none*                   | Time to cache Pass CLI command output                     |\n" +
+		"| `pass.command`                | string   | `pass`                   | Pass CLI command                                          |\n" +
+		"| `remove`                      | bool     | `false`                  | Remove targets                                            |\n" +
+		"| `script.recordOutput`         | bool     | `true`                   | Record the output of scripts in their history             |\n" +
+		"| `script.timeout`              | duration | *none*                   | Maximum time to wait for each script                      |\n" +
+		"| `secretPlugin.cacheTTL`       | duration | *none*                   | Time to cache secret plugin output                        |\n" +
+		"| `sops.dataKey`                | string   | *none*                   | Key for data from sops-encrypted data files               |\n" +
+		"| `sops.files`                  | []string | *none*                   | Additional sops-encrypted data files                      |\n" +
+		"| `sourceDir`                   | string   | `~/.local/share/chezmoi` | Source directory                                          |\n" +
+		"| `sourceVCS.autoCommit`        | bool     | `false`                  | Commit changes to the source state after any change       |\n" +
+		"| `sourceVCS.autoPush`          | bool     | `false`                  | Push changes to the source state after any change         |\n" +
+		"| `sourceVCS.command`           | string   | `git`                    | Source version control system                             |\n" +
+		"| `template.options`            | []string | `[\"missingkey=error\"]`   | Template options                                          |\n" +
+		"| `umask`                       | int      | *from system*            | Umask                                                     |\n" +
+		"| `vault.address`               | string   | `$VAULT_ADDR`            | Vault server address, for the `http` client               |\n" +
+		"| `vault.cacheTTL`              | duration | *none*                   | Time to cache Vault output                                |\n" +
+		"| `vault.client`                | string   | `cli`                    | Vault client, either `cli` or `http`                      |\n" +
+		"| `vault.command`               | string   | `vault`                  | Vault CLI command                                         |\n" +
+		"| `vault.namespace`             | string   | `$VAULT_NAMESPACE`       | Vault namespace, for the `http` client                    |\n" +
+		"| `vault.tokenFile`             | string   | `~/.vault-token`         | Vault token file, for the `http` client                   |\n" +
+		"| `verbose`                     | bool     | `false`                  | Verbose mode                                              |\n" +
 		"\n" +
 		"In addition, a number of secret manager integrations add configuration\n" +
 		"variables. These are documented in the secret manager section.\n" +
@@ -1647,6 +1834,31 @@ func init() {
 		"    data:\n" +
 		"        email: \"{{ $email }}\"\n" +
 		"\n" +
+		"### `.chezmoiallowsecrets`\n" +
+		"\n" +
+		"If a file called `.chezmoiallowsecrets` exists in the source state then it is\n" +
+		"interpreted as a set of patterns of targets that `chezmoi add` will add without\n" +
+		"checking for possible secrets. It uses the same syntax as `.chezmoiignore`.\n" +
+		"\n" +
+		"#### `.chezmoiallowsecrets` examples\n" +
+		"\n" +
+		"    .config/gh/hosts.yml # contains only a public host name\n" +
+		"    .ssh/*.pub\n" +
+		"\n" +
+		"### `.chezmoidata.sops.<format>`\n" +
+		"\n" +
+		"If a file called `.chezmoidata.sops.json`, `.chezmoidata.sops.yaml`, or\n" +
+		"`.chezmoidata.sops.yml` exists in the root of the source directory then it is\n" +
+		"decrypted with sops and its data is added to the template data. Only documents\n" +
+		"encrypted with age or PGP keys are supported. The file is only decrypted when a\n" +
+		"template uses its data, and its data is not included in the output of `chezmoi\n" +
+		"facts export`. Additional files can be listed in `sops.files`, and the data can\n" +
+		"be placed under a single key by setting `sops.dataKey`.\n" +
+		"\n" +
+		"#### `.chezmoidata.sops.<format>` examples\n" +
+		"\n" +
+		"    sops --encrypt --age <recipient> secrets.yaml > ~/.local/share/chezmoi/.chezmoidata.sops.yaml\n" +
+		"\n" +
 		"### `.chezmoiignore`\n" +
 		"\n" +
 		"If a file called `.chezmoiignore` exists in the source state then it is\n" +
@@ -1682,6 +1894,16 @@ func init() {
 		"    .personal-file\n" +
 		"    {{- end }}\n" +
 		"\n" +
+		"### `.chezmoiprofiles`\n" +
+		"\n" +
+		"If a directory called `.chezmoiprofiles` exists in the root of the source state\n" +
+		"then the files in it are machine profiles, as written by `chezmoi facts export\n" +
+		"--profile`. A profile called *name* is stored in a file called *name* with the\n" +
+		"extension `.json`, `.toml`, `.yaml`, or `.yml`, and contains the template data\n" +
+		"of a machine, including its `.chezmoi` facts. Profiles are used by the\n" +
+		"`--profile` flag of the `archive`, `diff`, `dump`, and `execute-template`\n" +
+		"commands.\n" +
+		"\n" +
 		"### `.chezmoiremove`\n" +
 		"\n" +
 		"If a file called `.chezmoiremove` exists in the source state then it is\n" +
@@ -1706,6 +1928,29 @@ func init() {
 		"\n" +
 		"The target state of `.config` will be `bar`.\n" +
 		"\n" +
+		"### `.chezmoitriggers`\n" +
+		"\n" +
+		"If a file called `.chezmoitriggers` exists in the source state then it is\n" +
+		"interpreted as a list of commands to run when targets change. Each line contains\n" +
+		"a pattern followed by whitespace and a command. Patterns are matched using the\n" +
+		"same syntax as `.chezmoiignore` against the target path and all of its parent\n" +
+		"directories.\n" +
+		"\n" +
+		"After all targets have been applied, chezmoi runs, in order and at most once,\n" +
+		"the command of each trigger whose pattern matches a target that chezmoi\n" +
+		"modified. Commands are run with `sh -c` (`cmd.exe /c` on Windows) in the\n" +
+		"destination directory. In dry run and verbose modes the commands are printed. In\n" +
+		"dry run mode they are not run.\n" +
+		"\n" +
+		"Lines beginning with `#` are comments. `.chezmoitriggers` is interpreted as a\n" +
+		"template, and `.chezmoitriggers` files in subdirectories apply only to that\n" +
+		"subdirectory.\n" +
+		"\n" +
+		"#### `.chezmoitriggers` examples\n" +
+		"\n" +
+		"    .tmux.conf                 tmux source-file ~/.tmux.conf\n" +
+		"    .config/systemd/user/*     systemctl --user daemon-reload\n" +
+		"\n" +
 		"### `.chezmoiversion`\n" +
 		"\n" +
 		"If a file called `.chezmoiversion` exists, then its contents are interpreted as\n" +
@@ -1728,6 +1973,20 @@ func init() {
 		"then its source state is replaced with its current state in the destination\n" +
 		"directory. The `add` command accepts additional flags:\n" +
 		"\n" +
+		"#### `-a`, `--autotemplate`\n" +
+		"\n" +
+		"When adding files with `--template`, generate the template automatically by\n" +
+		"replacing template data values with template expressions that reference them.\n" +
+		"String, numeric, and list values are replaced where they occur as whole words,\n" +
+		"and longer values are replaced before shorter ones. Values shorter than the\n" +
+		"`autoTemplate.minMatchLength` configuration variable, three characters by\n" +
+		"default, are not replaced, so that small numbers like `1` are not replaced\n" +
+		"everywhere that they occur. Any existing `{{` and `}}` in the file are\n" +
+		"escaped. Each substitution is printed. If\n" +
+		"the `--prompt` flag is set, chezmoi instead prompts before making each\n" +
+		"substitution, with the choices `y` (replace), `n` (keep the value), and `a`\n" +
+		"(replace this and all remaining values without prompting).\n" +
+		"\n" +
 		"#### `-e`, `--empty`\n" +
 		"\n" +
 		"Set the `empty` attribute on added files.\n" +
@@ -1748,40 +2007,100 @@ func init() {
 		"\n" +
 		"Recursively add all files, directories, and symlinks.\n" +
 		"\n" +
+		"#### `--secrets` *mode*\n" +
+		"\n" +
+		"Set how to handle files that contain possible secrets. Before adding a file,\n" +
+		"chezmoi checks its contents for private keys, known access token formats,\n" +
+		"password assignments, and other high-entropy strings. *mode* must be one of:\n" +
+		"\n" +
+		"| Mode      | Effect                                                                     |\n" +
+		"| --------- | -------------------------------------------------------------------------- |\n" +
+		"| `error`   | Refuse to add the file                                                     |\n" +
+		"| `ignore`  | Do not check for possible secrets                                          |\n" +
+		"| `prompt`  | Prompt to add the file as is, skip it, encrypt it, or add it as a template |\n" +
+		"| `warning` | Print a warning and add the file                                           |\n" +
+		"\n" +
+		"The default is `prompt` if the standard input is a terminal, and `error`\n" +
+		"otherwise. When adding a file as a template, chezmoi prompts for a template\n" +
+		"expression, for example `pass \"github.com/token\"`, to replace each secret, and\n" +
+		"escapes any existing template delimiters in the file. Files added with\n" +
+		"`--encrypt` and targets matching a pattern in `.chezmoiallowsecrets` are not\n" +
+		"checked.\n" +
+		"\n" +
 		"#### `-T`, `--template`\n" +
 		"\n" +
-		"Set the `template` attribute on added files and symlinks. In addition, if the\n" +
-		"`--autotemplate` flag is set, chezmoi attempts to automatically generate the\n" +
-		"template by replacing any template data values with the equivalent template data\n" +
-		"keys. Longer subsitutions occur before shorter ones.\n" +
+		"Set the `template` attribute on added files and symlinks.\n" +
 		"\n" +
 		"#### `add` examples\n" +
 		"\n" +
 		"    chezmoi add ~/.bashrc\n" +
 		"    chezmoi add ~/.gitconfig --template\n" +
+		"    chezmoi add ~/.gitconfig --template --autotemplate --prompt\n" +
 		"    chezmoi add ~/.vim --recursive\n" +
 		"    chezmoi add ~/.oh-my-zsh --exact --recursive\n" +
+		"    chezmoi add ~/.netrc --secrets=error\n" +
+		"    chezmoi add ~/.netrc --secrets=prompt\n" +
 		"\n" +
 		"### `apply` [*targets*]\n" +
 		"\n" +
 		"Ensure that *targets* are in the target state, updating them if necessary. If no\n" +
 		"targets are specified, the state of all targets are ensured.\n" +
 		"\n" +
+		"#### `-i`, `--interactive`\n" +
+		"\n" +
+		"Show each change and prompt before making it. The choices are:\n" +
+		"\n" +
+		"| Choice | Action                                                         |\n" +
+		"| ------ | -------------------------------------------------------------- |\n" +
+		"| `y`    | Make the change                                                |\n" +
+		"| `n`    | Skip the change                                                |\n" +
+		"| `p`    | Choose which hunks of the diff to apply, one at a time         |\n" +
+		"| `m`    | Run the merge command, as with [`merge`](#merge-targets)       |\n" +
+		"| `q`    | Quit without making this or any further changes                |\n" +
+		"| `a`    | Make this and all further changes without prompting            |\n" +
+		"\n" +
+		"`p` is only offered for changes to existing text files with more than one hunk,\n" +
+		"and `m` only for files. When choosing hunks, `y` and `n` apply or skip the hunk,\n" +
+		"`a` applies it and all remaining hunks, `d` skips it and all remaining hunks, and\n" +
+		"`q` quits after writing the hunks chosen so far. Skipping the creation of a\n" +
+		"directory also skips its contents. Scripts are shown before they are run. A\n" +
+		"summary of the decisions is printed at the end.\n" +
+		"\n" +
 		"#### `apply` examples\n" +
 		"\n" +
 		"    chezmoi apply\n" +
 		"    chezmoi apply --dry-run --verbose\n" +
 		"    chezmoi apply ~/.bashrc\n" +
+		"    chezmoi apply --interactive\n" +
 		"\n" +
 		"### `archive`\n" +
 		"\n" +
 		"Write a tar archive of the target state to stdout. This can be piped into `tar`\n" +
 		"to inspect the target state.\n" +
 		"\n" +
+		"#### `--data-override` *file*\n" +
+		"\n" +
+		"Use the machine facts and template data in *file* instead of the current\n" +
+		"machine's. See [`facts export`](#facts-export).\n" +
+		"\n" +
+		"#### `--profile` *name*\n" +
+		"\n" +
+		"Use the machine facts and template data in the profile *name* instead of the\n" +
+		"current machine's. See [`facts export`](#facts-export).\n" +
+		"\n" +
 		"#### `archive` examples\n" +
 		"\n" +
 		"    chezmoi archive | tar tvf -\n" +
 		"\n" +
+		"### `cache` `clear`\n" +
+		"\n" +
+		"Remove all entries from the template cache. See [template\n" +
+		"execution](#template-execution).\n" +
+		"\n" +
+		"#### `cache` examples\n" +
+		"\n" +
+		"    chezmoi cache clear\n" +
+		"\n" +
 		"### `cat` targets\n" +
 		"\n" +
 		"Write the target state of *targets*  to stdout. *targets* must be files or\n" +
@@ -1840,8 +2159,10 @@ func init() {
 		"\n" +
 		"### `data`\n" +
 		"\n" +
-		"Write the computed template data in JSON format to stdout. The `data` command\n" +
-		"accepts additional flags:\n" +
+		"Write the computed template data in JSON format to stdout. Values from\n" +
+		"sops-encrypted data files are replaced with `[redacted]`, without being\n" +
+		"decrypted, unless `--show-secrets` is given. The `data` command accepts\n" +
+		"additional flags:\n" +
 		"\n" +
 		"#### `-f`, `--format` *format*\n" +
 		"\n" +
@@ -1853,17 +2174,51 @@ func init() {
 		"    chezmoi data\n" +
 		"    chezmoi data --format=yaml\n" +
 		"\n" +
+		"### `decrypt`\n" +
+		"\n" +
+		"Decrypt ASCII-armored ciphertext read from stdin with gpg and write the\n" +
+		"plaintext to stdout.\n" +
+		"\n" +
+		"#### `decrypt` examples\n" +
+		"\n" +
+		"    chezmoi decrypt < secret.asc\n" +
+		"\n" +
 		"### `diff` [*targets*]\n" +
 		"\n" +
 		"Print the approximate shell commands required to ensure that *targets* in the\n" +
 		"destination directory match the target state. If no targets are specified, print\n" +
 		"the commands required for all targets. It is equivalent to `chezmoi apply\n" +
-		"--dry-run --verbose`.\n" +
+		"--dry-run --verbose`. If either version of a file is binary or is larger than\n" +
+		"1MB then only the sizes and SHA256 sums of the two versions are printed.\n" +
+		"\n" +
+		"#### `--source-ref` *revision*\n" +
+		"\n" +
+		"Use the source state at the git *revision* instead of the working copy of the\n" +
+		"source directory. The source directory is not modified. If `--source-ref` is\n" +
+		"given twice then the target states at the two revisions are compared with each\n" +
+		"other, ignoring the destination directory. Changes to the contents of scripts\n" +
+		"are printed as diffs of the scripts themselves.\n" +
+		"Files read by the `include` template function and sops-encrypted data files\n" +
+		"in the source directory are also read at *revision*.\n" +
+		"In symlink mode, targets that link into the source directory are compared as\n" +
+		"the files that they link to, so changes to their contents are shown.\n" +
+		"\n" +
+		"#### `--data-override` *file*\n" +
+		"\n" +
+		"Use the machine facts and template data in *file* instead of the current\n" +
+		"machine's. See [`facts export`](#facts-export).\n" +
+		"\n" +
+		"#### `--profile` *name*\n" +
+		"\n" +
+		"Use the machine facts and template data in the profile *name* instead of the\n" +
+		"current machine's. See [`facts export`](#facts-export).\n" +
 		"\n" +
 		"#### `diff` examples\n" +
 		"\n" +
 		"    chezmoi diff\n" +
 		"    chezmoi diff ~/.bashrc\n" +
+		"    chezmoi diff --source-ref=origin/master\n" +
+		"    chezmoi diff --source-ref=HEAD~1 --source-ref=HEAD\n" +
 		"\n" +
 		"### `docs` [*regexp*]\n" +
 		"\n" +
@@ -1878,7 +2233,8 @@ func init() {
 		"\n" +
 		"### `doctor`\n" +
 		"\n" +
-		"Check for potential problems.\n" +
+		"Check for potential problems. Any secret plugins found are listed and asked\n" +
+		"whether they are healthy.\n" +
 		"\n" +
 		"#### `doctor` examples\n" +
 		"\n" +
@@ -1894,10 +2250,27 @@ func init() {
 		"Print the target state in the given format. The accepted formats are `json`\n" +
 		"(JSON) and `yaml` (YAML).\n" +
 		"\n" +
+		"#### `--source-ref` *revision*\n" +
+		"\n" +
+		"Dump the target state at the git *revision* instead of the working copy of the\n" +
+		"source directory.\n" +
+		"\n" +
+		"#### `--data-override` *file*\n" +
+		"\n" +
+		"Use the machine facts and template data in *file* instead of the current\n" +
+		"machine's. See [`facts export`](#facts-export).\n" +
+		"\n" +
+		"#### `--profile` *name*\n" +
+		"\n" +
+		"Use the machine facts and template data in the profile *name* instead of the\n" +
+		"current machine's. See [`facts export`](#facts-export).\n" +
+		"\n" +
 		"#### `dump` examples\n" +
 		"\n" +
 		"    chezmoi dump ~/.bashrc\n" +
 		"    chezmoi dump --format=yaml\n" +
+		"    chezmoi dump --profile=server ~/.bashrc\n" +
+		"    chezmoi dump --source-ref=HEAD~1 ~/.bashrc\n" +
 		"\n" +
 		"### `edit` [*targets*]\n" +
 		"\n" +
@@ -1932,6 +2305,16 @@ func init() {
 		"\n" +
 		"    chezmoi edit-config\n" +
 		"\n" +
+		"### `encrypt`\n" +
+		"\n" +
+		"Encrypt stdin with gpg using the configured `gpg.recipient` or symmetric\n" +
+		"encryption and write the ASCII-armored ciphertext to stdout. The ciphertext can\n" +
+		"be passed to the `decrypt` template function.\n" +
+		"\n" +
+		"#### `encrypt` examples\n" +
+		"\n" +
+		"    echo -n \"my-password\" | chezmoi encrypt\n" +
+		"\n" +
 		"### `execute-template` [*templates*]\n" +
 		"\n" +
 		"Write the result of evaluating *templates* to stdout. This is useful for testing\n" +
@@ -1939,11 +2322,22 @@ func init() {
 		"as literal template data, with no whitespace added to the output between\n" +
 		"arguments. If no templates are specified, the template data are read from stdin.\n" +
 		"\n" +
+		"#### `--data-override` *file*\n" +
+		"\n" +
+		"Use the machine facts and template data in *file* instead of the current\n" +
+		"machine's. See [`facts export`](#facts-export).\n" +
+		"\n" +
+		"#### `--profile` *name*\n" +
+		"\n" +
+		"Use the machine facts and template data in the profile *name* instead of the\n" +
+		"current machine's. See [`facts export`](#facts-export).\n" +
+		"\n" +
 		"#### `execute-template` examples\n" +
 		"\n" +
 		"    chezmoi execute-template '{{ .chezmoi.sourceDir }}'\n" +
 		"    chezmoi execute-template '{{ .chezmoi.os }}' / '{{ .chezmoi.arch }}'\n" +
 		"    echo '{{ .chezmoi | toJson }}' | chezmoi execute-template\n" +
+		"    chezmoi execute-template --data-override=laptop.yaml '{{ .chezmoi.os }}'\n" +
 		"\n" +
 		"### `forget` *targets*\n" +
 		"\n" +
@@ -1953,6 +2347,38 @@ func init() {
 		"\n" +
 		"    chezmoi forget ~/.bashrc\n" +
 		"\n" +
+		"### `facts` `export`\n" +
+		"\n" +
+		"Write the template data of the current machine, including the machine facts\n" +
+		"in `.chezmoi` such as `.chezmoi.os`, `.chezmoi.hostname`, and\n" +
+		"`.chezmoi.osRelease`, to stdout.\n" +
+		"\n" +
+		"The `archive`, `diff`, `dump`, and `execute-template` commands accept a\n" +
+		"`--data-override` *file* flag, which renders the target state as if on the\n" +
+		"machine whose data was exported to *file*, and a `--profile` *name* flag, which\n" +
+		"does the same with a profile stored in the `.chezmoiprofiles` directory in the\n" +
+		"source state. The exported data replace the current machine's data key by key,\n" +
+		"so the `.chezmoi` facts are replaced as a whole. The format of *file* is\n" +
+		"determined by its extension.\n" +
+		"\n" +
+		"#### `-f`, `--format` *format*\n" +
+		"\n" +
+		"Write the data in the given format. The accepted formats are `json` (JSON),\n" +
+		"`toml` (TOML), and `yaml` (YAML). The default is `yaml`.\n" +
+		"\n" +
+		"#### `--profile` *name*\n" +
+		"\n" +
+		"Write the data to the profile *name* in the `.chezmoiprofiles` directory in the\n" +
+		"source state, replacing any existing profile with the same name, instead of to\n" +
+		"stdout.\n" +
+		"\n" +
+		"#### `facts` examples\n" +
+		"\n" +
+		"    chezmoi facts export > laptop.yaml\n" +
+		"    chezmoi dump --data-override=laptop.yaml\n" +
+		"    chezmoi facts export --profile=server\n" +
+		"    chezmoi archive --profile=server | tar tvf -\n" +
+		"\n" +
 		"### `git` [*arguments*]\n" +
 		"\n" +
 		"Run `git` *arguments* in the source directory. Note that flags in *arguments*\n" +
@@ -1977,6 +2403,59 @@ func init() {
 		"\n" +
 		"    chezmoi hg -- pull --rebase --update\n" +
 		"\n" +
+		"### `history`\n" +
+		"\n" +
+		"Print the history of script runs and applies, oldest first. Each entry records\n" +
+		"when the script run or apply started, how long it took, its exit code, and\n" +
+		"whether it failed or timed out. The stdout and stderr of scripts are also\n" +
+		"recorded, up to 64KB each, unless the `script.recordOutput` configuration\n" +
+		"variable is `false`. The most recent 1000 entries are kept.\n" +
+		"\n" +
+		"#### `-f`, `--format` *format*\n" +
+		"\n" +
+		"Print the history in the given format. The accepted formats are `table` (the\n" +
+		"default) and `json`.\n" +
+		"\n" +
+		"#### `--limit` *n*\n" +
+		"\n" +
+		"Print only the *n* most recent entries.\n" +
+		"\n" +
+		"#### `-o`, `--output`\n" +
+		"\n" +
+		"Include the stdout and stderr of scripts.\n" +
+		"\n" +
+		"#### `-t`, `--type` *type*\n" +
+		"\n" +
+		"Print only entries of type *type*, either `apply` or `script`.\n" +
+		"\n" +
+		"#### `history` examples\n" +
+		"\n" +
+		"    chezmoi history\n" +
+		"    chezmoi history --type=script --limit=5 --output\n" +
+		"    chezmoi history --format=json\n" +
+		"\n" +
+		"### `ignored`\n" +
+		"\n" +
+		"List the targets in the source state that are ignored by `.chezmoiignore`. The\n" +
+		"contents of ignored directories are not listed.\n" +
+		"\n" +
+		"#### `-f`, `--format` *format*\n" +
+		"\n" +
+		"Print the paths in the given format, either `json` or `yaml`. By default, paths\n" +
+		"are printed one per line.\n" +
+		"\n" +
+		"#### `-p`, `--path-style` *style*\n" +
+		"\n" +
+		"Print paths in the given style. `relative` (the default) prints paths relative\n" +
+		"to the destination directory, `absolute` prints absolute paths in the\n" +
+		"destination directory, and `source` prints absolute paths in the source\n" +
+		"directory.\n" +
+		"\n" +
+		"#### `ignored` examples\n" +
+		"\n" +
+		"    chezmoi ignored\n" +
+		"    chezmoi ignored --path-style=source\n" +
+		"\n" +
 		"### `init` [*repo*]\n" +
 		"\n" +
 		"Setup the source directory and update the destination directory to match the\n" +
@@ -2022,10 +2501,70 @@ func init() {
 		"    curl -s -L -o oh-my-zsh-master.tar.gz https://github.com/robbyrussell/oh-my-zsh/archive/master.tar.gz\n" +
 		"    chezmoi import --strip-components 1 --destination ~/.oh-my-zsh oh-my-zsh-master.tar.gz\n" +
 		"\n" +
+		"### `lint`\n" +
+		"\n" +
+		"Check the source state for problems and report all of them, with their source\n" +
+		"paths and, where possible, line and column numbers. `lint` exits with a non-zero\n" +
+		"status if any problems are found.\n" +
+		"\n" +
+		"Every template is parsed and executed, including files, symlinks, and scripts\n" +
+		"with the `template` attribute, `.chezmoiignore`, `.chezmoiremove`,\n" +
+		"`.chezmoiallowsecrets`, `.chezmoitriggers`, and the config file template.\n" +
+		"Templates in `.chezmoitemplates` are parsed and executed by the templates that\n" +
+		"use them. Secret functions, such as `pass` and `bitwarden`, secret plugins,\n" +
+		"`output`, and `promptString` are replaced by stubs that return empty values, so\n" +
+		"no secret managers are accessed, no commands are run, and no input is needed.\n" +
+		"Values from sops-encrypted data files are likewise stubbed and never decrypted,\n" +
+		"and lint assumes that they have every key. Encrypted templates are decrypted.\n" +
+		"\n" +
+		"In addition, `lint` reports:\n" +
+		"\n" +
+		"* References to template data keys that are not defined, for example a typo in\n" +
+		"  `{{ .chezmoi.hostnme }}`. Only references to the top-level data are checked.\n" +
+		"* Templates in `.chezmoitemplates` that are never used.\n" +
+		"* Files and directories in the source state whose names begin with a `.` and\n" +
+		"  that are therefore ignored, other than special files and version control\n" +
+		"  files such as `.git`.\n" +
+		"\n" +
+		"#### `lint` examples\n" +
+		"\n" +
+		"    chezmoi lint\n" +
+		"\n" +
 		"### `manage` *targets*\n" +
 		"\n" +
 		"`manage` is an alias for `add` for symmetry with `unmanage`.\n" +
 		"\n" +
+		"### `managed`\n" +
+		"\n" +
+		"List the managed targets, i.e. the targets in the source state that are not\n" +
+		"ignored, sorted by target path.\n" +
+		"\n" +
+		"#### `-f`, `--format` *format*\n" +
+		"\n" +
+		"Print the paths in the given format, either `json` or `yaml`. By default, paths\n" +
+		"are printed one per line.\n" +
+		"\n" +
+		"#### `-p`, `--path-style` *style*\n" +
+		"\n" +
+		"Print paths in the given style. `relative` (the default) prints paths relative\n" +
+		"to the destination directory, `absolute` prints absolute paths in the\n" +
+		"destination directory, and `source` prints absolute paths in the source\n" +
+		"directory.\n" +
+		"\n" +
+		"#### `-i`, `--include` *types*\n" +
+		"\n" +
+		"Only list entries of the given comma-separated types. The types are `dirs`,\n" +
+		"`files`, `symlinks`, and `scripts`, which are all included by default, and\n" +
+		"`encrypted` and `templates`, which include encrypted files and templates\n" +
+		"respectively.\n" +
+		"\n" +
+		"#### `managed` examples\n" +
+		"\n" +
+		"    chezmoi managed\n" +
+		"    chezmoi managed --include=files,symlinks\n" +
+		"    chezmoi managed --include=templates --path-style=source\n" +
+		"    chezmoi managed --format=json\n" +
+		"\n" +
 		"### `merge` *targets*\n" +
 		"\n" +
 		"Perform a three-way merge between the destination state, the source state, and\n" +
@@ -2039,6 +2578,20 @@ func init() {
 		"\n" +
 		"    chezmoi merge ~/.bashrc\n" +
 		"\n" +
+		"### `mv` *target* *destination*\n" +
+		"\n" +
+		"Move *target* to *destination* in both the source state and the destination\n" +
+		"directory. If *destination* is an existing directory then *target* is moved into\n" +
+		"it. The new source name keeps all of *target*'s attributes, for example whether\n" +
+		"it is private, executable, encrypted, or a template. Any parent directories of\n" +
+		"*destination* that are not already in the source state are added to it. If the\n" +
+		"source directory is a git repository and *target*'s source file is tracked then\n" +
+		"it is moved with `git mv`.\n" +
+		"\n" +
+		"#### `mv` examples\n" +
+		"\n" +
+		"    chezmoi mv ~/.vimrc ~/.config/nvim/init.vim\n" +
+		"\n" +
 		"### `purge`\n" +
 		"\n" +
 		"Remove chezmoi's configuration, state, and source directory, but leave the\n" +
@@ -2077,9 +2630,19 @@ func init() {
 		"\n" +
 		"    chezmoi secret help\n" +
 		"\n" +
+		"Each secret plugin, an executable called `chezmoi-secret-<name>` in your\n" +
+		"`$PATH`, adds a `chezmoi secret <name>` command, which sends its arguments to the\n" +
+		"plugin as a request and prints the result. The plugin protocol is described in\n" +
+		"the how-to guide.\n" +
+		"\n" +
+		"`chezmoi secret cache clear` removes all secret manager outputs from the secret\n" +
+		"cache, and all outputs of templates that call secret manager functions from the\n" +
+		"template cache. See [template execution](#template-execution).\n" +
+		"\n" +
 		"#### `secret` examples\n" +
 		"\n" +
 		"    chezmoi secret bitwarden list items\n" +
+		"    chezmoi secret cache clear\n" +
 		"    chezmoi secret keyring set --service service --user user\n" +
 		"    chezmoi secret keyring get --service service --user user\n" +
 		"    chezmoi secret lastpass ls\n" +
@@ -2111,6 +2674,21 @@ func init() {
 		"    chezmoi source-path\n" +
 		"    chezmoi source-path ~/.bashrc\n" +
 		"\n" +
+		"### `target-path` [*source-paths*]\n" +
+		"\n" +
+		"Print the target path of each source path. This is the reverse of\n" +
+		"`source-path`. Source paths do not need to exist and can be files, directories,\n" +
+		"scripts, templates, or encrypted files. Special files and directories in the\n" +
+		"source directory, i.e. those beginning with a `.` such as `.chezmoiignore`, do\n" +
+		"not have target paths and cause an error. If no source paths are specified then\n" +
+		"print the destination directory.\n" +
+		"\n" +
+		"#### `target-path` examples\n" +
+		"\n" +
+		"    chezmoi target-path\n" +
+		"    chezmoi target-path ~/.local/share/chezmoi/dot_bashrc\n" +
+		"    chezmoi target-path ~/.local/share/chezmoi/private_dot_ssh/encrypted_private_id_rsa.tmpl\n" +
+		"\n" +
 		"### `unmanage` *targets*\n" +
 		"\n" +
 		"`unmanage` is an alias for `forget` for symmetry with `manage`.\n" +
@@ -2127,9 +2705,31 @@ func init() {
 		"\n" +
 		"Pull changes from the source VCS and apply any changes.\n" +
 		"\n" +
+		"#### `-a`, `--apply`\n" +
+		"\n" +
+		"Apply changes after pulling, `true` by default. Use `--apply=false` to only\n" +
+		"pull.\n" +
+		"\n" +
+		"#### `--preview`\n" +
+		"\n" +
+		"Fetch changes from the source VCS without merging them and print the incoming\n" +
+		"commits, the diff that they would make to the destination directory, and the\n" +
+		"scripts that they would run, including `run_once_` scripts that have not yet\n" +
+		"been run. You are then asked whether to merge and apply the changes. The diff\n" +
+		"is computed from a temporary copy of the fetched revision, so the source\n" +
+		"directory is not modified unless you continue. If you continue, exactly the\n" +
+		"previewed revision is integrated, instead of pulling again, so changes pushed\n" +
+		"after the preview are not merged. The revision is integrated as\n" +
+		"`sourceVCS.pull` would: with `git rebase` if the pull arguments include\n" +
+		"`--rebase`, as they do by default, otherwise with `git merge`, passing\n" +
+		"`--ff`, `--ff-only`, `--no-ff`, `--autostash`, and `--no-autostash`. If you\n" +
+		"have local commits that are not in the fetched revision, the preview shows the\n" +
+		"fetched revision without them. Only supported for git.\n" +
+		"\n" +
 		"#### `update` examples\n" +
 		"\n" +
 		"    chezmoi update\n" +
+		"    chezmoi update --preview\n" +
 		"\n" +
 		"### `upgrade`\n" +
 		"\n" +
@@ -2164,6 +2764,34 @@ func init() {
 		"    chezmoi verify\n" +
 		"    chezmoi verify ~/.bashrc\n" +
 		"\n" +
+		"### `watch`\n" +
+		"\n" +
+		"Watch the source directory for changes and apply the affected targets as soon\n" +
+		"as they change. Changes are printed in the same format as the `--verbose` flag.\n" +
+		"Changes to `.chezmoiignore`, `.chezmoiremove`, `.chezmoitemplates`, and\n" +
+		"`.chezmoiversion` cause all targets to be applied. Changes to other files and\n" +
+		"directories beginning with a `.`, like `.git`, are ignored. Changes to the\n" +
+		"configuration file are not noticed, restart `chezmoi watch` to pick them up.\n" +
+		"Press Ctrl-C to stop watching.\n" +
+		"\n" +
+		"#### `--debounce` *duration*\n" +
+		"\n" +
+		"Wait until no changes have occurred for *duration* before applying them. The\n" +
+		"default is `250ms`.\n" +
+		"\n" +
+		"#### `--drift` *action*\n" +
+		"\n" +
+		"Also watch managed targets in the destination directory for changes made by\n" +
+		"other programs. *action* can be `ignore`, `report`, or `apply`. `report` prints\n" +
+		"the changes that would be needed to restore the target state, and `apply`\n" +
+		"restores it. The default is `ignore`.\n" +
+		"\n" +
+		"#### `watch` examples\n" +
+		"\n" +
+		"    chezmoi watch\n" +
+		"    chezmoi watch --drift=report\n" +
+		"    chezmoi watch --dry-run --drift=report\n" +
+		"\n" +
 		"## Editor configuration\n" +
 		"\n" +
 		"The `edit` and `edit-config` commands use the editor specified by the `VISUAL`\n" +
@@ -2180,6 +2808,52 @@ func init() {
 		"For machine-specific control of umask, set the `umask` configuration variable in\n" +
 		"chezmoi's configuration file.\n" +
 		"\n" +
+		"## Script execution\n" +
+		"\n" +
+		"Scripts are written to a temporary file and run in the directory in the\n" +
+		"destination directory that contains the script's target or, if that does not\n" +
+		"exist, its closest existing parent. Scripts inherit chezmoi's environment, plus\n" +
+		"the following variables:\n" +
+		"\n" +
+		"| Variable             | Value                                                |\n" +
+		"| -------------------- | ---------------------------------------------------- |\n" +
+		"| `CHEZMOI`            | `1`                                                  |\n" +
+		"| `CHEZMOI_ARCH`       | Architecture, e.g. `amd64`                           |\n" +
+		"| `CHEZMOI_DATA_FILE`  | Path to a JSON file containing template data         |\n" +
+		"| `CHEZMOI_DEST_DIR`   | Destination directory                                |\n" +
+		"| `CHEZMOI_OS`         | Operating system, e.g. `linux`                       |\n" +
+		"| `CHEZMOI_SOURCE_DIR` | Source directory                                     |\n" +
+		"\n" +
+		"The file in `CHEZMOI_DATA_FILE` contains all machine facts in `.chezmoi`, but\n" +
+		"does not contain secret values, such as those from sops-encrypted data files.\n" +
+		"\n" +
+		"Scripts whose extension has an entry in the `interpreters` configuration\n" +
+		"variable are run with that interpreter, unless, on systems other than Windows,\n" +
+		"they begin with a `#!` line. By default, `.py` scripts are run with `python3`,\n" +
+		"`.rb` scripts with `ruby`, and `.ps1` scripts with `pwsh -NoLogo -File`\n" +
+		"(`powershell` on Windows). Other scripts are executed directly. For example, to\n" +
+		"run `.pl` scripts with `perl`:\n" +
+		"\n" +
+		"    [interpreters.pl]\n" +
+		"      command = \"perl\"\n" +
+		"\n" +
+		"The exit code and duration of scripts are recorded for the\n" +
+		"[`history`](#history) command, as is their output, with any secrets redacted\n" +
+		"unless `--show-secrets` is given. The output is still printed to the terminal,\n" +
+		"but through a pipe, so programs run by scripts may not detect that they are\n" +
+		"writing to a terminal, for example to use colors. Set the `script.recordOutput`\n" +
+		"configuration variable to `false` to not record the output of scripts and run\n" +
+		"them directly on the terminal.\n" +
+		"\n" +
+		"If the `script.timeout` configuration variable is set, for example to `\"5m\"`,\n" +
+		"then scripts that do not complete within that time are killed, along with any\n" +
+		"processes that they started, and chezmoi reports an error. A single script can\n" +
+		"override this by including a `chezmoi:timeout=`*duration* directive, typically\n" +
+		"in a comment, for example:\n" +
+		"\n" +
+		"    #!/bin/sh\n" +
+		"    # chezmoi:timeout=30s\n" +
+		"\n" +
 		"## Template execution\n" +
 		"\n" +
 		"chezmoi executes templates using\n" +
@@ -2207,22 +2881,76 @@ func init() {
 		"For a full list of options, see\n" +
 		"[`Template.Option`](https://pkg.go.dev/text/template?tab=doc#Template.Option).\n" +
 		"\n" +
+		"chezmoi caches the output of templates in `chezmoicache.boltdb`, next to its\n" +
+		"persistent state, so that subsequent runs do not need to execute unchanged\n" +
+		"templates again. The cache key is a hash of the template, the template data,\n" +
+		"the contents of `.chezmoitemplates`, and the template options. Templates that\n" +
+		"call functions whose output can change between runs, for example `env`,\n" +
+		"`include`, `now`, `output`, or `uuidv4`, are never cached. The outputs of\n" +
+		"templates that call secret manager functions like `bitwarden`, `pass`, or\n" +
+		"`vault` are only cached if all of their secret managers have a `cacheTTL`, and\n" +
+		"then only for the shortest of them, so that changes to values in your password\n" +
+		"manager are picked up. The outputs of encrypted templates and of templates that\n" +
+		"call secret manager functions are encrypted with a key stored in your OS's\n" +
+		"keyring. If the keyring is not available then these outputs are not cached.\n" +
+		"The secrets in cached outputs are cached with them, encrypted, so that they are\n" +
+		"still redacted when the cached outputs are used. Cached outputs expire after 30\n" +
+		"days, so that the outputs of templates that are no longer used are removed.\n" +
+		"\n" +
+		"The cache is kept separate from the persistent state, `chezmoistate.boltdb`,\n" +
+		"because the persistent state is opened read-only by commands like `diff` and is\n" +
+		"locked while `apply` runs, whereas the cache is written by every command that\n" +
+		"executes templates. Keeping it separate also means that `chezmoicache.boltdb`\n" +
+		"can be deleted at any time without losing any state, such as which `run_once_`\n" +
+		"scripts have been run.\n" +
+		"\n" +
+		"Run `chezmoi cache clear` to remove all cached outputs, or pass `--no-cache` to\n" +
+		"bypass the cache for a single run.\n" +
+		"\n" +
+		"chezmoi can also cache the output of secret manager commands, so that templates\n" +
+		"that cannot be cached do not query your password manager on every run. This is\n" +
+		"disabled by default. To enable it for a secret manager, set its `cacheTTL`\n" +
+		"configuration variable to how long its output should be cached, for example:\n" +
+		"\n" +
+		"    [bitwarden]\n" +
+		"      cacheTTL = \"1h\"\n" +
+		"\n" +
+		"Cached outputs are stored in `chezmoicache.boltdb`, encrypted with a separate\n" +
+		"key stored in your OS's keyring. If the keyring is not available then nothing\n" +
+		"is cached. Run `chezmoi secret cache clear` to remove all cached secret manager\n" +
+		"outputs, and the cached outputs of templates that use them.\n" +
+		"\n" +
 		"## Template variables\n" +
 		"\n" +
 		"chezmoi provides the following automatically populated variables:\n" +
 		"\n" +
-		"| Variable                | Value                                                                                                                           |\n" +
-		"| ----------------------- | ------------------------------------------------------------------------------------------------------------------------------- |\n" +
-		"| `.chezmoi.arch`         | Architecture, e.g. `amd64`, `arm`, etc. as returned by [runtime.GOARCH](https://pkg.go.dev/runtime?tab=doc#pkg-constants).      |\n" +
-		"| `.chezmoi.fullHostname` | The full hostname of the machine chezmoi is running on.                                                                         |\n" +
-		"| `.chezmoi.group`        | The group of the user running chezmoi.                                                                                          |\n" +
-		"| `.chezmoi.homedir`      | The home directory of the user running chezmoi.                                                                                 |\n" +
-		"| `.chezmoi.hostname`     | The hostname of the machine chezmoi is running on, up to the first `.`.                                                         |\n" +
-		"| `.chezmoi.kernel`       | Contains information from `/proc/sys/kernel`. Linux only, useful for detecting specific kernels (i.e. Microsoft's WSL kernel).  |\n" +
-		"| `.chezmoi.os`           | Operating system, e.g. `darwin`, `linux`, etc. as returned by [runtime.GOOS](https://pkg.go.dev/runtime?tab=doc#pkg-constants). |\n" +
-		"| `.chezmoi.osRelease`    | The information from `/etc/os-release`, Linux only, run `chezmoi data` to see its output.                                       |\n" +
-		"| `.chezmoi.sourceDir`    | The source directory.                                                                                                           |\n" +
-		"| `.chezmoi.username`     | The username of the user running chezmoi.                                                                                       |\n" +
+		"| Variable                   | Value                                                                                                                           |\n" +
+		"| -------------------------- | ------------------------------------------------------------------------------------------------------------------------------- |\n" +
+		"| `.chezmoi.arch`            | Architecture, e.g. `amd64`, `arm`, etc. as returned by [runtime.GOARCH](https://pkg.go.dev/runtime?tab=doc#pkg-constants).      |\n" +
+		"| `.chezmoi.container`       | The container runtime chezmoi is running in, e.g. `docker`, `podman`, `kubernetes`, or `lxc`, or empty. Linux only.             |\n" +
+		"| `.chezmoi.cpuCount`        | The number of logical CPUs.                                                                                                     |\n" +
+		"| `.chezmoi.desktopSession`  | The desktop session type, e.g. `x11` or `wayland`, from `$XDG_SESSION_TYPE`.                                                    |\n" +
+		"| `.chezmoi.display`         | Whether a graphical display is available.                                                                                       |\n" +
+		"| `.chezmoi.fullHostname`    | The full hostname of the machine chezmoi is running on.                                                                         |\n" +
+		"| `.chezmoi.group`           | The group of the user running chezmoi.                                                                                          |\n" +
+		"| `.chezmoi.homedir`         | The home directory of the user running chezmoi.                                                                                 |\n" +
+		"| `.chezmoi.hostname`        | The hostname of the machine chezmoi is running on, up to the first `.`.                                                         |\n" +
+		"| `.chezmoi.kernel`          | Contains information from `/proc/sys/kernel`. Linux only, useful for detecting specific kernels (i.e. Microsoft's WSL kernel).  |\n" +
+		"| `.chezmoi.locale`          | The locale, e.g. `en_US.UTF-8`, from `$LC_ALL` or `$LANG`.                                                                      |\n" +
+		"| `.chezmoi.os`              | Operating system, e.g. `darwin`, `linux`, etc. as returned by [runtime.GOOS](https://pkg.go.dev/runtime?tab=doc#pkg-constants). |\n" +
+		"| `.chezmoi.osRelease`       | The information from `/etc/os-release`, Linux only, run `chezmoi data` to see its output.                                       |\n" +
+		"| `.chezmoi.packageManagers` | The package managers found in `$PATH`, e.g. `apt`, `brew`, or `dnf`.                                                            |\n" +
+		"| `.chezmoi.shell`           | The default shell of the user running chezmoi.                                                                                  |\n" +
+		"| `.chezmoi.sourceDir`       | The source directory.                                                                                                           |\n" +
+		"| `.chezmoi.timezone`        | The local timezone, e.g. `Europe/Berlin`.                                                                                       |\n" +
+		"| `.chezmoi.totalMemory`     | The total memory in bytes. Linux only.                                                                                          |\n" +
+		"| `.chezmoi.username`        | The username of the user running chezmoi.                                                                                       |\n" +
+		"| `.chezmoi.virtualization`  | The hypervisor of the virtual machine chezmoi is running in, e.g. `kvm`, `vmware`, or `virtualbox`, or empty. Linux only.       |\n" +
+		"| `.chezmoi.wsl`             | Whether chezmoi is running in Windows Subsystem for Linux.                                                                      |\n" +
+		"\n" +
+		"The variables `container`, `cpuCount`, `desktopSession`, `display`, `locale`,\n" +
+		"`packageManagers`, `shell`, `timezone`, `totalMemory`, `virtualization`, and\n" +
+		"`wsl` are only computed when a template uses them.\n" +
 		"\n" +
 		"Additional variables can be defined in the config file in the `data` section.\n" +
 		"Variable names must consist of a letter and be followed by zero or more letters\n" +
@@ -2248,6 +2976,45 @@ func init() {
 		"    username = {{ (bitwarden \"item\" \"example.com\").login.username }}\n" +
 		"    password = {{ (bitwarden \"item\" \"example.com\").login.password }}\n" +
 		"\n" +
+		"### `decrypt` *ciphertext*\n" +
+		"\n" +
+		"`decrypt` decrypts *ciphertext*, ASCII-armored output from `chezmoi encrypt`,\n" +
+		"with gpg and returns the plaintext. This allows individual secrets to be stored\n" +
+		"in otherwise readable templates. The output is cached so multiple calls to\n" +
+		"`decrypt` with the same *ciphertext* will only invoke gpg once.\n" +
+		"\n" +
+		"#### `decrypt` examples\n" +
+		"\n" +
+		"    password = {{ decrypt `-----BEGIN PGP MESSAGE-----\n" +
+		"    ...\n" +
+		"    -----END PGP MESSAGE-----` }}\n" +
+		"\n" +
+		"### `fromJson` *string*\n" +
+		"\n" +
+		"`fromJson` parses *string* as JSON and returns the result.\n" +
+		"\n" +
+		"#### `fromJson` examples\n" +
+		"\n" +
+		"    {{ (fromJson (include \"settings.json\")).theme }}\n" +
+		"\n" +
+		"### `fromToml` *string*\n" +
+		"\n" +
+		"`fromToml` parses *string* as TOML and returns the result.\n" +
+		"\n" +
+		"#### `fromToml` examples\n" +
+		"\n" +
+		"    {{ (fromToml (output \"cat\" \"config.toml\")).owner.name }}\n" +
+		"\n" +
+		"### `fromYaml` *string*\n" +
+		"\n" +
+		"`fromYaml` parses *string* as YAML and returns the result.\n" +
+		"\n" +
+		"#### `fromYaml` examples\n" +
+		"\n" +
+		"    {{ range (fromYaml (include \"hosts.yaml\")).hosts }}\n" +
+		"    Host {{ .name }}\n" +
+		"    {{ end }}\n" +
+		"\n" +
 		"### `gopass` *gopass-name*\n" +
 		"\n" +
 		"`gopass` returns passwords stored in [gopass](https://www.gopass.pw/) using the\n" +
@@ -2260,6 +3027,24 @@ func init() {
 		"\n" +
 		"    {{ gopass \"<pass-name>\" }}\n" +
 		"\n" +
+		"### `include` *filename*\n" +
+		"\n" +
+		"`include` returns the contents of *filename*. Relative paths are interpreted\n" +
+		"relative to the source directory.\n" +
+		"\n" +
+		"#### `include` examples\n" +
+		"\n" +
+		"    {{ include \"ssh/config.common\" }}\n" +
+		"\n" +
+		"### `joinPath` *elements*\n" +
+		"\n" +
+		"`joinPath` joins any number of path elements into a single path, separating\n" +
+		"them with the OS-specific path separator.\n" +
+		"\n" +
+		"#### `joinPath` examples\n" +
+		"\n" +
+		"    {{ joinPath .chezmoi.homedir \".vimrc\" }}\n" +
+		"\n" +
 		"### `keepassxc` *entry*\n" +
 		"\n" +
 		"`keepassxc` returns structured data retrieved from a\n" +
@@ -2331,6 +3116,19 @@ func init() {
 		"\n" +
 		"    {{ (index (lastpassRaw \"SSH Private Key\") 0).note }}\n" +
 		"\n" +
+		"### `lookPath` *file*\n" +
+		"\n" +
+		"`lookPath` searches for an executable named *file* in the directories named by\n" +
+		"the `PATH` environment variable. If *file* contains a slash, it is tried\n" +
+		"directly. The result may be an absolute path or a path relative to the current\n" +
+		"directory. If *file* is not found, `lookPath` returns an empty string.\n" +
+		"\n" +
+		"#### `lookPath` examples\n" +
+		"\n" +
+		"    {{ if lookPath \"diff-so-fancy\" }}\n" +
+		"    # diff-so-fancy is in $PATH\n" +
+		"    {{ end }}\n" +
+		"\n" +
 		"### `onepassword` *uuid*\n" +
 		"\n" +
 		"`onepassword` returns structured data from [1Password](https://1password.com/)\n" +
@@ -2357,6 +3155,18 @@ func init() {
 		"\n" +
 		"    {{- onepasswordDocument \"<uuid>\" -}}\n" +
 		"\n" +
+		"### `output` *name* [*args*]\n" +
+		"\n" +
+		"`output` returns the output of executing the command *name* with *args*. If\n" +
+		"executing the command returns an error then template execution exits with an\n" +
+		"error. The execution occurs every time that the template is executed. It is the\n" +
+		"user's responsibility to ensure that executing the command is both idempotent\n" +
+		"and fast.\n" +
+		"\n" +
+		"#### `output` examples\n" +
+		"\n" +
+		"    current-context: {{ output \"kubectl\" \"config\" \"current-context\" | trim }}\n" +
+		"\n" +
 		"### `pass` *pass-name*\n" +
 		"\n" +
 		"`pass` returns passwords stored in [pass](https://www.passwordstore.org/) using\n" +
@@ -2395,6 +3205,44 @@ func init() {
 		"parsed as JSON. The output is cached so multiple calls to `secret` with the same\n" +
 		"*args* will only invoke the generic secret command once.\n" +
 		"\n" +
+		"### `sops` *document*\n" +
+		"\n" +
+		"`sops` returns the structured data in *document*, a JSON or YAML document\n" +
+		"encrypted with sops using age or PGP keys. The output is cached so multiple\n" +
+		"calls to `sops` with the same *document* will only decrypt it once.\n" +
+		"\n" +
+		"#### `sops` examples\n" +
+		"\n" +
+		"    {{ (include \".secrets.sops.yaml\" | sops).github.token }}\n" +
+		"\n" +
+		"### `stat` *name*\n" +
+		"\n" +
+		"`stat` runs `stat(2)` on *name*. If *name* exists it returns structured data\n" +
+		"with the keys `name`, `size`, `mode`, `perm`, `modTime`, and `isDir`. If *name*\n" +
+		"does not exist then it returns a false value.\n" +
+		"\n" +
+		"#### `stat` examples\n" +
+		"\n" +
+		"    {{ if stat (joinPath .chezmoi.homedir \".pyenv\") }}\n" +
+		"    # ~/.pyenv exists\n" +
+		"    {{ end }}\n" +
+		"\n" +
+		"### `toToml` *value*\n" +
+		"\n" +
+		"`toToml` returns *value* formatted as TOML.\n" +
+		"\n" +
+		"#### `toToml` examples\n" +
+		"\n" +
+		"    {{ dict \"key\" \"value\" | toToml }}\n" +
+		"\n" +
+		"### `toYaml` *value*\n" +
+		"\n" +
+		"`toYaml` returns *value* formatted as YAML.\n" +
+		"\n" +
+		"#### `toYaml` examples\n" +
+		"\n" +
+		"    {{ dict \"key\" \"value\" | toYaml }}\n" +
+		"\n" +
 		"### `vault` *key*\n" +
 		"\n" +
 		"`vault` returns structured data from [Vault](https://www.vaultproject.io/) using\n" +
@@ -2403,6 +3251,14 @@ func init() {
 		"parsed as JSON. The output from `vault` is cached so calling `vault` multiple\n" +
 		"times with the same *key* will only invoke `vault` once.\n" +
 		"\n" +
+		"If `vault.client` is `http` then chezmoi reads *key* using Vault's HTTP API\n" +
+		"instead of the Vault CLI, returning the same data. The server address is\n" +
+		"`vault.address`, or `$VAULT_ADDR` if that is not set. The namespace is\n" +
+		"`vault.namespace`, or `$VAULT_NAMESPACE`. The token is `$VAULT_TOKEN`, or, if\n" +
+		"that is not set, the contents of `vault.tokenFile`, which defaults to the\n" +
+		"`~/.vault-token` file written by `vault login`. Both version 1 and version 2 KV\n" +
+		"secrets engines are supported.\n" +
+		"\n" +
 		"#### `vault` examples\n" +
 		"\n" +
 		"    {{ (vault \"<key>\").data.data.password }}\n")
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -72,6 +73,13 @@ type doctorFileCheck struct {
 
 type doctorRuntimeCheck struct{}
 
+type doctorSecretPluginCheck struct {
+	plugin *secretPlugin
+	health func() (string, error)
+	status string
+	err    error
+}
+
 type doctorSuspiciousFilesCheck struct {
 	path      string
 	filenames map[string]bool
@@ -116,8 +124,7 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 		mustSucceed: true,
 	}
 
-	allOK := true
-	for _, dc := range []doctorCheck{
+	checks := []doctorCheck{
 		&doctorVersionCheck{},
 		&doctorRuntimeCheck{},
 		&doctorDirectoryCheck{
@@ -156,6 +163,12 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 		},
 		vcsCommandCheck,
 		gpgBinaryCheck,
+		&doctorBinaryCheck{
+			name:          "age",
+			binaryName:    "age",
+			versionArgs:   []string{"--version"},
+			versionRegexp: regexp.MustCompile(`v?(\d+\.\d+\.\d+)`),
+		},
 		&doctorBinaryCheck{
 			name:          "1Password CLI",
 			binaryName:    c.Onepassword.Command,
@@ -203,7 +216,27 @@ func (c *Config) runDoctorCmd(cmd *cobra.Command, args []string) error {
 			name:       "generic secret CLI",
 			binaryName: c.GenericSecret.Command,
 		},
-	} {
+	}
+	for _, plugin := range c.secretPlugins {
+		plugin := plugin
+		checks = append(checks, &doctorSecretPluginCheck{
+			plugin: plugin,
+			health: func() (string, error) {
+				value, err := c.secretPluginRequest(plugin, secretPluginCommandHealth, nil)
+				if err != nil {
+					return "", err
+				}
+				var status string
+				if err := json.Unmarshal(value, &status); err != nil {
+					return "", err
+				}
+				return status, nil
+			},
+		})
+	}
+
+	allOK := true
+	for _, dc := range checks {
 		if dc.Skip() {
 			continue
 		}
@@ -395,6 +428,38 @@ func (doctorRuntimeCheck) Skip() bool {
 	return false
 }
 
+func (c *doctorSecretPluginCheck) Check() (bool, error) {
+	if c.plugin.err != nil {
+		c.err = c.plugin.err
+		return false, nil
+	}
+	c.status, c.err = c.health()
+	return c.err == nil, nil
+}
+
+func (c *doctorSecretPluginCheck) Enabled() bool {
+	return true
+}
+
+func (c *doctorSecretPluginCheck) MustSucceed() bool {
+	return false
+}
+
+func (c *doctorSecretPluginCheck) Result() string {
+	switch {
+	case c.err != nil:
+		return fmt.Sprintf("%s (%s secret plugin, %v)", c.plugin.path, c.plugin.name, c.err)
+	case c.status != "":
+		return fmt.Sprintf("%s (%s secret plugin, %s)", c.plugin.path, c.plugin.name, c.status)
+	default:
+		return fmt.Sprintf("%s (%s secret plugin)", c.plugin.path, c.plugin.name)
+	}
+}
+
+func (c *doctorSecretPluginCheck) Skip() bool {
+	return false
+}
+
 func (c *doctorSuspiciousFilesCheck) Check() (bool, error) {
 	if err := filepath.Walk(c.path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
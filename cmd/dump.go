@@ -6,11 +6,13 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
 )
 
 type dumpCmdConfig struct {
 	format    string
 	recursive bool
+	sourceRef string
 }
 
 var dumpCmd = &cobra.Command{
@@ -28,6 +30,8 @@ func init() {
 	persistentFlags := dumpCmd.PersistentFlags()
 	persistentFlags.StringVarP(&config.dump.format, "format", "f", "json", "format (JSON, TOML, or YAML)")
 	persistentFlags.BoolVarP(&config.dump.recursive, "recursive", "r", true, "recursive")
+	persistentFlags.StringVar(&config.dump.sourceRef, "source-ref", "", "use the source state at revision")
+	addDataOverrideFlags(persistentFlags)
 
 	markRemainingZshCompPositionalArgumentsAsFiles(dumpCmd, 1)
 }
@@ -37,11 +41,25 @@ func (c *Config) runDumpCmd(cmd *cobra.Command, args []string) error {
 	if !ok {
 		return fmt.Errorf("%s: unknown format", c.dump.format)
 	}
-	ts, err := c.getTargetState(nil)
-	if err != nil {
-		return err
+	c.redactSecrets()
+	var ts *chezmoi.TargetState
+	if c.dump.sourceRef == "" {
+		var err error
+		ts, err = c.getTargetState(nil)
+		if err != nil {
+			return err
+		}
+	} else {
+		var cleanup func()
+		var err error
+		ts, cleanup, err = c.getTargetStateAtRef(c.dump.sourceRef)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
 	}
 	var concreteValue interface{}
+	var err error
 	if len(args) == 0 {
 		concreteValue, err = ts.ConcreteValue(c.dump.recursive)
 		if err != nil {
@@ -64,5 +82,6 @@ func (c *Config) runDumpCmd(cmd *cobra.Command, args []string) error {
 		}
 		concreteValue = concreteValues
 	}
+	c.redactor.RedactConcreteValue(concreteValue)
 	return format(c.Stdout, concreteValue)
 }
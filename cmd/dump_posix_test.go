@@ -0,0 +1,46 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestDumpSourceRef(t *testing.T) {
+	skipIfGitNotFound(t)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/dot_bashrc": "# one\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "init", "--quiet")
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "add", ".")
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "commit", "--quiet", "--message", "One")
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_bashrc", []byte("# working copy\n"), 0666))
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withDestDir("/home/user"),
+		withStdout(stdout),
+		withDumpCmdConfig(dumpCmdConfig{
+			format:    "json",
+			recursive: true,
+			sourceRef: "HEAD",
+		}),
+	)
+	require.NoError(t, c.runDumpCmd(nil, nil))
+	var actual []map[string]interface{}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &actual))
+	require.Len(t, actual, 1)
+	assert.Equal(t, "# one\n", actual[0]["contents"])
+	assert.Equal(t, "/home/user/.local/share/chezmoi/dot_bashrc", actual[0]["sourcePath"])
+}
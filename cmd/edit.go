@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/twpayne/chezmoi/internal/chezmoi"
 	vfs "github.com/twpayne/go-vfs"
+	bolt "go.etcd.io/bbolt"
 )
 
 var editCmd = &cobra.Command{
@@ -153,23 +154,30 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	readOnlyFS := vfs.NewReadOnlyFS(c.fs)
-	applyOptions := chezmoi.ApplyOptions{
-		DestDir:           ts.DestDir,
-		DryRun:            c.DryRun,
-		Ignore:            ts.TargetIgnore.Match,
-		ScriptStateBucket: c.scriptStateBucket,
-		Stdout:            c.Stdout,
-		Umask:             ts.Umask,
-		Verbose:           c.Verbose,
+	// The persistent state is only written if the edited files are applied.
+	var persistentStateOptions *bolt.Options
+	if !c.edit.apply {
+		persistentStateOptions = &bolt.Options{
+			ReadOnly: true,
+		}
 	}
+	persistentState, err := c.getPersistentState(persistentStateOptions)
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	readOnlyFS := vfs.NewReadOnlyFS(c.fs)
+	applyOptions := c.getApplyOptions(ts, persistentState)
+	recordingMutator := chezmoi.NewRecordingMutator(c.mutator)
+FOR:
 	for i, entry := range entries {
 		anyMutator := chezmoi.NewAnyMutator(chezmoi.NullMutator{})
 		var mutator chezmoi.Mutator = anyMutator
 		if c.edit.diff {
-			mutator = chezmoi.NewVerboseMutator(c.Stdout, mutator, c.colored, c.maxDiffDataSize)
+			mutator = chezmoi.NewVerboseMutator(c.Stdout, mutator, c.colored, c.maxDiffDataSize, c.redactor)
 		}
-		if err := entry.Apply(readOnlyFS, mutator, c.Follow, &applyOptions); err != nil {
+		if err := entry.Apply(readOnlyFS, mutator, c.Follow, applyOptions); err != nil {
 			return err
 		}
 		if c.edit.apply && anyMutator.Mutated() {
@@ -183,15 +191,15 @@ func (c *Config) runEditCmd(cmd *cobra.Command, args []string) error {
 				case 'n':
 					continue
 				case 'q':
-					return nil
+					break FOR
 				case 'a':
 					c.edit.prompt = false
 				}
 			}
-			if err := entry.Apply(readOnlyFS, c.mutator, c.Follow, &applyOptions); err != nil {
+			if err := entry.Apply(readOnlyFS, recordingMutator, c.Follow, applyOptions); err != nil {
 				return err
 			}
 		}
 	}
-	return nil
+	return c.runTriggers(ts, recordingMutator)
 }
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+)
+
+var encryptCmd = &cobra.Command{
+	Use:     "encrypt",
+	Args:    cobra.NoArgs,
+	Short:   "Encrypt stdin",
+	Long:    mustGetLongHelp("encrypt"),
+	Example: getExample("encrypt"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runEncryptCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(encryptCmd)
+}
+
+func (c *Config) runEncryptCmd(cmd *cobra.Command, args []string) error {
+	plaintext, err := ioutil.ReadAll(c.Stdin)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := c.getGPG().Encrypt("stdin", plaintext)
+	if err != nil {
+		return err
+	}
+	_, err = c.Stdout.Write(ciphertext)
+	return err
+}
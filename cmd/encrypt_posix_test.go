@@ -0,0 +1,89 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+// withTestGPGHome sets $GNUPGHOME to a temporary directory containing a new
+// key without a passphrase for user@example.com for the duration of f.
+func withTestGPGHome(t *testing.T, f func()) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg not found in $PATH")
+	}
+	gpgHome, err := ioutil.TempDir("", "chezmoi-test-gpg")
+	require.NoError(t, err)
+	defer os.RemoveAll(gpgHome)
+	defer func() {
+		_ = exec.Command("gpgconf", "--homedir", gpgHome, "--kill", "gpg-agent").Run()
+	}()
+	oldGPGHome, ok := os.LookupEnv("GNUPGHOME")
+	require.NoError(t, os.Setenv("GNUPGHOME", gpgHome))
+	defer func() {
+		if ok {
+			_ = os.Setenv("GNUPGHOME", oldGPGHome)
+		} else {
+			_ = os.Unsetenv("GNUPGHOME")
+		}
+	}()
+	output, err := exec.Command(
+		"gpg",
+		"--batch",
+		"--passphrase", "",
+		"--quick-generate-key", "user@example.com",
+		"default", "default", "never",
+	).CombinedOutput()
+	if err != nil {
+		t.Skipf("gpg --quick-generate-key: %v\n%s", err, output)
+	}
+	f()
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	withTestGPGHome(t, func() {
+		fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+			"/home/user/.local/share/chezmoi": &vfst.Dir{Perm: 0755},
+		})
+		require.NoError(t, err)
+		defer cleanup()
+
+		newConfig := func(stdin string, stdout *bytes.Buffer) *Config {
+			c := newTestConfig(
+				fs,
+				withMutator(chezmoi.NullMutator{}),
+				withStdin(strings.NewReader(stdin)),
+				withStdout(stdout),
+			)
+			c.GPG.Recipient = "user@example.com"
+			c.addSecretTemplateFunc("decrypt", c.decryptFunc)
+			return c
+		}
+
+		ciphertext := &bytes.Buffer{}
+		require.NoError(t, newConfig("hunter2", ciphertext).runEncryptCmd(nil, nil))
+		assert.True(t, strings.HasPrefix(ciphertext.String(), "-----BEGIN PGP MESSAGE-----\n"))
+		assert.NotContains(t, ciphertext.String(), "hunter2")
+
+		plaintext := &bytes.Buffer{}
+		require.NoError(t, newConfig(ciphertext.String(), plaintext).runDecryptCmd(nil, nil))
+		assert.Equal(t, "hunter2", plaintext.String())
+
+		stdout := &bytes.Buffer{}
+		c := newConfig("", stdout)
+		require.NoError(t, c.runExecuteTemplateCmd(nil, []string{
+			"password = {{ decrypt `" + ciphertext.String() + "` }}",
+		}))
+		assert.Equal(t, "password = hunter2", stdout.String())
+	})
+}
@@ -18,6 +18,8 @@ var executeTemplateCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(executeTemplateCmd)
+
+	addDataOverrideFlags(executeTemplateCmd.PersistentFlags())
 }
 
 func (c *Config) runExecuteTemplateCmd(cmd *cobra.Command, args []string) error {
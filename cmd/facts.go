@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	vfs "github.com/twpayne/go-vfs"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var factsCmd = &cobra.Command{
+	Use:     "facts",
+	Args:    cobra.NoArgs,
+	Short:   "Manage machine facts",
+	Long:    mustGetLongHelp("facts"),
+	Example: getExample("facts"),
+}
+
+var factsExportCmd = &cobra.Command{
+	Use:     "export",
+	Args:    cobra.NoArgs,
+	Short:   "Write the machine facts and data to stdout or to a profile",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runFactsExportCmd,
+}
+
+type factsExportCmdConfig struct {
+	format  string
+	profile string
+}
+
+// profileExts are the extensions of profile files, in order of precedence.
+var profileExts = []string{"json", "toml", "yaml", "yml"}
+
+func init() {
+	rootCmd.AddCommand(factsCmd)
+	factsCmd.AddCommand(factsExportCmd)
+
+	persistentFlags := factsExportCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.factsExport.format, "format", "f", "yaml", "format (JSON, TOML, or YAML)")
+	persistentFlags.StringVar(&config.factsExport.profile, "profile", "", "write to profile")
+}
+
+func (c *Config) runFactsExportCmd(cmd *cobra.Command, args []string) error {
+	formatName := strings.ToLower(c.factsExport.format)
+	format, ok := formatMap[formatName]
+	if !ok {
+		return fmt.Errorf("%s: unknown format", c.factsExport.format)
+	}
+	// Never export secrets decrypted from sops-encrypted data files.
+	data, err := c.getDataWithSOPS(false)
+	if err != nil {
+		return err
+	}
+	data, err = chezmoi.ResolveTemplateData(data, nil)
+	if err != nil {
+		return err
+	}
+	if c.factsExport.profile == "" {
+		return format(c.Stdout, data)
+	}
+
+	profilesDir := filepath.Join(c.SourceDir, chezmoi.ProfilesDirName)
+	if err := vfs.MkdirAll(c.mutator, profilesDir, 0777&^os.FileMode(c.Umask)); err != nil {
+		return err
+	}
+	// Remove any existing profile with the same name in a different format,
+	// as it would take precedence.
+	for _, ext := range profileExts {
+		if ext == formatName {
+			continue
+		}
+		path := filepath.Join(profilesDir, c.factsExport.profile+"."+ext)
+		if _, err := c.fs.Lstat(path); err == nil {
+			if err := c.mutator.RemoveAll(path); err != nil {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	sb := &strings.Builder{}
+	if err := format(sb, data); err != nil {
+		return err
+	}
+	path := filepath.Join(profilesDir, c.factsExport.profile+"."+formatName)
+	return c.mutator.WriteFile(path, []byte(sb.String()), 0666&^os.FileMode(c.Umask), nil)
+}
+
+// addDataOverrideFlags adds the --data-override and --profile flags to flags.
+func addDataOverrideFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&config.dataOverride, "data-override", "", "use machine facts and data from file")
+	flags.StringVar(&config.profile, "profile", "", "use machine facts and data from profile")
+}
+
+// getDataOverride returns the data that overrides the template data, if any.
+func (c *Config) getDataOverride() (map[string]interface{}, error) {
+	switch {
+	case c.dataOverride != "" && c.profile != "":
+		return nil, errors.New("--data-override and --profile cannot be used together")
+	case c.dataOverride != "":
+		return c.readDataFile(c.dataOverride)
+	case c.profile != "":
+		profilesDir := filepath.Join(c.SourceDir, chezmoi.ProfilesDirName)
+		for _, ext := range profileExts {
+			path := filepath.Join(profilesDir, c.profile+"."+ext)
+			switch _, err := c.fs.Stat(path); {
+			case err == nil:
+				return c.readDataFile(path)
+			case !os.IsNotExist(err):
+				return nil, err
+			}
+		}
+		return nil, fmt.Errorf("%s: profile not found in %s", c.profile, profilesDir)
+	default:
+		return nil, nil
+	}
+}
+
+// readDataFile reads the data in the file at path, decoding it according to
+// its extension.
+func (c *Config) readDataFile(path string) (map[string]interface{}, error) {
+	contents, err := c.fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext {
+	case "json":
+		err = json.Unmarshal(contents, &data)
+	case "toml":
+		var tree *toml.Tree
+		if tree, err = toml.LoadBytes(contents); err == nil {
+			data = tree.ToMap()
+		}
+	case "yaml", "yml":
+		if err = yaml.Unmarshal(contents, &data); err == nil {
+			data = stringKeyMap(data).(map[string]interface{})
+		}
+	default:
+		return nil, fmt.Errorf("%s: unknown format", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
+}
+
+// mergeData merges src into dst recursively, so that src can override
+// individual keys of nested maps in dst without replacing the whole map.
+// Nested maps in dst are copied before being merged into, as they may be
+// shared with the config.
+func mergeData(dst, src map[string]interface{}) {
+	for key, srcValue := range src {
+		srcMap, ok := srcValue.(map[string]interface{})
+		if !ok {
+			dst[key] = srcValue
+			continue
+		}
+		dstMap, ok := dst[key].(map[string]interface{})
+		if !ok {
+			dst[key] = srcValue
+			continue
+		}
+		merged := make(map[string]interface{}, len(dstMap))
+		for k, v := range dstMap {
+			merged[k] = v
+		}
+		mergeData(merged, srcMap)
+		dst[key] = merged
+	}
+}
+
+// stringKeyMap returns value with all maps with interface{} keys, as decoded
+// by gopkg.in/yaml.v2, converted to maps with string keys, so that they can be
+// used in the same way as data from other formats.
+func stringKeyMap(value interface{}) interface{} {
+	switch value := value.(type) {
+	case []interface{}:
+		for i, element := range value {
+			value[i] = stringKeyMap(element)
+		}
+		return value
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[fmt.Sprint(k)] = stringKeyMap(v)
+		}
+		return result
+	case map[string]interface{}:
+		for k, v := range value {
+			value[k] = stringKeyMap(v)
+		}
+		return value
+	default:
+		return value
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestFactsExportCmd(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/.chezmoiprofiles/server.json": "{}\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withData(map[string]interface{}{
+			"email": "user@example.com",
+		}),
+		withStdout(stdout),
+	)
+	c.factsExport.format = "yaml"
+	require.NoError(t, c.runFactsExportCmd(nil, nil))
+	assert.Contains(t, stdout.String(), "email: user@example.com\n")
+	assert.Contains(t, stdout.String(), "chezmoi:\n")
+
+	// Exporting to a profile replaces any existing profile with the same name.
+	stdout.Reset()
+	c.factsExport.profile = "server"
+	require.NoError(t, c.runFactsExportCmd(nil, nil))
+	assert.Empty(t, stdout.String())
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/.chezmoiprofiles/server.json",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.local/share/chezmoi/.chezmoiprofiles/server.yaml",
+			vfst.TestModeIsRegular,
+		),
+	)
+
+	// The exported profile can be read back.
+	c.profile = "server"
+	data, err := c.getData()
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", data["email"])
+	_, ok := data["chezmoi"].(map[string]interface{})
+	assert.True(t, ok)
+}
+
+func TestDataOverride(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi/.chezmoiprofiles": map[string]interface{}{
+			"laptop.toml": "email = \"laptop@example.com\"\n\n[chezmoi]\n  os = \"darwin\"\n",
+		},
+		"/home/user/server.yaml": "chezmoi:\n  os: linux\n  osRelease:\n    id: ubuntu\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		name         string
+		dataOverride string
+		profile      string
+		template     string
+		expectedErr  bool
+		expected     string
+	}{
+		{
+			name:     "none",
+			template: "{{ .email }}",
+			expected: "user@example.com",
+		},
+		{
+			name:         "data_override",
+			dataOverride: "/home/user/server.yaml",
+			template:     "{{ .chezmoi.os }} {{ .chezmoi.osRelease.id }} {{ .email }}",
+			expected:     "linux ubuntu user@example.com",
+		},
+		{
+			name:     "profile",
+			profile:  "laptop",
+			template: "{{ .chezmoi.os }} {{ .email }}",
+			expected: "darwin laptop@example.com",
+		},
+		{
+			name:     "profile_partial_chezmoi",
+			profile:  "laptop",
+			template: "{{ .chezmoi.os }} {{ .chezmoi.arch }} {{ .chezmoi.sourceDir }}",
+			expected: "darwin " + runtime.GOARCH + " /home/user/.local/share/chezmoi",
+		},
+		{
+			name:        "missing_profile",
+			profile:     "missing",
+			expectedErr: true,
+		},
+		{
+			name:         "both",
+			dataOverride: "/home/user/server.yaml",
+			profile:      "laptop",
+			expectedErr:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(
+				fs,
+				withData(map[string]interface{}{
+					"email": "user@example.com",
+				}),
+				withStdout(stdout),
+			)
+			c.dataOverride = tc.dataOverride
+			c.profile = tc.profile
+			err := c.runExecuteTemplateCmd(nil, []string{tc.template})
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, stdout.String())
+		})
+	}
+}
@@ -15,6 +15,20 @@ var helps = map[string]help{
 			"  state, then its source state is replaced with its current state in the\n" +
 			"  destination directory. The `add` command accepts additional flags:\n" +
 			"\n" +
+			"  `-a`, `--autotemplate`\n" +
+			"\n" +
+			"  When adding files with `--template`, generate the template automatically by\n" +
+			"  replacing template data values with template expressions that reference them.\n" +
+			"  String, numeric, and list values are replaced where they occur as whole words,\n" +
+			"  and longer values are replaced before shorter ones. Values shorter than the\n" +
+			"  `autoTemplate.minMatchLength` configuration variable, three characters by\n" +
+			"  default, are not replaced, so that small numbers like `1` are not replaced\n" +
+			"  everywhere that they occur. Any existing `{{` and `}}` in the file are\n" +
+			"  escaped. Each substitution is printed. If the `--prompt` flag is set, chezmoi\n" +
+			"  instead prompts before making each substitution, with the choices `y`\n" +
+			"  (replace), `n` (keep the value), and `a` (replace this and all remaining\n" +
+			"  values without prompting).\n" +
+			"\n" +
 			"  `-e`, `--empty`\n" +
 			"\n" +
 			"  Set the `empty` attribute on added files.\n" +
@@ -36,36 +50,102 @@ var helps = map[string]help{
 			"\n" +
 			"  Recursively add all files, directories, and symlinks.\n" +
 			"\n" +
+			"  `--secrets` *mode*\n" +
+			"\n" +
+			"  Set how to handle files that contain possible secrets. Before adding a file,\n" +
+			"  chezmoi checks its contents for private keys, known access token formats,\n" +
+			"  password assignments, and other high-entropy strings. *mode* must be one of:\n" +
+			"\n" +
+			"     MODE   |             EFFECT\n" +
+			"  ----------+---------------------------------\n" +
+			"    error   | Refuse to add the file\n" +
+			"    ignore  | Do not check for possible\n" +
+			"            | secrets\n" +
+			"    prompt  | Prompt to add the file as is,\n" +
+			"            | skip it, encrypt it, or add it\n" +
+			"            | as a template\n" +
+			"    warning | Print a warning and add the\n" +
+			"            | file\n" +
+			"\n" +
+			"  The default is `prompt` if the standard input is a terminal, and `error`\n" +
+			"  otherwise. When adding a file as a template, chezmoi prompts for a template\n" +
+			"  expression, for example `pass \"github.com/token\"`, to replace each secret, and\n" +
+			"  escapes any existing template delimiters in the file. Files added with `--\n" +
+			"  encrypt` and targets matching a pattern in `.chezmoiallowsecrets` are not\n" +
+			"  checked.\n" +
+			"\n" +
 			"  `-T`, `--template`\n" +
 			"\n" +
-			"  Set the `template` attribute on added files and symlinks. In addition, if the\n" +
-			"  `--autotemplate` flag is set, chezmoi attempts to automatically generate the\n" +
-			"  template by replacing any template data values with the equivalent template\n" +
-			"  data keys. Longer subsitutions occur before shorter ones.",
+			"  Set the `template` attribute on added files and symlinks.",
 		example: "" +
 			"  chezmoi add ~/.bashrc\n" +
 			"  chezmoi add ~/.gitconfig --template\n" +
+			"  chezmoi add ~/.gitconfig --template --autotemplate --prompt\n" +
 			"  chezmoi add ~/.vim --recursive\n" +
-			"  chezmoi add ~/.oh-my-zsh --exact --recursive",
+			"  chezmoi add ~/.oh-my-zsh --exact --recursive\n" +
+			"  chezmoi add ~/.netrc --secrets=error\n" +
+			"  chezmoi add ~/.netrc --secrets=prompt",
 	},
 	"apply": {
 		long: "" +
 			"Description:\n" +
 			"  Ensure that *targets* are in the target state, updating them if necessary. If\n" +
-			"  no targets are specified, the state of all targets are ensured.",
+			"  no targets are specified, the state of all targets are ensured.\n" +
+			"\n" +
+			"  `-i`, `--interactive`\n" +
+			"\n" +
+			"  Show each change and prompt before making it. The choices are:\n" +
+			"\n" +
+			"    CHOICE |             ACTION\n" +
+			"  ---------+---------------------------------\n" +
+			"    y      | Make the change\n" +
+			"    n      | Skip the change\n" +
+			"    p      | Choose which hunks of the diff\n" +
+			"           | to apply, one at a time\n" +
+			"    m      | Run the merge command, as with\n" +
+			"           | merge\n" +
+			"    q      | Quit without making this or\n" +
+			"           | any further changes\n" +
+			"    a      | Make this and all further\n" +
+			"           | changes without prompting\n" +
+			"\n" +
+			"  `p` is only offered for changes to existing text files with more than one\n" +
+			"  hunk, and `m` only for files. When choosing hunks, `y` and `n` apply or skip\n" +
+			"  the hunk, `a` applies it and all remaining hunks, `d` skips it and all\n" +
+			"  remaining hunks, and `q` quits after writing the hunks chosen so far. Skipping\n" +
+			"  the creation of a directory also skips its contents. Scripts are shown before\n" +
+			"  they are run. A summary of the decisions is printed at the end.",
 		example: "" +
 			"  chezmoi apply\n" +
 			"  chezmoi apply --dry-run --verbose\n" +
-			"  chezmoi apply ~/.bashrc",
+			"  chezmoi apply ~/.bashrc\n" +
+			"  chezmoi apply --interactive",
 	},
 	"archive": {
 		long: "" +
 			"Description:\n" +
 			"  Write a tar archive of the target state to stdout. This can be piped into\n" +
-			"  `tar` to inspect the target state.",
+			"  `tar` to inspect the target state.\n" +
+			"\n" +
+			"  `--data-override` *file*\n" +
+			"\n" +
+			"  Use the machine facts and template data in *file* instead of the current\n" +
+			"  machine's. See facts export.\n" +
+			"\n" +
+			"  `--profile` *name*\n" +
+			"\n" +
+			"  Use the machine facts and template data in the profile *name* instead of the\n" +
+			"  current machine's. See facts export.",
 		example: "" +
 			"  chezmoi archive | tar tvf -",
 	},
+	"cache": {
+		long: "" +
+			"Description:\n" +
+			"  Remove all entries from the template cache. See template execution.",
+		example: "" +
+			"  chezmoi cache clear",
+	},
 	"cat": {
 		long: "" +
 			"Description:\n" +
@@ -123,8 +203,9 @@ var helps = map[string]help{
 	"data": {
 		long: "" +
 			"Description:\n" +
-			"  Write the computed template data in JSON format to stdout. The `data` command\n" +
-			"  accepts additional flags:\n" +
+			"  Write the computed template data in JSON format to stdout. Values from sops-\n" +
+			"  encrypted data files are replaced with `[redacted]`, without being decrypted,\n" +
+			"  unless `--show-secrets` is given. The `data` command accepts additional flags:\n" +
 			"\n" +
 			"  `-f`, `--format` *format*\n" +
 			"\n" +
@@ -134,16 +215,49 @@ var helps = map[string]help{
 			"  chezmoi data\n" +
 			"  chezmoi data --format=yaml",
 	},
+	"decrypt": {
+		long: "" +
+			"Description:\n" +
+			"  Decrypt ASCII-armored ciphertext read from stdin with gpg and write the\n" +
+			"  plaintext to stdout.",
+		example: "" +
+			"  chezmoi decrypt < secret.asc",
+	},
 	"diff": {
 		long: "" +
 			"Description:\n" +
 			"  Print the approximate shell commands required to ensure that *targets* in the\n" +
 			"  destination directory match the target state. If no targets are specified,\n" +
 			"  print the commands required for all targets. It is equivalent to `chezmoi\n" +
-			"  apply --dry-run --verbose`.",
+			"  apply --dry-run --verbose`. If either version of a file is binary or is larger than\n" +
+			"  1MB then only the sizes and SHA256 sums of the two versions are printed.\n" +
+			"\n" +
+			"  `--source-ref` *revision*\n" +
+			"\n" +
+			"  Use the source state at the git *revision* instead of the working copy of the\n" +
+			"  source directory. The source directory is not modified. If `--source-ref` is\n" +
+			"  given twice then the target states at the two revisions are compared with each\n" +
+			"  other, ignoring the destination directory. Changes to the contents of scripts\n" +
+			"  are printed as diffs of the scripts themselves. Files read by the `include`\n" +
+			"  template function and sops-encrypted data files in the source directory are\n" +
+			"  also read at *revision*. In symlink mode, targets that link into the source\n" +
+			"  directory are compared as the files that they link to, so changes to their\n" +
+			"  contents are shown.\n" +
+			"\n" +
+			"  `--data-override` *file*\n" +
+			"\n" +
+			"  Use the machine facts and template data in *file* instead of the current\n" +
+			"  machine's. See facts export.\n" +
+			"\n" +
+			"  `--profile` *name*\n" +
+			"\n" +
+			"  Use the machine facts and template data in the profile *name* instead of the\n" +
+			"  current machine's. See facts export.",
 		example: "" +
 			"  chezmoi diff\n" +
-			"  chezmoi diff ~/.bashrc",
+			"  chezmoi diff ~/.bashrc\n" +
+			"  chezmoi diff --source-ref=origin/master\n" +
+			"  chezmoi diff --source-ref=HEAD~1 --source-ref=HEAD",
 	},
 	"docs": {
 		long: "" +
@@ -158,7 +272,8 @@ var helps = map[string]help{
 	"doctor": {
 		long: "" +
 			"Description:\n" +
-			"  Check for potential problems.",
+			"  Check for potential problems. Any secret plugins found are listed and asked\n" +
+			"  whether they are healthy.",
 		example: "" +
 			"  chezmoi doctor",
 	},
@@ -171,10 +286,27 @@ var helps = map[string]help{
 			"  `-f`, `--format` *format*\n" +
 			"\n" +
 			"  Print the target state in the given format. The accepted formats are `json`\n" +
-			"  (JSON) and `yaml` (YAML).",
+			"  (JSON) and `yaml` (YAML).\n" +
+			"\n" +
+			"  `--source-ref` *revision*\n" +
+			"\n" +
+			"  Dump the target state at the git *revision* instead of the working copy of the\n" +
+			"  source directory.\n" +
+			"\n" +
+			"  `--data-override` *file*\n" +
+			"\n" +
+			"  Use the machine facts and template data in *file* instead of the current\n" +
+			"  machine's. See facts export.\n" +
+			"\n" +
+			"  `--profile` *name*\n" +
+			"\n" +
+			"  Use the machine facts and template data in the profile *name* instead of the\n" +
+			"  current machine's. See facts export.",
 		example: "" +
 			"  chezmoi dump ~/.bashrc\n" +
-			"  chezmoi dump --format=yaml",
+			"  chezmoi dump --format=yaml\n" +
+			"  chezmoi dump --profile=server ~/.bashrc\n" +
+			"  chezmoi dump --source-ref=HEAD~1 ~/.bashrc",
 	},
 	"edit": {
 		long: "" +
@@ -209,6 +341,15 @@ var helps = map[string]help{
 			"\n" +
 			"    chezmoi edit-config",
 	},
+	"encrypt": {
+		long: "" +
+			"Description:\n" +
+			"  Encrypt stdin with gpg using the configured `gpg.recipient` or symmetric\n" +
+			"  encryption and write the ASCII-armored ciphertext to stdout. The ciphertext can\n" +
+			"  be passed to the `decrypt` template function.",
+		example: "" +
+			"  echo -n \"my-password\" | chezmoi encrypt",
+	},
 	"execute-template": {
 		long: "" +
 			"Description:\n" +
@@ -218,11 +359,53 @@ var helps = map[string]help{
 			"  between arguments. If no templates are specified, the template data are read\n" +
 			"  from stdin.\n" +
 			"\n" +
+			"  `--data-override` *file*\n" +
+			"\n" +
+			"  Use the machine facts and template data in *file* instead of the current\n" +
+			"  machine's. See facts export.\n" +
+			"\n" +
+			"  `--profile` *name*\n" +
+			"\n" +
+			"  Use the machine facts and template data in the profile *name* instead of the\n" +
+			"  current machine's. See facts export.\n" +
+			"\n" +
 			"  `execute-template` examples\n" +
 			"\n" +
 			"    chezmoi execute-template '{{ .chezmoi.sourceDir }}'\n" +
 			"    chezmoi execute-template '{{ .chezmoi.os }}' / '{{ .chezmoi.arch }}'\n" +
-			"    echo '{{ .chezmoi | toJson }}' | chezmoi execute-template",
+			"    echo '{{ .chezmoi | toJson }}' | chezmoi execute-template\n" +
+			"    chezmoi execute-template --data-override=laptop.yaml '{{ .chezmoi.os }}'",
+	},
+	"facts": {
+		long: "" +
+			"Description:\n" +
+			"  Write the template data of the current machine, including the machine facts in\n" +
+			"  `.chezmoi` such as `.chezmoi.os`, `.chezmoi.hostname`, and\n" +
+			"  `.chezmoi.osRelease`, to stdout.\n" +
+			"\n" +
+			"  The `archive`, `diff`, `dump`, and `execute-template` commands accept a `--data-\n" +
+			"  override` *file* flag, which renders the target state as if on the machine\n" +
+			"  whose data was exported to *file*, and a `--profile` *name* flag, which does the\n" +
+			"  same with a profile stored in the `.chezmoiprofiles` directory in the source\n" +
+			"  state. The exported data replace the current machine's data key by key, so the\n" +
+			"  `.chezmoi` facts are replaced as a whole. The format of *file* is determined\n" +
+			"  by its extension.\n" +
+			"\n" +
+			"  `-f`, `--format` *format*\n" +
+			"\n" +
+			"  Write the data in the given format. The accepted formats are `json` (JSON),\n" +
+			"  `toml` (TOML), and `yaml` (YAML). The default is `yaml`.\n" +
+			"\n" +
+			"  `--profile` *name*\n" +
+			"\n" +
+			"  Write the data to the profile *name* in the `.chezmoiprofiles` directory in\n" +
+			"  the source state, replacing any existing profile with the same name, instead\n" +
+			"  of to stdout.",
+		example: "" +
+			"  chezmoi facts export > laptop.yaml\n" +
+			"  chezmoi dump --data-override=laptop.yaml\n" +
+			"  chezmoi facts export --profile=server\n" +
+			"  chezmoi archive --profile=server | tar tvf -",
 	},
 	"forget": {
 		long: "" +
@@ -254,6 +437,57 @@ var helps = map[string]help{
 		example: "" +
 			"  chezmoi hg -- pull --rebase --update",
 	},
+	"history": {
+		long: "" +
+			"Description:\n" +
+			"  Print the history of script runs and applies, oldest first. Each entry records\n" +
+			"  when the script run or apply started, how long it took, its exit code, and\n" +
+			"  whether it failed or timed out. The stdout and stderr of scripts are also\n" +
+			"  recorded, up to 64KB each, unless the `script.recordOutput` configuration\n" +
+			"  variable is `false`. The most recent 1000 entries are kept.\n" +
+			"\n" +
+			"  `-f`, `--format` *format*\n" +
+			"\n" +
+			"  Print the history in the given format. The accepted formats are `table` (the\n" +
+			"  default) and `json`.\n" +
+			"\n" +
+			"  `--limit` *n*\n" +
+			"\n" +
+			"  Print only the *n* most recent entries.\n" +
+			"\n" +
+			"  `-o`, `--output`\n" +
+			"\n" +
+			"  Include the stdout and stderr of scripts.\n" +
+			"\n" +
+			"  `-t`, `--type` *type*\n" +
+			"\n" +
+			"  Print only entries of type *type*, either `apply` or `script`.",
+		example: "" +
+			"  chezmoi history\n" +
+			"  chezmoi history --type=script --limit=5 --output\n" +
+			"  chezmoi history --format=json",
+	},
+	"ignored": {
+		long: "" +
+			"Description:\n" +
+			"  List the targets in the source state that are ignored by `.chezmoiignore`. The\n" +
+			"  contents of ignored directories are not listed.\n" +
+			"\n" +
+			"  `-f`, `--format` *format*\n" +
+			"\n" +
+			"  Print the paths in the given format, either `json` or `yaml`. By default,\n" +
+			"  paths are printed one per line.\n" +
+			"\n" +
+			"  `-p`, `--path-style` *style*\n" +
+			"\n" +
+			"  Print paths in the given style. `relative` (the default) prints paths relative\n" +
+			"  to the destination directory, `absolute` prints absolute paths in the\n" +
+			"  destination directory, and `source` prints absolute paths in the source\n" +
+			"  directory.",
+		example: "" +
+			"  chezmoi ignored\n" +
+			"  chezmoi ignored --path-style=source",
+	},
 	"import": {
 		long: "" +
 			"Description:\n" +
@@ -298,11 +532,70 @@ var helps = map[string]help{
 			"  chezmoi init https://github.com/user/dotfiles.git\n" +
 			"  chezmoi init https://github.com/user/dotfiles.git --apply",
 	},
+	"lint": {
+		long: "" +
+			"Description:\n" +
+			"  Check the source state for problems and report all of them, with their source\n" +
+			"  paths and, where possible, line and column numbers. `lint` exits with a non-\n" +
+			"  zero status if any problems are found.\n" +
+			"\n" +
+			"  Every template is parsed and executed, including files, symlinks, and scripts\n" +
+			"  with the `template` attribute, `.chezmoiignore`, `.chezmoiremove`,\n" +
+			"  `.chezmoiallowsecrets`, `.chezmoitriggers`, and the config file template.\n" +
+			"  Templates in `.chezmoitemplates` are parsed and executed by the templates that\n" +
+			"  use them. Secret functions, such as `pass` and `bitwarden`, secret plugins,\n" +
+			"  `output`, and `promptString` are replaced by stubs that return empty values,\n" +
+			"  so no secret managers are accessed, no commands are run, and no input is\n" +
+			"  needed. Values from sops-encrypted data files are likewise stubbed and never\n" +
+			"  decrypted, and lint assumes that they have every key. Encrypted templates are\n" +
+			"  decrypted.\n" +
+			"\n" +
+			"  In addition, `lint` reports:\n" +
+			"\n" +
+			"  • References to template data keys that are not defined, for example a typo in\n" +
+			"  `{{ .chezmoi.hostnme }}`. Only references to the top-level data are checked.\n" +
+			"  • Templates in `.chezmoitemplates` that are never used.\n" +
+			"  • Files and directories in the source state whose names begin with a `.` and\n" +
+			"  that are therefore ignored, other than special files and version control\n" +
+			"  files such as `.git`.",
+		example: "" +
+			"  chezmoi lint",
+	},
 	"manage": {
 		long: "" +
 			"Description:\n" +
 			"  `manage` is an alias for `add` for symmetry with `unmanage`.",
 	},
+	"managed": {
+		long: "" +
+			"Description:\n" +
+			"  List the managed targets, i.e. the targets in the source state that are not\n" +
+			"  ignored, sorted by target path.\n" +
+			"\n" +
+			"  `-f`, `--format` *format*\n" +
+			"\n" +
+			"  Print the paths in the given format, either `json` or `yaml`. By default,\n" +
+			"  paths are printed one per line.\n" +
+			"\n" +
+			"  `-p`, `--path-style` *style*\n" +
+			"\n" +
+			"  Print paths in the given style. `relative` (the default) prints paths relative\n" +
+			"  to the destination directory, `absolute` prints absolute paths in the\n" +
+			"  destination directory, and `source` prints absolute paths in the source\n" +
+			"  directory.\n" +
+			"\n" +
+			"  `-i`, `--include` *types*\n" +
+			"\n" +
+			"  Only list entries of the given comma-separated types. The types are `dirs`,\n" +
+			"  `files`, `symlinks`, and `scripts`, which are all included by default, and\n" +
+			"  `encrypted` and `templates`, which include encrypted files and templates\n" +
+			"  respectively.",
+		example: "" +
+			"  chezmoi managed\n" +
+			"  chezmoi managed --include=files,symlinks\n" +
+			"  chezmoi managed --include=templates --path-style=source\n" +
+			"  chezmoi managed --format=json",
+	},
 	"merge": {
 		long: "" +
 			"Description:\n" +
@@ -316,6 +609,19 @@ var helps = map[string]help{
 		example: "" +
 			"  chezmoi merge ~/.bashrc",
 	},
+	"mv": {
+		long: "" +
+			"Description:\n" +
+			"  Move *target* to *destination* in both the source state and the destination\n" +
+			"  directory. If *destination* is an existing directory then *target* is moved\n" +
+			"  into it. The new source name keeps all of *target*'s attributes, for example\n" +
+			"  whether it is private, executable, encrypted, or a template. Any parent\n" +
+			"  directories of *destination* that are not already in the source state are\n" +
+			"  added to it. If the source directory is a git repository and *target*'s source\n" +
+			"  file is tracked then it is moved with `git mv`.",
+		example: "" +
+			"  chezmoi mv ~/.vimrc ~/.config/nvim/init.vim",
+	},
 	"purge": {
 		long: "" +
 			"Description:\n" +
@@ -354,9 +660,19 @@ var helps = map[string]help{
 			"\n" +
 			"  To get a full list of available commands run:\n" +
 			"\n" +
-			"    chezmoi secret help",
+			"    chezmoi secret help\n" +
+			"\n" +
+			"  Each secret plugin, an executable called `chezmoi-secret-<name>` in your\n" +
+			"  `$PATH`, adds a `chezmoi secret <name>` command, which sends its arguments to\n" +
+			"  the plugin as a request and prints the result. The plugin protocol is\n" +
+			"  described in the how-to guide.\n" +
+			"\n" +
+			"  `chezmoi secret cache clear` removes all secret manager outputs from the\n" +
+			"  secret cache, and all outputs of templates that call secret manager functions\n" +
+			"  from the template cache. See template execution.",
 		example: "" +
 			"  chezmoi secret bitwarden list items\n" +
+			"  chezmoi secret cache clear\n" +
 			"  chezmoi secret keyring set --service service --user user\n" +
 			"  chezmoi secret keyring get --service service --user user\n" +
 			"  chezmoi secret lastpass ls\n" +
@@ -388,6 +704,23 @@ var helps = map[string]help{
 			"    chezmoi source-path\n" +
 			"    chezmoi source-path ~/.bashrc",
 	},
+	"target-path": {
+		long: "" +
+			"Description:\n" +
+			"  Print the target path of each source path. This is the reverse of `source-\n" +
+			"  path`. Source paths do not need to exist and can be files, directories,\n" +
+			"  scripts, templates, or encrypted files. Special files and directories in the\n" +
+			"  source directory, i.e. those beginning with a `.` such as `.chezmoiignore`, do\n" +
+			"  not have target paths and cause an error. If no source paths are specified\n" +
+			"  then print the destination directory.\n" +
+			"\n" +
+			"  `target-path` examples\n" +
+			"\n" +
+			"    chezmoi target-path\n" +
+			"    chezmoi target-path ~/.local/share/chezmoi/dot_bashrc\n" +
+			"    chezmoi target-path\n" +
+			"  ~/.local/share/chezmoi/private_dot_ssh/encrypted_private_id_rsa.tmpl",
+	},
 	"unmanage": {
 		long: "" +
 			"Description:\n" +
@@ -403,9 +736,31 @@ var helps = map[string]help{
 	"update": {
 		long: "" +
 			"Description:\n" +
-			"  Pull changes from the source VCS and apply any changes.",
+			"  Pull changes from the source VCS and apply any changes.\n" +
+			"\n" +
+			"  `-a`, `--apply`\n" +
+			"\n" +
+			"  Apply changes after pulling, `true` by default. Use `--apply=false` to only\n" +
+			"  pull.\n" +
+			"\n" +
+			"  `--preview`\n" +
+			"\n" +
+			"  Fetch changes from the source VCS without merging them and print the incoming\n" +
+			"  commits, the diff that they would make to the destination directory, and the\n" +
+			"  scripts that they would run, including `run_once_` scripts that have not yet\n" +
+			"  been run. You are then asked whether to merge and apply the changes. The diff\n" +
+			"  is computed from a temporary copy of the fetched revision, so the source\n" +
+			"  directory is not modified unless you continue. If you continue, exactly the\n" +
+			"  previewed revision is integrated, instead of pulling again, so changes pushed\n" +
+			"  after the preview are not merged. The revision is integrated as\n" +
+			"  `sourceVCS.pull` would: with `git rebase` if the pull arguments include `--\n" +
+			"  rebase`, as they do by default, otherwise with `git merge`, passing `--ff`, `--ff-\n" +
+			"  only`, `--no-ff`, `--autostash`, and `--no-autostash`. If you have local commits that\n" +
+			"  are not in the fetched revision, the preview shows the fetched revision\n" +
+			"  without them. Only supported for git.",
 		example: "" +
-			"  chezmoi update",
+			"  chezmoi update\n" +
+			"  chezmoi update --preview",
 	},
 	"upgrade": {
 		long: "" +
@@ -438,4 +793,31 @@ var helps = map[string]help{
 			"  chezmoi verify\n" +
 			"  chezmoi verify ~/.bashrc",
 	},
+	"watch": {
+		long: "" +
+			"Description:\n" +
+			"  Watch the source directory for changes and apply the affected targets as soon\n" +
+			"  as they change. Changes are printed in the same format as the `--verbose` flag.\n" +
+			"  Changes to `.chezmoiignore`, `.chezmoiremove`, `.chezmoitemplates`, and\n" +
+			"  `.chezmoiversion` cause all targets to be applied. Changes to other files and\n" +
+			"  directories beginning with a `.`, like `.git`, are ignored. Changes to the\n" +
+			"  configuration file are not noticed, restart `chezmoi watch` to pick them up.\n" +
+			"  Press Ctrl-C to stop watching.\n" +
+			"\n" +
+			"  `--debounce` *duration*\n" +
+			"\n" +
+			"  Wait until no changes have occurred for *duration* before applying them. The\n" +
+			"  default is `250ms`.\n" +
+			"\n" +
+			"  `--drift` *action*\n" +
+			"\n" +
+			"  Also watch managed targets in the destination directory for changes made by\n" +
+			"  other programs. *action* can be `ignore`, `report`, or `apply`. `report`\n" +
+			"  prints the changes that would be needed to restore the target state, and\n" +
+			"  `apply` restores it. The default is `ignore`.",
+		example: "" +
+			"  chezmoi watch\n" +
+			"  chezmoi watch --drift=report\n" +
+			"  chezmoi watch --dry-run --drift=report",
+	},
 }
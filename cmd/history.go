@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	bolt "go.etcd.io/bbolt"
+)
+
+var historyCmd = &cobra.Command{
+	Use:     "history",
+	Args:    cobra.NoArgs,
+	Short:   "Show the history of script runs and applies",
+	Long:    mustGetLongHelp("history"),
+	Example: getExample("history"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runHistoryCmd,
+}
+
+type historyCmdConfig struct {
+	format string
+	limit  int
+	output bool
+	_type  string
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+
+	persistentFlags := historyCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.history.format, "format", "f", "table", "format (table or JSON)")
+	persistentFlags.IntVar(&config.history.limit, "limit", 0, "show only the most recent entries")
+	persistentFlags.BoolVarP(&config.history.output, "output", "o", false, "show script output")
+	persistentFlags.StringVarP(&config.history._type, "type", "t", "", "show only entries of type (apply or script)")
+}
+
+func (c *Config) runHistoryCmd(cmd *cobra.Command, args []string) error {
+	switch c.history._type {
+	case "", chezmoi.HistoryTypeApply, chezmoi.HistoryTypeScript:
+	default:
+		return fmt.Errorf("%s: unknown type", c.history._type)
+	}
+
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	allEntries, err := chezmoi.HistoryEntries(persistentState, c.historyBucket)
+	if err != nil {
+		return err
+	}
+	entries := make([]*chezmoi.HistoryEntry, 0, len(allEntries))
+	for _, entry := range allEntries {
+		if c.history._type != "" && entry.Type != c.history._type {
+			continue
+		}
+		if !c.history.output {
+			entry.Stdout = ""
+			entry.Stderr = ""
+		}
+		entries = append(entries, entry)
+	}
+	if c.history.limit > 0 && len(entries) > c.history.limit {
+		entries = entries[len(entries)-c.history.limit:]
+	}
+
+	switch strings.ToLower(c.history.format) {
+	case "json":
+		return formatMap["json"](c.Stdout, entries)
+	case "table":
+		return c.printHistoryTable(entries)
+	default:
+		return fmt.Errorf("%s: unknown format", c.history.format)
+	}
+}
+
+func (c *Config) printHistoryTable(entries []*chezmoi.HistoryEntry) error {
+	w := tabwriter.NewWriter(c.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED\tTYPE\tNAME\tDURATION\tEXIT\tSTATUS")
+	for _, entry := range entries {
+		status := "ok"
+		switch {
+		case entry.TimedOut:
+			status = "timed out"
+		case entry.Error != "":
+			status = "failed"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.StartedAt.Local().Format(time.RFC3339),
+			entry.Type,
+			entry.Name,
+			entry.Duration.Round(time.Millisecond),
+			strconv.Itoa(entry.ExitCode),
+			status,
+		)
+		if entry.Stdout != "" {
+			fmt.Fprintf(w, "%s", indentOutput("stdout", entry.Stdout))
+		}
+		if entry.Stderr != "" {
+			fmt.Fprintf(w, "%s", indentOutput("stderr", entry.Stderr))
+		}
+	}
+	return w.Flush()
+}
+
+// indentOutput returns output with each line prefixed with name, so that it
+// is not aligned by a tabwriter.
+func indentOutput(name, output string) string {
+	sb := &strings.Builder{}
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		fmt.Fprintf(sb, "  %s: %s\n", name, strings.ReplaceAll(line, "\t", "    "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,73 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestHistory(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			"run_foo.sh":         "#!/bin/sh\necho foo\necho bar >&2\n",
+			"run_secret.sh.tmpl": "#!/bin/sh\necho password {{ testSecret }}\n",
+			"run_slow.sh":        "#!/bin/sh\n# chezmoi:timeout=100ms\nsleep 10\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	c := newTestConfig(fs, withDestDir("/"))
+	c.redactSecrets()
+	c.addSecretTemplateFunc("testSecret", func() string {
+		return "hunter2"
+	})
+	assert.Error(t, c.runApplyCmd(nil, nil))
+
+	stdout := &bytes.Buffer{}
+	c.Stdout = stdout
+
+	c.history.format = "json"
+	c.history.output = true
+	require.NoError(t, c.runHistoryCmd(nil, nil))
+	var entries []*chezmoi.HistoryEntry
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &entries))
+	require.Len(t, entries, 4)
+
+	// Entries are ordered by start time, so the apply precedes its scripts.
+	assert.Equal(t, chezmoi.HistoryTypeApply, entries[0].Type)
+	assert.Equal(t, "apply", entries[0].Name)
+	assert.Equal(t, 1, entries[0].ExitCode)
+
+	assert.Equal(t, chezmoi.HistoryTypeScript, entries[1].Type)
+	assert.Equal(t, "run_foo.sh", entries[1].Name)
+	assert.Equal(t, 0, entries[1].ExitCode)
+	assert.Equal(t, "foo\n", entries[1].Stdout)
+	assert.Equal(t, "bar\n", entries[1].Stderr)
+
+	// Secrets are redacted from recorded output.
+	assert.Equal(t, "run_secret.sh.tmpl", entries[2].Name)
+	assert.Equal(t, "password "+chezmoi.RedactedText+"\n", entries[2].Stdout)
+
+	assert.Equal(t, chezmoi.HistoryTypeScript, entries[3].Type)
+	assert.Equal(t, "run_slow.sh", entries[3].Name)
+	assert.True(t, entries[3].TimedOut)
+	assert.Contains(t, entries[3].Error, "timed out after 100ms")
+
+	stdout.Reset()
+	c.history.format = "table"
+	c.history.output = false
+	c.history._type = chezmoi.HistoryTypeScript
+	c.history.limit = 1
+	require.NoError(t, c.runHistoryCmd(nil, nil))
+	assert.Contains(t, stdout.String(), "run_slow.sh")
+	assert.Contains(t, stdout.String(), "timed out")
+	assert.NotContains(t, stdout.String(), "run_foo.sh")
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var ignoredCmd = &cobra.Command{
+	Use:     "ignored",
+	Args:    cobra.NoArgs,
+	Short:   "List the ignored targets",
+	Long:    mustGetLongHelp("ignored"),
+	Example: getExample("ignored"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runIgnoredCmd,
+}
+
+type ignoredCmdConfig struct {
+	format    string
+	pathStyle string
+}
+
+func init() {
+	rootCmd.AddCommand(ignoredCmd)
+
+	persistentFlags := ignoredCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.ignored.format, "format", "f", "", "format (JSON or YAML)")
+	persistentFlags.StringVarP(&config.ignored.pathStyle, "path-style", "p", "relative", "path style (absolute, relative, or source)")
+}
+
+func (c *Config) runIgnoredCmd(cmd *cobra.Command, args []string) error {
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+	var entries []chezmoi.Entry
+	walkIgnoredEntries(ts.Entries, ts.TargetIgnore.Match, func(entry chezmoi.Entry) {
+		entries = append(entries, entry)
+	})
+	return c.printEntryPaths(ts, entries, c.ignored.pathStyle, c.ignored.format)
+}
+
+// walkIgnoredEntries calls f on every entry in entries that is ignored,
+// recursively. The contents of ignored directories are not visited.
+func walkIgnoredEntries(entries map[string]chezmoi.Entry, ignore func(string) bool, f func(chezmoi.Entry)) {
+	for _, entry := range entries {
+		if ignore(entry.TargetName()) {
+			f(entry)
+			continue
+		}
+		if dir, ok := entry.(*chezmoi.Dir); ok {
+			walkIgnoredEntries(dir.Entries, ignore, f)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
 	"os"
@@ -101,7 +100,7 @@ func (c *Config) runInitCmd(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
-		if err := c.applyArgs(nil, persistentState); err != nil {
+		if err := c.applyArgsWithHistory(nil, persistentState); err != nil {
 			return err
 		}
 	}
@@ -135,10 +134,15 @@ func (c *Config) createConfigFile() error {
 		return err
 	}
 
-	contents := &bytes.Buffer{}
-	if err = t.Execute(contents, map[string]interface{}{
+	templateData, err := chezmoi.ResolveTemplateData(map[string]interface{}{
 		"chezmoi": defaultData,
-	}); err != nil {
+	}, t)
+	if err != nil {
+		return err
+	}
+
+	contents := &bytes.Buffer{}
+	if err = t.Execute(contents, templateData); err != nil {
 		return err
 	}
 
@@ -174,8 +178,7 @@ func (c *Config) findConfigTemplate() (string, string, string, error) {
 }
 
 func (c *Config) promptString(field string) string {
-	fmt.Fprintf(c.Stdout, "%s? ", field)
-	value, err := bufio.NewReader(c.Stdin).ReadString('\n')
+	value, err := c.readLine(field)
 	panicOnError(err)
-	return strings.TrimSpace(value)
+	return value
 }
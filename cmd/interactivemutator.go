@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+// errInteractiveQuit is returned when the user quits an interactive apply.
+var errInteractiveQuit = errors.New("quit")
+
+// An interactiveDecision records what the user chose to do with a change.
+type interactiveDecision struct {
+	name     string
+	decision string
+}
+
+// An interactiveMutator is a chezmoi.Mutator that shows each change and
+// prompts the user before passing it to another chezmoi.Mutator.
+type interactiveMutator struct {
+	c           *Config
+	ts          *chezmoi.TargetState
+	m           chezmoi.Mutator
+	all         bool
+	quit        bool
+	skippedDirs []string
+	decisions   []interactiveDecision
+}
+
+func newInteractiveMutator(c *Config, ts *chezmoi.TargetState, m chezmoi.Mutator) *interactiveMutator {
+	return &interactiveMutator{
+		c:  c,
+		ts: ts,
+		m:  m,
+	}
+}
+
+// Chmod implements chezmoi.Mutator.Chmod.
+func (m *interactiveMutator) Chmod(name string, mode os.FileMode) error {
+	if ok, err := m.confirm(name, fmt.Sprintf("chmod %o %s", mode, chezmoi.MaybeShellQuote(name))); err != nil || !ok {
+		return err
+	}
+	return m.m.Chmod(name, mode)
+}
+
+// IdempotentCmdOutput implements chezmoi.Mutator.IdempotentCmdOutput.
+func (m *interactiveMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements chezmoi.Mutator.Mkdir.
+func (m *interactiveMutator) Mkdir(name string, perm os.FileMode) error {
+	if ok, err := m.confirm(name, fmt.Sprintf("mkdir -m %o %s", perm, chezmoi.MaybeShellQuote(name))); err != nil {
+		return err
+	} else if !ok {
+		// The directory's contents cannot be created without it, so skip
+		// them too.
+		m.skippedDirs = append(m.skippedDirs, name)
+		return nil
+	}
+	return m.m.Mkdir(name, perm)
+}
+
+// RemoveAll implements chezmoi.Mutator.RemoveAll.
+func (m *interactiveMutator) RemoveAll(name string) error {
+	if ok, err := m.confirm(name, fmt.Sprintf("rm -rf %s", chezmoi.MaybeShellQuote(name))); err != nil || !ok {
+		return err
+	}
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements chezmoi.Mutator.Rename.
+func (m *interactiveMutator) Rename(oldpath, newpath string) error {
+	if ok, err := m.confirm(newpath, fmt.Sprintf("mv %s %s", chezmoi.MaybeShellQuote(oldpath), chezmoi.MaybeShellQuote(newpath))); err != nil || !ok {
+		return err
+	}
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements chezmoi.Mutator.RunCmd.
+func (m *interactiveMutator) RunCmd(cmd *exec.Cmd) error {
+	name := chezmoi.ShellQuoteArgs(cmd.Args)
+	if ok, err := m.confirm(name, name); err != nil || !ok {
+		return err
+	}
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements chezmoi.Mutator.Stat.
+func (m *interactiveMutator) Stat(name string) (os.FileInfo, error) {
+	return m.m.Stat(name)
+}
+
+// WriteFile implements chezmoi.Mutator.WriteFile.
+func (m *interactiveMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	if m.quit {
+		return errInteractiveQuit
+	}
+	if m.skipped(name) {
+		return nil
+	}
+	if m.all {
+		m.decide(name, "applied")
+		return m.m.WriteFile(name, data, perm, currData)
+	}
+
+	// Show the change using the same format as the verbose and diff output.
+	// Secrets are not redacted as the user needs to see the whole change to
+	// decide whether to apply it.
+	verboseMutator := chezmoi.NewVerboseMutator(m.c.Stdout, chezmoi.NullMutator{}, m.c.colored, m.c.maxDiffDataSize, nil)
+	if err := verboseMutator.WriteFile(name, data, perm, currData); err != nil {
+		return err
+	}
+
+	// Only offer hunk selection for text files with existing contents, and
+	// merging for files in the target state.
+	choices := "yn"
+	lineDiff := chezmoi.NewLineDiff(currData, data, 3)
+	if currData != nil && lineDiff.Hunks() > 1 && !chezmoi.IsBinary(currData) && !chezmoi.IsBinary(data) {
+		choices += "p"
+	}
+	if _, ok := m.mergeEntry(name); ok && !m.c.DryRun {
+		choices += "m"
+	}
+	choices += "qa"
+
+	choice, err := m.c.prompt(fmt.Sprintf("Apply %s", name), choices)
+	if err != nil {
+		return err
+	}
+	switch choice {
+	case 'y':
+		m.decide(name, "applied")
+		return m.m.WriteFile(name, data, perm, currData)
+	case 'n':
+		m.decide(name, "skipped")
+		return nil
+	case 'p':
+		return m.writeHunks(name, lineDiff, perm, currData)
+	case 'm':
+		return m.merge(name)
+	case 'q':
+		m.quit = true
+		return errInteractiveQuit
+	case 'a':
+		m.all = true
+		m.decide(name, "applied")
+		return m.m.WriteFile(name, data, perm, currData)
+	}
+	return nil
+}
+
+// WriteSymlink implements chezmoi.Mutator.WriteSymlink.
+func (m *interactiveMutator) WriteSymlink(oldname, newname string) error {
+	if ok, err := m.confirm(newname, fmt.Sprintf("ln -sf %s %s", chezmoi.MaybeShellQuote(oldname), chezmoi.MaybeShellQuote(newname))); err != nil || !ok {
+		return err
+	}
+	return m.m.WriteSymlink(oldname, newname)
+}
+
+// confirm shows action and returns whether the user chose to apply it to name.
+func (m *interactiveMutator) confirm(name, action string) (bool, error) {
+	if m.quit {
+		return false, errInteractiveQuit
+	}
+	if m.skipped(name) {
+		return false, nil
+	}
+	if m.all {
+		m.decide(name, "applied")
+		return true, nil
+	}
+	if _, err := fmt.Fprintln(m.c.Stdout, action); err != nil {
+		return false, err
+	}
+	choice, err := m.c.prompt(fmt.Sprintf("Apply %s", name), "ynqa")
+	if err != nil {
+		return false, err
+	}
+	switch choice {
+	case 'y':
+		m.decide(name, "applied")
+		return true, nil
+	case 'n':
+		m.decide(name, "skipped")
+		return false, nil
+	case 'q':
+		m.quit = true
+		return false, errInteractiveQuit
+	case 'a':
+		m.all = true
+		m.decide(name, "applied")
+		return true, nil
+	}
+	return false, nil
+}
+
+// confirmScript shows the contents of the script name and returns whether the
+// user chose to run it.
+func (m *interactiveMutator) confirmScript(name string, contents []byte) (bool, error) {
+	if m.quit {
+		return false, errInteractiveQuit
+	}
+	if !m.all {
+		if _, err := m.c.Stdout.Write(contents); err != nil {
+			return false, err
+		}
+	}
+	return m.confirm(name, "run "+name)
+}
+
+// decide records decision for name.
+func (m *interactiveMutator) decide(name, decision string) {
+	m.decisions = append(m.decisions, interactiveDecision{
+		name:     name,
+		decision: decision,
+	})
+}
+
+// merge runs the merge command on the file name.
+func (m *interactiveMutator) merge(name string) error {
+	entry, _ := m.mergeEntry(name)
+	tempDir, err := ioutil.TempDir("", "chezmoi")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+	if err := m.c.runMergeCommand(applyCmd, name, entry, tempDir); err != nil {
+		return err
+	}
+	m.decide(name, "merged")
+	return nil
+}
+
+// mergeEntry returns the entry in the target state for name, and whether it
+// can be merged.
+func (m *interactiveMutator) mergeEntry(name string) (chezmoi.Entry, bool) {
+	entry, err := m.ts.Get(m.c.fs, name)
+	if err != nil || entry == nil {
+		return nil, false
+	}
+	_, ok := entry.(*chezmoi.File)
+	return entry, ok
+}
+
+// printSummary prints the decisions made.
+func (m *interactiveMutator) printSummary() {
+	if len(m.decisions) == 0 {
+		return
+	}
+	fmt.Fprintln(m.c.Stdout, "Summary:")
+	for _, d := range m.decisions {
+		fmt.Fprintf(m.c.Stdout, "  %s: %s\n", d.name, d.decision)
+	}
+	if m.quit {
+		fmt.Fprintln(m.c.Stdout, "  (quit before all changes were considered)")
+	}
+}
+
+// skipped returns whether name is in a directory that the user chose not to
+// create.
+func (m *interactiveMutator) skipped(name string) bool {
+	for _, dir := range m.skippedDirs {
+		if strings.HasPrefix(name, dir+string(filepath.Separator)) {
+			m.decide(name, "skipped")
+			return true
+		}
+	}
+	return false
+}
+
+// writeHunks prompts the user for each hunk in lineDiff and writes the selected
+// hunks to name.
+func (m *interactiveMutator) writeHunks(name string, lineDiff *chezmoi.LineDiff, perm os.FileMode, currData []byte) error {
+	selected := make([]bool, lineDiff.Hunks())
+	count := 0
+FOR:
+	for i := range selected {
+		if err := lineDiff.WriteHunk(m.c.Stdout, i, name, m.c.colored); err != nil {
+			return err
+		}
+		choice, err := m.c.prompt(fmt.Sprintf("Apply this hunk (%d/%d)", i+1, len(selected)), "ynqad")
+		if err != nil {
+			return err
+		}
+		switch choice {
+		case 'y':
+			selected[i] = true
+			count++
+		case 'n':
+		case 'q':
+			m.quit = true
+			break FOR
+		case 'a':
+			for j := i; j < len(selected); j++ {
+				selected[j] = true
+				count++
+			}
+			break FOR
+		case 'd':
+			break FOR
+		}
+	}
+	if count == 0 {
+		m.decide(name, "skipped")
+	} else {
+		m.decide(name, fmt.Sprintf("applied %d of %d hunks", count, len(selected)))
+		data := lineDiff.Apply(func(i int) bool {
+			return selected[i]
+		})
+		if err := m.m.WriteFile(name, data, perm, currData); err != nil {
+			return err
+		}
+	}
+	if m.quit {
+		return errInteractiveQuit
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+var _ chezmoi.Mutator = &interactiveMutator{}
+
+func TestApplyInteractive(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		input       string
+		tests       []vfst.Test
+		wantSummary []string
+	}{
+		{
+			name:  "yes_no",
+			input: "y\nn\n",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestContentsString("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"),
+				),
+				vfst.TestPath("/home/user/.inputrc",
+					vfst.TestDoesNotExist,
+				),
+			},
+			wantSummary: []string{
+				"/home/user/.bashrc: applied",
+				"/home/user/.inputrc: skipped",
+			},
+		},
+		{
+			name:  "hunks",
+			input: "p\ny\nn\nn\n",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestContentsString("1\n2\n3\n4\n5\n6\n7\n8\n0\n10\n"),
+				),
+			},
+			wantSummary: []string{
+				"/home/user/.bashrc: applied 1 of 2 hunks",
+			},
+		},
+		{
+			name:  "all",
+			input: "a\n",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestContentsString("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"),
+				),
+				vfst.TestPath("/home/user/.inputrc",
+					vfst.TestContentsString("# contents of .inputrc\n"),
+				),
+			},
+			wantSummary: []string{
+				"/home/user/.bashrc: applied",
+				"/home/user/.inputrc: applied",
+			},
+		},
+		{
+			name:  "quit",
+			input: "q\n",
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestContentsString("0\n2\n3\n4\n5\n6\n7\n8\n0\n10\n"),
+				),
+				vfst.TestPath("/home/user/.inputrc",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bashrc": "0\n2\n3\n4\n5\n6\n7\n8\n0\n10\n",
+					".local/share/chezmoi": map[string]interface{}{
+						"dot_bashrc":  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+						"dot_inputrc": "# contents of .inputrc\n",
+					},
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs,
+				withApplyCmdConfig(applyCmdConfig{
+					interactive: true,
+				}),
+				withStdin(strings.NewReader(tc.input)),
+				withStdout(stdout),
+			)
+			require.NoError(t, c.runApplyCmd(nil, nil))
+			vfst.RunTests(t, fs, "", tc.tests)
+			for _, line := range tc.wantSummary {
+				assert.Contains(t, stdout.String(), "  "+line+"\n")
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+
+	keyring "github.com/zalando/go-keyring"
+)
+
+// A keyringAEAD encrypts data with a key stored in the OS keyring. If the
+// keyring is not available then nothing is encrypted or decrypted.
+type keyringAEAD struct {
+	service string
+	user    string
+	aead    cipher.AEAD
+	aeadErr error
+}
+
+// get returns the cipher, creating a new key in the OS keyring if create is
+// true and there is none. It returns nil if the keyring is not available.
+func (k *keyringAEAD) get(create bool) (cipher.AEAD, error) {
+	if k.aead != nil || k.aeadErr != nil {
+		return k.aead, nil
+	}
+	var key []byte
+	encodedKey, err := keyring.Get(k.service, k.user)
+	switch {
+	case err == nil:
+		key, err = base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			k.aeadErr = err
+			return nil, nil
+		}
+	case errors.Is(err, keyring.ErrNotFound) && create:
+		key = make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		if err := keyring.Set(k.service, k.user, base64.StdEncoding.EncodeToString(key)); err != nil {
+			k.aeadErr = err
+			return nil, nil
+		}
+	case errors.Is(err, keyring.ErrNotFound):
+		return nil, nil
+	default:
+		k.aeadErr = err
+		return nil, nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		k.aeadErr = err
+		return nil, nil
+	}
+	k.aead, err = cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return k.aead, nil
+}
+
+// open decrypts ciphertext, which was encrypted by seal with additionalData.
+// It returns nil if ciphertext cannot be decrypted, for example because the key
+// has changed since it was encrypted.
+func (k *keyringAEAD) open(ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := k.get(false)
+	if err != nil || aead == nil {
+		return nil, err
+	}
+	nonceSize := aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, nil
+	}
+	plaintext, err := aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], additionalData)
+	if err != nil {
+		return nil, nil
+	}
+	if plaintext == nil {
+		plaintext = []byte{}
+	}
+	return plaintext, nil
+}
+
+// seal encrypts plaintext, authenticating it with additionalData. It returns
+// nil if the keyring is not available.
+func (k *keyringAEAD) seal(plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := k.get(true)
+	if err != nil || aead == nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+var lintCmd = &cobra.Command{
+	Use:     "lint",
+	Args:    cobra.NoArgs,
+	Short:   "Check the source state for problems",
+	Long:    mustGetLongHelp("lint"),
+	Example: getExample("lint"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runLintCmd,
+}
+
+// lintStubValue is the result of stubbed secret template functions that
+// return structured values. Missing map keys evaluate to no value, so any
+// field of lintStubValue can be referenced.
+var lintStubValue = map[string]interface{}{}
+
+// commandTemplateFuncs are template functions, other than secret template
+// functions, that run commands. lint stubs them so that it does not run any
+// code.
+var commandTemplateFuncs = map[string]struct{}{
+	"output": {},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
+
+func (c *Config) runLintCmd(cmd *cobra.Command, args []string) error {
+	// Stub the values in sops-encrypted data files so that they are never
+	// decrypted.
+	sopsData, err := c.getSOPSData()
+	if err != nil {
+		return err
+	}
+	data, err := c.getDataWithSOPSData(stubLintSecretValues(sopsData))
+	if err != nil {
+		return err
+	}
+
+	templateFuncs := c.getLintTemplateFuncs()
+	templateOptions := getLintTemplateOptions(c.Template.Options)
+
+	ts := chezmoi.NewTargetState(
+		chezmoi.WithGPG(c.getGPG()),
+		chezmoi.WithSourceDir(c.SourceDir),
+		chezmoi.WithTemplateData(data),
+		chezmoi.WithTemplateFuncs(templateFuncs),
+		chezmoi.WithTemplateOptions(templateOptions),
+		chezmoi.WithUmask(os.FileMode(c.Umask)),
+	)
+	problems, err := ts.Lint(vfs.NewReadOnlyFS(c.fs))
+	if err != nil {
+		return err
+	}
+
+	// Lint the config file template with the same functions and data that
+	// chezmoi init uses.
+	filename, _, configTemplate, err := c.findConfigTemplate()
+	if err != nil {
+		return err
+	}
+	if filename != "" {
+		defaultData, err := c.getDefaultData()
+		if err != nil {
+			return err
+		}
+		configTemplateFuncs := make(template.FuncMap, len(templateFuncs)+1)
+		for key, value := range templateFuncs {
+			configTemplateFuncs[key] = value
+		}
+		configTemplateFuncs["promptString"] = func(string) string {
+			return ""
+		}
+		configTS := chezmoi.NewTargetState(
+			chezmoi.WithSourceDir(c.SourceDir),
+			chezmoi.WithTemplateData(map[string]interface{}{
+				"chezmoi": defaultData,
+			}),
+			chezmoi.WithTemplateFuncs(configTemplateFuncs),
+			chezmoi.WithTemplateOptions(nil),
+		)
+		configTemplatePath := filepath.Join(c.SourceDir, "."+filename+chezmoi.TemplateSuffix)
+		problems = append(problems, configTS.LintTemplateData(configTemplatePath, []byte(configTemplate))...)
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintln(c.Stdout, problem)
+	}
+	switch len(problems) {
+	case 0:
+		return nil
+	case 1:
+		return errors.New("1 problem found")
+	default:
+		return fmt.Errorf("%d problems found", len(problems))
+	}
+}
+
+// getLintTemplateFuncs returns c's template functions with every secret
+// function and every function that runs a command replaced by a stub, so that
+// templates can be executed without accessing any secret manager or running
+// any commands.
+func (c *Config) getLintTemplateFuncs() template.FuncMap {
+	templateFuncs := make(template.FuncMap, len(c.templateFuncs))
+	for key, value := range c.templateFuncs {
+		if _, ok := c.secretTemplateFuncs[key]; ok {
+			value = stubTemplateFunc(value)
+		} else if _, ok := commandTemplateFuncs[key]; ok {
+			value = stubTemplateFunc(value)
+		}
+		templateFuncs[key] = value
+	}
+	return templateFuncs
+}
+
+// getLintTemplateOptions returns options without any missingkey option. Lint
+// reports references to undefined template data itself, and the values
+// returned by stubbed secret functions have no keys.
+func getLintTemplateOptions(options []string) []string {
+	var lintOptions []string
+	for _, option := range options {
+		if !strings.HasPrefix(option, "missingkey=") {
+			lintOptions = append(lintOptions, option)
+		}
+	}
+	return lintOptions
+}
+
+// stubLintSecretValues returns a copy of data with every LazyValue replaced by
+// a secret LazyValue whose value is lintStubValue. Lint assumes that secret
+// values have every key.
+func stubLintSecretValues(data map[string]interface{}) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		switch value := value.(type) {
+		case *chezmoi.LazyValue:
+			result[key] = chezmoi.NewSecretLazyValue(func() (interface{}, error) {
+				return lintStubValue, nil
+			})
+		case map[string]interface{}:
+			result[key] = stubLintSecretValues(value)
+		default:
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// stubTemplateFunc returns a function with the same signature as fn that
+// returns lintStubValue for empty interface results and zero values for all
+// other results.
+func stubTemplateFunc(fn interface{}) interface{} {
+	fnType := reflect.TypeOf(fn)
+	return reflect.MakeFunc(fnType, func([]reflect.Value) []reflect.Value {
+		results := make([]reflect.Value, fnType.NumOut())
+		for i := range results {
+			results[i] = reflect.New(fnType.Out(i)).Elem()
+			if outType := fnType.Out(i); outType.Kind() == reflect.Interface && outType.NumMethod() == 0 {
+				results[i].Set(reflect.ValueOf(lintStubValue))
+			}
+		}
+		return results
+	}).Interface()
+}
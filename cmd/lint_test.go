@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestLintCmd(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		root             map[string]interface{}
+		expectedErr      string
+		expectedProblems []string
+	}{
+		{
+			name: "no_problems",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoi.toml.tmpl": "[data]\n  email = \"{{ promptString \"email\" }}\"\n",
+					"dot_netrc.tmpl":     "password {{ (secretJSON \"netrc\").login.password }}\n",
+					"dot_bashrc.tmpl":    "# {{ .chezmoi.os }} {{ .email }}\n",
+					"dot_output.tmpl":    "{{ output \"false\" }}\n",
+				},
+			},
+		},
+		{
+			name: "problems",
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi": map[string]interface{}{
+					".chezmoi.toml.tmpl": "{{ .chezmoi.hostnme }}\n",
+					"dot_bashrc.tmpl":    "# {{ .emial }}\n",
+				},
+			},
+			expectedErr: "2 problems found",
+			expectedProblems: []string{
+				"/home/user/.local/share/chezmoi/dot_bashrc.tmpl:1:5: undefined data key .emial\n",
+				"/home/user/.local/share/chezmoi/.chezmoi.toml.tmpl:1:11: undefined data key .chezmoi.hostnme\n",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(
+				fs,
+				withData(map[string]interface{}{
+					"email": "user@example.com",
+				}),
+				withGenericSecretCmdConfig(genericSecretCmdConfig{
+					Command: "false",
+				}),
+				withStdout(stdout),
+			)
+			c.addSecretTemplateFunc("secretJSON", c.secretJSONFunc)
+			c.addTemplateFunc("output", c.outputFunc)
+			err = c.runLintCmd(nil, nil)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Empty(t, stdout.String())
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+			for _, expectedProblem := range tc.expectedProblems {
+				assert.Contains(t, stdout.String(), expectedProblem)
+			}
+		})
+	}
+}
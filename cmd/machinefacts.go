@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	shell "github.com/twpayne/go-shell"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// knownPackageManagers are the package managers that are detected, in the
+// order in which they are reported.
+var knownPackageManagers = []string{
+	"apk",
+	"apt",
+	"brew",
+	"choco",
+	"dnf",
+	"emerge",
+	"flatpak",
+	"nix",
+	"pacman",
+	"pkg",
+	"port",
+	"scoop",
+	"snap",
+	"winget",
+	"xbps-install",
+	"yum",
+	"zypper",
+}
+
+// machineFacts computes facts about the current machine that are relatively
+// expensive or rarely needed, and so are only computed when a template
+// references them.
+type machineFacts struct {
+	fs       vfs.FS
+	getenv   func(string) string
+	lookPath func(string) (string, error)
+}
+
+// lazyData returns the facts as template data.
+func (f *machineFacts) lazyData() map[string]interface{} {
+	return map[string]interface{}{
+		"container": chezmoi.NewLazyValue(func() (interface{}, error) {
+			return getContainer(f.fs)
+		}),
+		"cpuCount": chezmoi.NewLazyValue(func() (interface{}, error) {
+			return runtime.NumCPU(), nil
+		}),
+		"desktopSession": chezmoi.NewLazyValue(func() (interface{}, error) {
+			return f.getenv("XDG_SESSION_TYPE"), nil
+		}),
+		"display": chezmoi.NewLazyValue(func() (interface{}, error) {
+			return f.display(), nil
+		}),
+		"locale": chezmoi.NewLazyValue(func() (interface{}, error) {
+			return f.locale(), nil
+		}),
+		"packageManagers": chezmoi.NewLazyValue(func() (interface{}, error) {
+			return f.packageManagers(), nil
+		}),
+		"shell": chezmoi.NewLazyValue(func() (interface{}, error) {
+			userShell, _ := shell.CurrentUserShell()
+			return userShell, nil
+		}),
+		"timezone": chezmoi.NewLazyValue(func() (interface{}, error) {
+			return f.timezone()
+		}),
+		"totalMemory": chezmoi.NewLazyValue(func() (interface{}, error) {
+			return getTotalMemory(f.fs)
+		}),
+		"virtualization": chezmoi.NewLazyValue(func() (interface{}, error) {
+			return getVirtualization(f.fs)
+		}),
+		"wsl": chezmoi.NewLazyValue(func() (interface{}, error) {
+			return isWSL(f.fs)
+		}),
+	}
+}
+
+// display returns whether a graphical display is available.
+func (f *machineFacts) display() bool {
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return true
+	default:
+		return f.getenv("DISPLAY") != "" || f.getenv("WAYLAND_DISPLAY") != ""
+	}
+}
+
+// locale returns the current locale, for example en_US.UTF-8.
+func (f *machineFacts) locale() string {
+	for _, key := range []string{"LC_ALL", "LANG"} {
+		if locale := f.getenv(key); locale != "" {
+			return locale
+		}
+	}
+	return ""
+}
+
+// packageManagers returns the package managers that are in $PATH.
+func (f *machineFacts) packageManagers() []string {
+	packageManagers := []string{}
+	for _, name := range knownPackageManagers {
+		if _, err := f.lookPath(name); err == nil {
+			packageManagers = append(packageManagers, name)
+		}
+	}
+	return packageManagers
+}
+
+// timezone returns the name of the local timezone, for example Europe/Berlin,
+// or the empty string if it cannot be determined.
+func (f *machineFacts) timezone() (string, error) {
+	if tz := f.getenv("TZ"); tz != "" {
+		return strings.TrimPrefix(tz, ":"), nil
+	}
+	data, err := f.fs.ReadFile("/etc/timezone")
+	switch {
+	case err == nil:
+		if tz := strings.TrimSpace(string(data)); tz != "" {
+			return tz, nil
+		}
+	case os.IsNotExist(err), os.IsPermission(err):
+	default:
+		return "", err
+	}
+	// /etc/localtime is typically a symlink to the timezone's file in the
+	// zoneinfo database.
+	linkname, err := f.fs.Readlink("/etc/localtime")
+	if err != nil {
+		return "", nil
+	}
+	const zoneinfo = "zoneinfo/"
+	if index := strings.LastIndex(linkname, zoneinfo); index != -1 {
+		return linkname[index+len(zoneinfo):], nil
+	}
+	return "", nil
+}
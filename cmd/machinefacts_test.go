@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestMachineFacts(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/etc": map[string]interface{}{
+			"localtime": &vfst.Symlink{Target: "/usr/share/zoneinfo/Europe/Berlin"},
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	for _, tc := range []struct {
+		name                    string
+		env                     map[string]string
+		path                    []string
+		expectedDisplay         bool
+		expectedLocale          string
+		expectedPackageManagers []string
+		expectedTimezone        string
+	}{
+		{
+			name:                    "empty",
+			expectedPackageManagers: []string{},
+			expectedTimezone:        "Europe/Berlin",
+		},
+		{
+			name: "desktop",
+			env: map[string]string{
+				"DISPLAY": ":0",
+				"LANG":    "de_DE.UTF-8",
+				"TZ":      ":America/New_York",
+			},
+			path:                    []string{"apt", "flatpak", "snap"},
+			expectedDisplay:         true,
+			expectedLocale:          "de_DE.UTF-8",
+			expectedPackageManagers: []string{"apt", "flatpak", "snap"},
+			expectedTimezone:        "America/New_York",
+		},
+		{
+			name: "lc_all",
+			env: map[string]string{
+				"LANG":   "de_DE.UTF-8",
+				"LC_ALL": "C",
+			},
+			expectedLocale:          "C",
+			expectedPackageManagers: []string{},
+			expectedTimezone:        "Europe/Berlin",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &machineFacts{
+				fs: fs,
+				getenv: func(key string) string {
+					return tc.env[key]
+				},
+				lookPath: func(file string) (string, error) {
+					for _, name := range tc.path {
+						if name == file {
+							return "/usr/bin/" + name, nil
+						}
+					}
+					return "", errors.New("not found")
+				},
+			}
+			if runtime.GOOS == "linux" {
+				assert.Equal(t, tc.expectedDisplay, f.display())
+			}
+			assert.Equal(t, tc.expectedLocale, f.locale())
+			assert.Equal(t, tc.expectedPackageManagers, f.packageManagers())
+			timezone, err := f.timezone()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedTimezone, timezone)
+		})
+	}
+}
+
+func TestMachineFactsTimezoneFile(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/etc": map[string]interface{}{
+			"localtime": &vfst.Symlink{Target: "/usr/share/zoneinfo/Europe/Berlin"},
+			"timezone":  "Europe/London\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	f := &machineFacts{
+		fs: fs,
+		getenv: func(string) string {
+			return ""
+		},
+	}
+	timezone, err := f.timezone()
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/London", timezone)
+}
+
+func TestMachineFactsLazy(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	var lookPathCalls int
+	f := &machineFacts{
+		fs: fs,
+		getenv: func(key string) string {
+			if key == "LANG" {
+				return "en_US.UTF-8"
+			}
+			return ""
+		},
+		lookPath: func(file string) (string, error) {
+			lookPathCalls++
+			return "", errors.New("not found")
+		},
+	}
+	data := map[string]interface{}{
+		"chezmoi": f.lazyData(),
+	}
+
+	ts := chezmoi.NewTargetState(
+		chezmoi.WithTemplateData(data),
+	)
+	output, err := ts.ExecuteTemplateData("template", []byte("{{ .chezmoi.locale }}"))
+	require.NoError(t, err)
+	assert.Equal(t, "en_US.UTF-8", string(output))
+	assert.Equal(t, 0, lookPathCalls)
+
+	output, err = ts.ExecuteTemplateData("template", []byte("{{ .chezmoi.packageManagers }}"))
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(output))
+	assert.Equal(t, len(knownPackageManagers), lookPathCalls)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var managedCmd = &cobra.Command{
+	Use:     "managed",
+	Args:    cobra.NoArgs,
+	Short:   "List the managed targets",
+	Long:    mustGetLongHelp("managed"),
+	Example: getExample("managed"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runManagedCmd,
+}
+
+type managedCmdConfig struct {
+	format    string
+	include   []string
+	pathStyle string
+}
+
+// managedIncludes are the valid values of the managed command's --include
+// flag.
+var managedIncludes = map[string]struct{}{
+	"dirs":      {},
+	"encrypted": {},
+	"files":     {},
+	"scripts":   {},
+	"symlinks":  {},
+	"templates": {},
+}
+
+func init() {
+	rootCmd.AddCommand(managedCmd)
+
+	persistentFlags := managedCmd.PersistentFlags()
+	persistentFlags.StringVarP(&config.managed.format, "format", "f", "", "format (JSON or YAML)")
+	persistentFlags.StringSliceVarP(&config.managed.include, "include", "i", []string{"dirs", "files", "symlinks", "scripts"}, "include entry types")
+	persistentFlags.StringVarP(&config.managed.pathStyle, "path-style", "p", "relative", "path style (absolute, relative, or source)")
+}
+
+func (c *Config) runManagedCmd(cmd *cobra.Command, args []string) error {
+	include := make(map[string]struct{})
+	for _, s := range c.managed.include {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if _, ok := managedIncludes[s]; !ok {
+			return fmt.Errorf("%s: unknown include", s)
+		}
+		include[s] = struct{}{}
+	}
+
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+
+	var entries []chezmoi.Entry
+	walkEntriesNotIgnored(ts.Entries, ts.TargetIgnore.Match, func(entry chezmoi.Entry) {
+		if managedEntryIncluded(entry, include) {
+			entries = append(entries, entry)
+		}
+	})
+	return c.printEntryPaths(ts, entries, c.managed.pathStyle, c.managed.format)
+}
+
+// managedEntryIncluded returns whether entry is of one of the types in
+// include.
+func managedEntryIncluded(entry chezmoi.Entry, include map[string]struct{}) bool {
+	has := func(s string) bool {
+		_, ok := include[s]
+		return ok
+	}
+	switch entry := entry.(type) {
+	case *chezmoi.Dir:
+		return has("dirs")
+	case *chezmoi.File:
+		return has("files") || has("encrypted") && entry.Encrypted || has("templates") && entry.Template
+	case *chezmoi.Script:
+		return has("scripts") || has("templates") && entry.Template
+	case *chezmoi.Symlink:
+		return has("symlinks") || has("templates") && entry.Template
+	default:
+		return false
+	}
+}
+
+// printEntryPaths prints the paths of entries in ts, sorted by target name, in
+// pathStyle and format.
+func (c *Config) printEntryPaths(ts *chezmoi.TargetState, entries []chezmoi.Entry, pathStyle, format string) error {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].TargetName() < entries[j].TargetName()
+	})
+	paths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		switch strings.ToLower(pathStyle) {
+		case "absolute":
+			paths = append(paths, filepath.Join(ts.DestDir, entry.TargetName()))
+		case "relative":
+			paths = append(paths, entry.TargetName())
+		case "source":
+			paths = append(paths, filepath.Join(ts.SourceDir, entry.SourceName()))
+		default:
+			return fmt.Errorf("%s: unknown path style", pathStyle)
+		}
+	}
+
+	if format == "" {
+		for _, path := range paths {
+			if _, err := fmt.Fprintln(c.Stdout, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	formatFunc, ok := formatMap[strings.ToLower(format)]
+	if !ok {
+		return fmt.Errorf("%s: unknown format", format)
+	}
+	return formatFunc(c.Stdout, paths)
+}
+
+// walkEntriesNotIgnored calls f on every entry in entries that is not ignored,
+// recursively. The contents of ignored directories are also ignored.
+func walkEntriesNotIgnored(entries map[string]chezmoi.Entry, ignore func(string) bool, f func(chezmoi.Entry)) {
+	for _, entry := range entries {
+		if ignore(entry.TargetName()) {
+			continue
+		}
+		f(entry)
+		if dir, ok := entry.(*chezmoi.Dir); ok {
+			walkEntriesNotIgnored(dir.Entries, ignore, f)
+		}
+	}
+}
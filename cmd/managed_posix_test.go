@@ -0,0 +1,106 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestManagedAndIgnoredCommands(t *testing.T) {
+	root := map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoiignore":        "README.md\n.ignored\n",
+			"README.md":             "# README\n",
+			"dot_bashrc":            "# contents of .bashrc\n",
+			"dot_dir/file.tmpl":     "{{ \"# contents of .dir/file\" }}\n",
+			"dot_ignored/file":      "# contents of .ignored/file\n",
+			"encrypted_dot_secret":  "",
+			"run_script.sh":         "#!/bin/sh\n",
+			"symlink_dot_symlink":   ".bashrc",
+			"dot_dir/symlink_other": "file",
+		},
+	}
+	for _, tc := range []struct {
+		name string
+		run  func(*Config) error
+		want string
+	}{
+		{
+			name: "managed",
+			run: func(c *Config) error {
+				c.managed.include = []string{"dirs", "files", "symlinks", "scripts"}
+				c.managed.pathStyle = "relative"
+				return c.runManagedCmd(nil, nil)
+			},
+			want: "" +
+				".bashrc\n" +
+				".dir\n" +
+				".dir/file\n" +
+				".dir/other\n" +
+				".secret\n" +
+				".symlink\n" +
+				"script.sh\n",
+		},
+		{
+			name: "managed_files_absolute",
+			run: func(c *Config) error {
+				c.managed.include = []string{"files"}
+				c.managed.pathStyle = "absolute"
+				return c.runManagedCmd(nil, nil)
+			},
+			want: "" +
+				"/home/user/.bashrc\n" +
+				"/home/user/.dir/file\n" +
+				"/home/user/.secret\n",
+		},
+		{
+			name: "managed_encrypted_and_templates_source",
+			run: func(c *Config) error {
+				c.managed.include = []string{"encrypted", "templates"}
+				c.managed.pathStyle = "source"
+				return c.runManagedCmd(nil, nil)
+			},
+			want: "" +
+				"/home/user/.local/share/chezmoi/dot_dir/file.tmpl\n" +
+				"/home/user/.local/share/chezmoi/encrypted_dot_secret\n",
+		},
+		{
+			name: "managed_symlinks_json",
+			run: func(c *Config) error {
+				c.managed.include = []string{"symlinks"}
+				c.managed.pathStyle = "relative"
+				c.managed.format = "json"
+				return c.runManagedCmd(nil, nil)
+			},
+			want: "[\n" +
+				"  \".dir/other\",\n" +
+				"  \".symlink\"\n" +
+				"]\n",
+		},
+		{
+			name: "ignored",
+			run: func(c *Config) error {
+				c.ignored.pathStyle = "relative"
+				return c.runIgnoredCmd(nil, nil)
+			},
+			want: "" +
+				".ignored\n" +
+				"README.md\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(root)
+			require.NoError(t, err)
+			defer cleanup()
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withStdout(stdout))
+			require.NoError(t, tc.run(c))
+			assert.Equal(t, tc.want, stdout.String())
+		})
+	}
+}
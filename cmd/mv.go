@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+var mvCmd = &cobra.Command{
+	Use:      "mv target destination",
+	Args:     cobra.ExactArgs(2),
+	Short:    "Move a target in the source state and the destination directory",
+	Long:     mustGetLongHelp("mv"),
+	Example:  getExample("mv"),
+	PreRunE:  config.ensureNoError,
+	RunE:     config.runMvCmd,
+	PostRunE: config.autoCommitAndAutoPush,
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+
+	markRemainingZshCompPositionalArgumentsAsFiles(mvCmd, 1)
+}
+
+func (c *Config) runMvCmd(cmd *cobra.Command, args []string) error {
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+	entries, err := c.getEntries(ts, args[:1])
+	if err != nil {
+		return err
+	}
+	entry := entries[0]
+
+	oldTargetPath := filepath.Join(ts.DestDir, entry.TargetName())
+	newTargetPath, err := filepath.Abs(args[1])
+	if err != nil {
+		return err
+	}
+	// If the destination is an existing directory then move the target into
+	// it.
+	if info, err := c.fs.Stat(newTargetPath); err == nil && info.IsDir() {
+		newTargetPath = filepath.Join(newTargetPath, filepath.Base(oldTargetPath))
+	}
+	newTargetName, ok := relPathWithin(ts.DestDir, newTargetPath)
+	if !ok {
+		return fmt.Errorf("%s: outside target directory", args[1])
+	}
+	if newTargetName == entry.TargetName() || strings.HasPrefix(newTargetName, entry.TargetName()+string(filepath.Separator)) {
+		return fmt.Errorf("%s: cannot move %s into itself", args[1], args[0])
+	}
+	if newEntry, _ := ts.Get(c.fs, newTargetPath); newEntry != nil {
+		return fmt.Errorf("%s: already in source state", args[1])
+	}
+
+	newSourceBase, err := mvSourceBase(entry, filepath.Base(newTargetName))
+	if err != nil {
+		return err
+	}
+	oldSourcePath := filepath.Join(ts.SourceDir, entry.SourceName())
+
+	// Check that nothing will be overwritten before changing anything.
+	_, isScript := entry.(*chezmoi.Script)
+	moveTarget := false
+	if !isScript {
+		switch _, err := c.fs.Lstat(oldTargetPath); {
+		case err == nil:
+			moveTarget = true
+		case os.IsNotExist(err):
+		default:
+			return err
+		}
+		if _, err := c.fs.Lstat(newTargetPath); err == nil {
+			return fmt.Errorf("%s: already exists", newTargetPath)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	newSourceDirName, err := c.makeSourceDirs(ts, filepath.Dir(newTargetName))
+	if err != nil {
+		return err
+	}
+	newSourcePath := filepath.Join(ts.SourceDir, newSourceDirName, newSourceBase)
+	if _, err := c.fs.Lstat(newSourcePath); err == nil {
+		return fmt.Errorf("%s: already exists", newSourcePath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if err := c.moveSourcePath(oldSourcePath, newSourcePath); err != nil {
+		return err
+	}
+
+	if !moveTarget {
+		return nil
+	}
+	if err := vfs.MkdirAll(c.mutator, filepath.Dir(newTargetPath), 0777&^os.FileMode(c.Umask)); err != nil {
+		return err
+	}
+	return c.mutator.Rename(oldTargetPath, newTargetPath)
+}
+
+// makeSourceDirs returns the source name of the directory targetDirName,
+// creating any directories that are not already in the source state.
+func (c *Config) makeSourceDirs(ts *chezmoi.TargetState, targetDirName string) (string, error) {
+	if targetDirName == "." {
+		return "", nil
+	}
+	sourceDirName := ""
+	targetName := ""
+	for _, component := range strings.Split(targetDirName, string(filepath.Separator)) {
+		targetName = filepath.Join(targetName, component)
+		targetPath := filepath.Join(ts.DestDir, targetName)
+		entry, _ := ts.Get(c.fs, targetPath)
+		switch entry := entry.(type) {
+		case *chezmoi.Dir:
+			sourceDirName = entry.SourceName()
+			continue
+		case nil:
+		default:
+			return "", fmt.Errorf("%s: not a directory", targetPath)
+		}
+
+		// Use the permissions of the directory in the destination directory,
+		// if it exists.
+		perm := os.FileMode(0777)
+		if info, err := c.fs.Stat(targetPath); err == nil && info.IsDir() {
+			perm = info.Mode().Perm()
+		}
+		da := chezmoi.DirAttributes{
+			Name: component,
+			Perm: perm,
+		}
+		sourceDirName = filepath.Join(sourceDirName, da.SourceName())
+		sourceDirPath := filepath.Join(ts.SourceDir, sourceDirName)
+		if _, err := c.fs.Stat(sourceDirPath); os.IsNotExist(err) {
+			if err := c.mutator.Mkdir(sourceDirPath, 0777&^os.FileMode(c.Umask)); err != nil {
+				return "", err
+			}
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return sourceDirName, nil
+}
+
+// moveSourcePath moves oldpath to newpath in the source directory, using git
+// if the source directory is a git repository and oldpath is tracked.
+func (c *Config) moveSourcePath(oldpath, newpath string) error {
+	if filepath.Base(c.SourceVCS.Command) == "git" {
+		if _, err := c.fs.Stat(filepath.Join(c.SourceDir, ".git")); err == nil {
+			oldRelPath, err := filepath.Rel(c.SourceDir, oldpath)
+			if err != nil {
+				return err
+			}
+			newRelPath, err := filepath.Rel(c.SourceDir, newpath)
+			if err != nil {
+				return err
+			}
+			if _, err := c.output(c.SourceDir, c.SourceVCS.Command, "ls-files", "--error-unmatch", "--", oldRelPath); err == nil {
+				return c.run(c.SourceDir, c.SourceVCS.Command, "mv", "--", oldRelPath, newRelPath)
+			}
+		}
+	}
+	return c.mutator.Rename(oldpath, newpath)
+}
+
+// mvSourceBase returns the base of the source name of entry when its target is
+// renamed to newBase, preserving its attributes.
+func mvSourceBase(entry chezmoi.Entry, newBase string) (string, error) {
+	oldBase := filepath.Base(entry.SourceName())
+	switch entry.(type) {
+	case *chezmoi.Dir:
+		da := chezmoi.ParseDirAttributes(oldBase)
+		da.Name = newBase
+		return da.SourceName(), nil
+	case *chezmoi.File, *chezmoi.Symlink:
+		fa := chezmoi.ParseFileAttributes(oldBase)
+		fa.Name = newBase
+		return fa.SourceName(), nil
+	case *chezmoi.Script:
+		sa := chezmoi.ParseScriptAttributes(oldBase)
+		sa.Name = newBase
+		return sa.SourceName(), nil
+	default:
+		return "", fmt.Errorf("%s: unsupported entry type", entry.TargetName())
+	}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestMvCommand(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		root    interface{}
+		wantErr bool
+		tests   interface{}
+	}{
+		{
+			name: "file_to_new_dir",
+			args: []string{"/home/user/.vimrc", "/home/user/.config/nvim/init.vim"},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".config": &vfst.Dir{Perm: 0700},
+					".vimrc":  &vfst.File{Perm: 0755, Contents: []byte("# contents of .vimrc\n")},
+					".local/share/chezmoi/executable_dot_vimrc.tmpl": "# contents of .vimrc\n",
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/executable_dot_vimrc.tmpl",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.local/share/chezmoi/private_dot_config/nvim/executable_init.vim.tmpl",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of .vimrc\n"),
+				),
+				vfst.TestPath("/home/user/.vimrc",
+					vfst.TestDoesNotExist,
+				),
+				vfst.TestPath("/home/user/.config/nvim/init.vim",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("# contents of .vimrc\n"),
+				),
+			},
+		},
+		{
+			name: "file_into_managed_dir",
+			args: []string{"/home/user/foo", "/home/user/.dir"},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".dir": &vfst.Dir{Perm: 0755},
+					"foo":  &vfst.File{Perm: 0600, Contents: []byte("# contents of foo\n")},
+					".local/share/chezmoi": map[string]interface{}{
+						"exact_dot_dir": &vfst.Dir{Perm: 0755},
+						"private_foo":   "# contents of foo\n",
+					},
+				},
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/exact_dot_dir/private_foo",
+					vfst.TestModeIsRegular,
+				),
+				vfst.TestPath("/home/user/.dir/foo",
+					vfst.TestModeIsRegular,
+				),
+			},
+		},
+		{
+			name: "dir_not_in_destination",
+			args: []string{"/home/user/.dir", "/home/user/.newdir"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/exact_dot_dir/symlink_foo": "bar",
+			},
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/exact_dot_newdir/symlink_foo",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString("bar"),
+				),
+				vfst.TestPath("/home/user/.newdir",
+					vfst.TestDoesNotExist,
+				),
+			},
+		},
+		{
+			name: "destination_exists",
+			args: []string{"/home/user/.bashrc", "/home/user/.bashrc.old"},
+			root: map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bashrc":                         "# contents of .bashrc\n",
+					".bashrc.old":                     "# old contents of .bashrc\n",
+					".local/share/chezmoi/dot_bashrc": "# contents of .bashrc\n",
+				},
+			},
+			wantErr: true,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+					vfst.TestModeIsRegular,
+				),
+			},
+		},
+		{
+			name: "into_itself",
+			args: []string{"/home/user/.dir", "/home/user/.dir/subdir"},
+			root: map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_dir/file": "# contents of .dir/file\n",
+			},
+			wantErr: true,
+			tests: []vfst.Test{
+				vfst.TestPath("/home/user/.local/share/chezmoi/dot_dir/file",
+					vfst.TestModeIsRegular,
+				),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(tc.root)
+			require.NoError(t, err)
+			defer cleanup()
+			c := newTestConfig(fs)
+			if tc.wantErr {
+				assert.Error(t, c.runMvCmd(nil, tc.args))
+			} else {
+				assert.NoError(t, c.runMvCmd(nil, tc.args))
+			}
+			vfst.RunTests(t, fs, "", tc.tests)
+		})
+	}
+}
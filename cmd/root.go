@@ -26,11 +26,12 @@ var (
 )
 
 var rootCmd = &cobra.Command{
-	Use:               "chezmoi",
-	Short:             "Manage your dotfiles across multiple machines, securely",
-	SilenceErrors:     true,
-	SilenceUsage:      true,
-	PersistentPreRunE: config.persistentPreRunRootE,
+	Use:                "chezmoi",
+	Short:              "Manage your dotfiles across multiple machines, securely",
+	SilenceErrors:      true,
+	SilenceUsage:       true,
+	PersistentPreRunE:  config.persistentPreRunRootE,
+	PersistentPostRunE: config.persistentPostRunRootE,
 }
 
 func init() {
@@ -72,6 +73,10 @@ func init() {
 	persistentFlags.BoolVar(&config.Debug, "debug", false, "write debug logs")
 	panicOnError(viper.BindPFlag("debug", persistentFlags.Lookup("debug")))
 
+	persistentFlags.BoolVar(&config.noCache, "no-cache", false, "do not use the template cache")
+
+	persistentFlags.BoolVar(&config.showSecrets, "show-secrets", false, "show secrets in output")
+
 	cobra.OnInitialize(func() {
 		_, err := os.Stat(config.configFile)
 		switch {
@@ -118,6 +123,8 @@ func Execute() {
 	}
 	rootCmd.Version = strings.Join(versionComponents, ", ")
 
+	config.registerSecretPlugins(secretCmd, os.Getenv("PATH"))
+
 	if err := rootCmd.Execute(); err != nil {
 		printErrorAndExit(err)
 	}
@@ -146,6 +153,12 @@ func (c *Config) persistentPreRunRootE(cmd *cobra.Command, args []string) error
 		}
 	}
 
+	switch c.Mode {
+	case chezmoi.ModeFile, chezmoi.ModeSymlink:
+	default:
+		return fmt.Errorf("invalid mode: %s", c.Mode)
+	}
+
 	c.fs = vfs.OSFS
 	c.mutator = chezmoi.NewFSMutator(config.fs)
 	if c.DryRun {
@@ -154,8 +167,12 @@ func (c *Config) persistentPreRunRootE(cmd *cobra.Command, args []string) error
 	if c.Debug {
 		c.mutator = chezmoi.NewDebugMutator(c.mutator)
 	}
+	// Secrets are redacted from recorded script output too.
+	if c.Verbose || c.Script.RecordOutput {
+		c.redactSecrets()
+	}
 	if c.Verbose {
-		c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize)
+		c.mutator = chezmoi.NewVerboseMutator(c.Stdout, c.mutator, c.colored, c.maxDiffDataSize, c.redactor)
 	}
 
 	info, err := c.fs.Stat(c.SourceDir)
@@ -178,6 +195,10 @@ func (c *Config) persistentPreRunRootE(cmd *cobra.Command, args []string) error
 	return c.snapFix()
 }
 
+func (c *Config) persistentPostRunRootE(cmd *cobra.Command, args []string) error {
+	return c.closeCachePersistentState()
+}
+
 func getExample(command string) string {
 	return helps[command].example
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/twpayne/chezmoi/internal/chezmoi"
@@ -19,14 +20,15 @@ var bitwardenCmd = &cobra.Command{
 }
 
 type bitwardenCmdConfig struct {
-	Command string
+	Command  string
+	CacheTTL time.Duration
 }
 
 var bitwardenCache = make(map[string]interface{})
 
 func init() {
 	config.Bitwarden.Command = "bw"
-	config.addTemplateFunc("bitwarden", config.bitwardenFunc)
+	config.addSecretTemplateFunc("bitwarden", config.bitwardenFunc)
 
 	secretCmd.AddCommand(bitwardenCmd)
 }
@@ -45,7 +47,7 @@ func (c *Config) bitwardenFunc(args ...string) interface{} {
 	cmd := exec.Command(name, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.secretCmdOutput(cmd, c.Bitwarden.CacheTTL)
 	if err != nil {
 		panic(fmt.Errorf("bitwarden: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
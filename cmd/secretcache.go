@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	bolt "go.etcd.io/bbolt"
+)
+
+var secretCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Args:  cobra.NoArgs,
+	Short: "Interact with the secret cache",
+}
+
+const secretCacheKeyringUser = "secret-cache-key"
+
+// A secretCacheEntry is a cached secret manager command output.
+type secretCacheEntry struct {
+	Expires time.Time `json:"expires"`
+	Output  []byte    `json:"output"`
+}
+
+// A persistentSecretCache stores the outputs of secret manager commands in a
+// chezmoi.PersistentState, encrypted with a key stored in the OS keyring. If
+// the keyring is not available then nothing is cached.
+type persistentSecretCache struct {
+	persistentState chezmoi.PersistentState
+	bucket          []byte
+	aead            *keyringAEAD
+	now             func() time.Time
+}
+
+func init() {
+	secretCmd.AddCommand(secretCacheCmd)
+}
+
+func newPersistentSecretCache(persistentState chezmoi.PersistentState, bucket []byte) *persistentSecretCache {
+	return &persistentSecretCache{
+		persistentState: persistentState,
+		bucket:          bucket,
+		aead: &keyringAEAD{
+			service: templateCacheKeyringService,
+			user:    secretCacheKeyringUser,
+		},
+		now: time.Now,
+	}
+}
+
+// Clear removes all cached outputs.
+func (c *persistentSecretCache) Clear() error {
+	var keys [][]byte
+	if err := c.persistentState.ForEach(c.bucket, func(k, _ []byte) error {
+		keys = append(keys, append([]byte(nil), k...))
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := c.persistentState.Delete(c.bucket, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the cached output for key, or nil if there is no unexpired
+// cached output.
+func (c *persistentSecretCache) Get(key []byte) ([]byte, error) {
+	data, err := c.persistentState.Get(c.bucket, key)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var entry secretCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, nil
+	}
+	if !c.now().Before(entry.Expires) {
+		return nil, c.persistentState.Delete(c.bucket, key)
+	}
+	return c.aead.open(entry.Output, key)
+}
+
+// Set caches output for key until ttl has elapsed.
+func (c *persistentSecretCache) Set(key, output []byte, ttl time.Duration) error {
+	ciphertext, err := c.aead.seal(output, key)
+	if err != nil || ciphertext == nil {
+		return err
+	}
+	data, err := json.Marshal(secretCacheEntry{
+		Expires: c.now().Add(ttl),
+		Output:  ciphertext,
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.persistentState.Set(c.bucket, key, data); !errors.Is(err, bolt.ErrTimeout) {
+		return err
+	}
+	return nil
+}
+
+// cachedSecretOutput returns the cached output of the secret manager command
+// argv. If there is none, it calls f to get the output and, if ttl is positive,
+// caches it for ttl.
+func (c *Config) cachedSecretOutput(argv []string, ttl time.Duration, f func() ([]byte, error)) ([]byte, error) {
+	if ttl <= 0 {
+		return f()
+	}
+	secretCache, err := c.getSecretCache()
+	if err != nil {
+		return nil, err
+	}
+	if secretCache == nil {
+		return f()
+	}
+	key := secretCacheKey(argv)
+	if output, err := secretCache.Get(key); err != nil || output != nil {
+		return output, err
+	}
+	output, err := f()
+	if err != nil {
+		return output, err
+	}
+	if err := secretCache.Set(key, output, ttl); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// getSecretCache returns the secret cache, opening it if needed. It returns nil
+// if the cache is disabled or is in use by another process.
+func (c *Config) getSecretCache() (*persistentSecretCache, error) {
+	if c.secretCache != nil {
+		return c.secretCache, nil
+	}
+	persistentState, err := c.getCachePersistentState()
+	if err != nil || persistentState == nil {
+		return nil, err
+	}
+	c.secretCache = newPersistentSecretCache(persistentState, c.secretCacheBucket)
+	return c.secretCache, nil
+}
+
+// secretTemplateFuncCacheTTL returns how long the output of the secret template
+// function name may be cached, which is the cache TTL of its secret manager.
+func (c *Config) secretTemplateFuncCacheTTL(name string) time.Duration {
+	switch name {
+	case "bitwarden":
+		return c.Bitwarden.CacheTTL
+	case "gopass":
+		return c.Gopass.CacheTTL
+	case "keepassxc", "keepassxcAttribute":
+		return c.KeePassXC.CacheTTL
+	case "lastpass", "lastpassRaw":
+		return c.Lastpass.CacheTTL
+	case "onepassword", "onepasswordDocument":
+		return c.Onepassword.CacheTTL
+	case "pass":
+		return c.Pass.CacheTTL
+	case "secret", "secretJSON":
+		return c.GenericSecret.CacheTTL
+	case "vault":
+		return c.Vault.CacheTTL
+	}
+	for _, plugin := range c.secretPlugins {
+		if plugin.err == nil && (name == plugin.name || name == plugin.name+"JSON") {
+			return c.SecretPlugin.CacheTTL
+		}
+	}
+	return 0
+}
+
+// secretCmdOutput returns the output of cmd, a secret manager command, using
+// and updating the secret cache if ttl is positive.
+func (c *Config) secretCmdOutput(cmd *exec.Cmd, ttl time.Duration) ([]byte, error) {
+	return c.cachedSecretOutput(cmd.Args, ttl, func() ([]byte, error) {
+		return c.mutator.IdempotentCmdOutput(cmd)
+	})
+}
+
+// secretCacheKey returns the cache key for argv. argv is hashed so that the
+// names of secrets are not stored in the cache.
+func secretCacheKey(argv []string) []byte {
+	key := sha256.Sum256([]byte(strings.Join(argv, "\x00")))
+	return key[:]
+}
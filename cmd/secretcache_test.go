@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+	keyring "github.com/zalando/go-keyring"
+)
+
+func TestSecretCache(t *testing.T) {
+	keyring.MockInit()
+	for _, tc := range []struct {
+		name      string
+		ttl       time.Duration
+		elapsed   time.Duration
+		noCache   bool
+		clear     bool
+		wantCalls int
+	}{
+		{
+			name:      "cached",
+			ttl:       time.Hour,
+			wantCalls: 1,
+		},
+		{
+			name:      "expired",
+			ttl:       time.Hour,
+			elapsed:   2 * time.Hour,
+			wantCalls: 2,
+		},
+		{
+			name:      "no_ttl",
+			wantCalls: 2,
+		},
+		{
+			name:      "no_cache",
+			ttl:       time.Hour,
+			noCache:   true,
+			wantCalls: 2,
+		},
+		{
+			name:      "clear",
+			ttl:       time.Hour,
+			clear:     true,
+			wantCalls: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": &vfst.Dir{Perm: 0755},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			calls := 0
+			start := time.Now()
+			for i := 0; i < 2; i++ {
+				c := newTestConfig(fs, withNoCache(tc.noCache))
+				if i == 1 && tc.clear {
+					require.NoError(t, c.runSecretCacheClearCmd(nil, nil))
+				}
+				secretCache, err := c.getSecretCache()
+				require.NoError(t, err)
+				if secretCache != nil {
+					secretCache.now = func() time.Time {
+						return start.Add(time.Duration(i) * tc.elapsed)
+					}
+				}
+				output, err := c.cachedSecretOutput([]string{"secret", "get", "name"}, tc.ttl, func() ([]byte, error) {
+					calls++
+					return []byte("hunter2"), nil
+				})
+				require.NoError(t, err)
+				assert.Equal(t, []byte("hunter2"), output)
+				require.NoError(t, c.persistentPostRunRootE(nil, nil))
+			}
+			assert.Equal(t, tc.wantCalls, calls)
+
+			if data, err := fs.ReadFile(filepath.Join("/", "home", "user", ".config", "chezmoi", "chezmoicache.boltdb")); err == nil {
+				assert.False(t, bytes.Contains(data, []byte("hunter2")))
+				assert.False(t, bytes.Contains(data, []byte("name")))
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	bolt "go.etcd.io/bbolt"
+)
+
+var secretCacheClearCmd = &cobra.Command{
+	Use:     "clear",
+	Args:    cobra.NoArgs,
+	Short:   "Clear the secret cache",
+	PreRunE: config.ensureNoError,
+	RunE:    config.runSecretCacheClearCmd,
+}
+
+func init() {
+	secretCacheCmd.AddCommand(secretCacheClearCmd)
+}
+
+func (c *Config) runSecretCacheClearCmd(cmd *cobra.Command, args []string) error {
+	templateCacheFile := c.getTemplateCacheFile()
+	if _, err := c.fs.Stat(templateCacheFile); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if c.DryRun {
+		return nil
+	}
+	persistentState, err := chezmoi.NewBoltPersistentState(c.fs, templateCacheFile, os.FileMode(c.Umask), &bolt.Options{
+		Timeout: time.Second,
+	})
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+	if err := newPersistentSecretCache(persistentState, c.secretCacheBucket).Clear(); err != nil {
+		return err
+	}
+	// Cached template outputs may contain the cleared secrets.
+	return newPersistentTemplateCache(persistentState, c.templateCacheBucket, c.secretTemplateFuncs, c.secretTemplateFuncCacheTTL).ClearSecrets()
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/twpayne/chezmoi/internal/chezmoi"
@@ -20,7 +21,8 @@ var genericSecretCmd = &cobra.Command{
 }
 
 type genericSecretCmdConfig struct {
-	Command string
+	Command  string
+	CacheTTL time.Duration
 }
 
 var (
@@ -29,8 +31,8 @@ var (
 )
 
 func init() {
-	config.addTemplateFunc("secret", config.secretFunc)
-	config.addTemplateFunc("secretJSON", config.secretJSONFunc)
+	config.addSecretTemplateFunc("secret", config.secretFunc)
+	config.addSecretTemplateFunc("secretJSON", config.secretJSONFunc)
 
 	secretCmd.AddCommand(genericSecretCmd)
 }
@@ -48,7 +50,7 @@ func (c *Config) secretFunc(args ...string) interface{} {
 	cmd := exec.Command(name, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.secretCmdOutput(cmd, c.GenericSecret.CacheTTL)
 	if err != nil {
 		panic(fmt.Errorf("secret: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
@@ -66,7 +68,7 @@ func (c *Config) secretJSONFunc(args ...string) interface{} {
 	cmd := exec.Command(name, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.secretCmdOutput(cmd, c.GenericSecret.CacheTTL)
 	if err != nil {
 		panic(fmt.Errorf("secretJSON: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os/exec"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/twpayne/chezmoi/internal/chezmoi"
@@ -17,7 +18,8 @@ var gopassCmd = &cobra.Command{
 }
 
 type gopassCmdConfig struct {
-	Command string
+	Command  string
+	CacheTTL time.Duration
 }
 
 var gopassCache = make(map[string]string)
@@ -26,7 +28,7 @@ func init() {
 	secretCmd.AddCommand(gopassCmd)
 
 	config.Gopass.Command = "gopass"
-	config.addTemplateFunc("gopass", config.gopassFunc)
+	config.addSecretTemplateFunc("gopass", config.gopassFunc)
 }
 
 func (c *Config) runSecretGopassCmd(cmd *cobra.Command, args []string) error {
@@ -40,7 +42,7 @@ func (c *Config) gopassFunc(id string) string {
 	name := c.Gopass.Command
 	args := []string{"show", id}
 	cmd := exec.Command(name, args...)
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.secretCmdOutput(cmd, c.Gopass.CacheTTL)
 	if err != nil {
 		panic(fmt.Errorf("gopass: %s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
 	}
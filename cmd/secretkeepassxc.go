@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/spf13/cobra"
@@ -27,6 +28,7 @@ type keePassXCCmdConfig struct {
 	Command  string
 	Database string
 	Args     []string
+	CacheTTL time.Duration
 }
 
 type keePassXCAttributeCacheKey struct {
@@ -45,8 +47,8 @@ var (
 
 func init() {
 	config.KeePassXC.Command = "keepassxc-cli"
-	config.addTemplateFunc("keepassxc", config.keePassXCFunc)
-	config.addTemplateFunc("keepassxcAttribute", config.keePassXCAttributeFunc)
+	config.addSecretTemplateFunc("keepassxc", config.keePassXCFunc)
+	config.addSecretTemplateFunc("keepassxcAttribute", config.keePassXCAttributeFunc)
 
 	secretCmd.AddCommand(keePassXCCmd)
 }
@@ -127,19 +129,22 @@ func (c *Config) keePassXCAttributeFunc(entry, attribute string) string {
 }
 
 func (c *Config) runKeePassXCCLICommand(name string, args []string) ([]byte, error) {
-	if keePassXCPassword == "" {
-		fmt.Printf("Insert password to unlock %s: ", c.KeePassXC.Database)
-		password, err := terminal.ReadPassword(int(os.Stdout.Fd()))
-		fmt.Println()
-		if err != nil {
-			return nil, err
+	// Check the secret cache before prompting for the password.
+	return c.cachedSecretOutput(append([]string{name}, args...), c.KeePassXC.CacheTTL, func() ([]byte, error) {
+		if keePassXCPassword == "" {
+			fmt.Printf("Insert password to unlock %s: ", c.KeePassXC.Database)
+			password, err := terminal.ReadPassword(int(os.Stdout.Fd()))
+			fmt.Println()
+			if err != nil {
+				return nil, err
+			}
+			keePassXCPassword = string(password)
 		}
-		keePassXCPassword = string(password)
-	}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = bytes.NewBufferString(keePassXCPassword + "\n")
-	cmd.Stderr = c.Stderr
-	return c.mutator.IdempotentCmdOutput(cmd)
+		cmd := exec.Command(name, args...)
+		cmd.Stdin = bytes.NewBufferString(keePassXCPassword + "\n")
+		cmd.Stderr = c.Stderr
+		return c.mutator.IdempotentCmdOutput(cmd)
+	})
 }
 
 func parseKeyPassXCOutput(output []byte) (map[string]string, error) {
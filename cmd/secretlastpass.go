@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 
 	"github.com/coreos/go-semver/semver"
@@ -35,6 +36,7 @@ var (
 
 type lastpassCmdConfig struct {
 	Command          string
+	CacheTTL         time.Duration
 	versionCheckOnce sync.Once
 }
 
@@ -42,8 +44,8 @@ var lastPassCache = make(map[string][]map[string]interface{})
 
 func init() {
 	config.Lastpass.Command = "lpass"
-	config.addTemplateFunc("lastpass", config.lastpassFunc)
-	config.addTemplateFunc("lastpassRaw", config.lastpassRawFunc)
+	config.addSecretTemplateFunc("lastpass", config.lastpassFunc)
+	config.addSecretTemplateFunc("lastpassRaw", config.lastpassRawFunc)
 
 	secretCmd.AddCommand(lastpassCmd)
 }
@@ -57,7 +59,7 @@ func (c *Config) lastpassOutput(args ...string) ([]byte, error) {
 	cmd := exec.Command(name, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.secretCmdOutput(cmd, c.Lastpass.CacheTTL)
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/twpayne/chezmoi/internal/chezmoi"
@@ -18,7 +19,8 @@ var onepasswordCmd = &cobra.Command{
 }
 
 type onepasswordCmdConfig struct {
-	Command string
+	Command  string
+	CacheTTL time.Duration
 }
 
 var (
@@ -28,8 +30,8 @@ var (
 
 func init() {
 	config.Onepassword.Command = "op"
-	config.addTemplateFunc("onepassword", config.onepasswordFunc)
-	config.addTemplateFunc("onepasswordDocument", config.onepasswordDocumentFunc)
+	config.addSecretTemplateFunc("onepassword", config.onepasswordFunc)
+	config.addSecretTemplateFunc("onepasswordDocument", config.onepasswordDocumentFunc)
 
 	secretCmd.AddCommand(onepasswordCmd)
 }
@@ -47,7 +49,7 @@ func (c *Config) onepasswordFunc(item string) interface{} {
 	cmd := exec.Command(name, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.secretCmdOutput(cmd, c.Onepassword.CacheTTL)
 	if err != nil {
 		panic(fmt.Errorf("onepassword: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
@@ -68,7 +70,7 @@ func (c *Config) onepasswordDocumentFunc(item string) interface{} {
 	cmd := exec.Command(name, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.secretCmdOutput(cmd, c.Onepassword.CacheTTL)
 	if err != nil {
 		panic(fmt.Errorf("onepassword: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
 	}
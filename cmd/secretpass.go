@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os/exec"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/twpayne/chezmoi/internal/chezmoi"
@@ -17,7 +18,8 @@ var passCmd = &cobra.Command{
 }
 
 type passCmdConfig struct {
-	Command string
+	Command  string
+	CacheTTL time.Duration
 }
 
 var passCache = make(map[string]string)
@@ -26,7 +28,7 @@ func init() {
 	secretCmd.AddCommand(passCmd)
 
 	config.Pass.Command = "pass"
-	config.addTemplateFunc("pass", config.passFunc)
+	config.addSecretTemplateFunc("pass", config.passFunc)
 }
 
 func (c *Config) runSecretPassCmd(cmd *cobra.Command, args []string) error {
@@ -40,7 +42,7 @@ func (c *Config) passFunc(id string) string {
 	name := c.Pass.Command
 	args := []string{"show", id}
 	cmd := exec.Command(name, args...)
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	output, err := c.secretCmdOutput(cmd, c.Pass.CacheTTL)
 	if err != nil {
 		panic(fmt.Errorf("pass: %s %s: %w", name, chezmoi.ShellQuoteArgs(args), err))
 	}
@@ -0,0 +1,272 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+const (
+	secretPluginPrefix          = "chezmoi-secret-"
+	secretPluginProtocolVersion = 1
+)
+
+// Secret plugin commands.
+const (
+	secretPluginCommandGet     = "get"
+	secretPluginCommandGetJSON = "get-json"
+	secretPluginCommandHealth  = "health"
+	secretPluginCommandList    = "list"
+)
+
+var secretPluginNameRegexp = regexp.MustCompile(`\A[A-Za-z_][A-Za-z0-9_]*\z`)
+
+type secretPluginConfig struct {
+	CacheTTL time.Duration
+}
+
+// A secretPlugin is an executable on $PATH called chezmoi-secret-<name> that
+// retrieves secrets from a secret manager. For each request, chezmoi runs the
+// executable, writes a secretPluginRequest as a line of JSON to its stdin, and
+// reads a secretPluginResponse as JSON from its stdout.
+type secretPlugin struct {
+	name string
+	path string
+	err  error
+}
+
+// A secretPluginRequest is a request sent to a secret plugin.
+type secretPluginRequest struct {
+	Version int      `json:"version"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+}
+
+// A secretPluginResponse is a response received from a secret plugin. If Error
+// is not empty then the request failed.
+type secretPluginResponse struct {
+	Value json.RawMessage `json:"value"`
+	Error string          `json:"error,omitempty"`
+}
+
+var secretPluginCache = make(map[string]json.RawMessage)
+
+// findSecretPlugins returns the secret plugins in path, a list of directories
+// in the same format as $PATH. If there are several plugins with the same name
+// then only the first is returned.
+func findSecretPlugins(path string) []*secretPlugin {
+	var plugins []*secretPlugin
+	names := make(map[string]struct{})
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, info := range infos {
+			if info.IsDir() || !strings.HasPrefix(info.Name(), secretPluginPrefix) {
+				continue
+			}
+			pluginPath, err := exec.LookPath(filepath.Join(dir, info.Name()))
+			if err != nil {
+				continue
+			}
+			name := strings.TrimPrefix(info.Name(), secretPluginPrefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if _, ok := names[name]; ok {
+				continue
+			}
+			names[name] = struct{}{}
+			plugins = append(plugins, &secretPlugin{
+				name: name,
+				path: pluginPath,
+			})
+		}
+	}
+	return plugins
+}
+
+// registerSecretPlugins finds the secret plugins in path and, for each plugin
+// called name, adds the name and nameJSON template functions and a name
+// subcommand to parentCmd. Plugins whose names are not valid template function
+// names or that conflict with existing template functions or subcommands are
+// not registered.
+func (c *Config) registerSecretPlugins(parentCmd *cobra.Command, path string) {
+	c.secretPlugins = findSecretPlugins(path)
+	for _, plugin := range c.secretPlugins {
+		switch {
+		case !secretPluginNameRegexp.MatchString(plugin.name):
+			plugin.err = fmt.Errorf("%s: invalid name", plugin.name)
+			continue
+		case c.templateFuncs[plugin.name] != nil:
+			plugin.err = fmt.Errorf("%s: template function already defined", plugin.name)
+			continue
+		case c.templateFuncs[plugin.name+"JSON"] != nil:
+			plugin.err = fmt.Errorf("%sJSON: template function already defined", plugin.name)
+			continue
+		}
+		for _, cmd := range parentCmd.Commands() {
+			if cmd.Name() == plugin.name {
+				plugin.err = fmt.Errorf("%s: command already defined", plugin.name)
+			}
+		}
+		if plugin.err != nil {
+			continue
+		}
+		c.addSecretTemplateFunc(plugin.name, c.secretPluginFunc(plugin))
+		c.addSecretTemplateFunc(plugin.name+"JSON", c.secretPluginJSONFunc(plugin))
+		parentCmd.AddCommand(c.newSecretPluginCmd(plugin))
+	}
+}
+
+func (c *Config) newSecretPluginCmd(plugin *secretPlugin) *cobra.Command {
+	return &cobra.Command{
+		Use:     plugin.name + " command [args...]",
+		Args:    cobra.MinimumNArgs(1),
+		Short:   fmt.Sprintf("Execute the %s secret plugin", plugin.name),
+		PreRunE: c.ensureNoError,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.runSecretPluginCmd(plugin, args)
+		},
+	}
+}
+
+func (c *Config) runSecretPluginCmd(plugin *secretPlugin, args []string) error {
+	command, args := args[0], args[1:]
+	value, err := c.secretPluginRequest(plugin, command, args)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", plugin.path, command, err)
+	}
+	switch command {
+	case secretPluginCommandGet, secretPluginCommandHealth:
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return fmt.Errorf("%s: %w", plugin.path, err)
+		}
+		_, err = fmt.Fprintln(c.Stdout, s)
+		return err
+	case secretPluginCommandList:
+		var items []string
+		if err := json.Unmarshal(value, &items); err != nil {
+			return fmt.Errorf("%s: %w", plugin.path, err)
+		}
+		for _, item := range items {
+			if _, err := fmt.Fprintln(c.Stdout, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		sb := &bytes.Buffer{}
+		if err := json.Indent(sb, value, "", "  "); err != nil {
+			return fmt.Errorf("%s: %w", plugin.path, err)
+		}
+		sb.WriteByte('\n')
+		_, err = c.Stdout.Write(sb.Bytes())
+		return err
+	}
+}
+
+func (c *Config) secretPluginFunc(plugin *secretPlugin) func(...string) string {
+	return func(args ...string) string {
+		var value string
+		c.secretPluginCachedRequest(plugin, secretPluginCommandGet, args, &value)
+		return value
+	}
+}
+
+func (c *Config) secretPluginJSONFunc(plugin *secretPlugin) func(...string) interface{} {
+	return func(args ...string) interface{} {
+		var value interface{}
+		c.secretPluginCachedRequest(plugin, secretPluginCommandGetJSON, args, &value)
+		return value
+	}
+}
+
+// secretPluginCachedRequest sends a request to plugin and decodes the value of
+// the response into value, panicking on any error. Responses are cached for the
+// lifetime of the process.
+func (c *Config) secretPluginCachedRequest(plugin *secretPlugin, command string, args []string, value interface{}) {
+	funcName := plugin.name
+	if command == secretPluginCommandGetJSON {
+		funcName += "JSON"
+	}
+	key := strings.Join(append([]string{plugin.path, command}, args...), "\x00")
+	data, ok := secretPluginCache[key]
+	if !ok {
+		var err error
+		data, err = c.secretPluginRequest(plugin, command, args)
+		if err != nil {
+			panic(fmt.Errorf("%s: %s: %w", funcName, chezmoi.ShellQuoteArgs(args), err))
+		}
+		secretPluginCache[key] = data
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		panic(fmt.Errorf("%s: %s: %w", funcName, chezmoi.ShellQuoteArgs(args), err))
+	}
+}
+
+// secretPluginRequest sends a request with command and args to plugin and
+// returns the value of its response. The responses to get and get-json
+// requests are cached in the secret cache.
+func (c *Config) secretPluginRequest(plugin *secretPlugin, command string, args []string) (json.RawMessage, error) {
+	if args == nil {
+		args = []string{}
+	}
+	request, err := json.Marshal(&secretPluginRequest{
+		Version: secretPluginProtocolVersion,
+		Command: command,
+		Args:    args,
+	})
+	if err != nil {
+		return nil, err
+	}
+	request = append(request, '\n')
+
+	var ttl time.Duration
+	switch command {
+	case secretPluginCommandGet, secretPluginCommandGetJSON:
+		ttl = c.SecretPlugin.CacheTTL
+	}
+
+	var response secretPluginResponse
+	output, err := c.cachedSecretOutput(append([]string{plugin.path, command}, args...), ttl, func() ([]byte, error) {
+		cmd := exec.Command(plugin.path)
+		cmd.Stdin = bytes.NewReader(request)
+		cmd.Stderr = c.Stderr
+		output, err := c.mutator.IdempotentCmdOutput(cmd)
+		if err != nil {
+			return nil, err
+		}
+		// Parse the response here so that errors are not cached.
+		if err := json.Unmarshal(output, &response); err != nil {
+			return nil, err
+		}
+		if response.Error != "" {
+			return nil, errors.New(response.Error)
+		}
+		return output, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(output, &response); err != nil {
+		return nil, err
+	}
+	return response.Value, nil
+}
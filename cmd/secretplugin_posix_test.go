@@ -0,0 +1,118 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+const testSecretPluginScript = `#!/bin/sh
+read -r request
+case "$request" in
+*'"command":"get","args":["missing"]'*)
+	echo '{"error":"not found"}'
+	;;
+*'"command":"get"'*)
+	echo '{"value":"hunter2"}'
+	;;
+*'"command":"get-json"'*)
+	echo '{"value":{"username":"user","password":"hunter2"}}'
+	;;
+*'"command":"list"'*)
+	echo '{"value":["a","b"]}'
+	;;
+*'"command":"health"'*)
+	echo '{"value":"ok"}'
+	;;
+*)
+	echo '{"error":"unknown command"}'
+	;;
+esac
+`
+
+func newSecretPluginTestDir(t *testing.T, names ...string) (string, func()) {
+	dir, err := ioutil.TempDir("", "chezmoi")
+	require.NoError(t, err)
+	for _, name := range names {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, secretPluginPrefix+name), []byte(testSecretPluginScript), 0755))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, secretPluginPrefix+"notexecutable"), []byte(testSecretPluginScript), 0644))
+	return dir, func() {
+		assert.NoError(t, os.RemoveAll(dir))
+	}
+}
+
+func TestFindSecretPlugins(t *testing.T) {
+	dir1, cleanup1 := newSecretPluginTestDir(t, "a", "b")
+	defer cleanup1()
+	dir2, cleanup2 := newSecretPluginTestDir(t, "b", "c")
+	defer cleanup2()
+
+	plugins := findSecretPlugins(string(filepath.ListSeparator) + dir1 + string(filepath.ListSeparator) + dir2)
+	assert.Equal(t, []*secretPlugin{
+		{name: "a", path: filepath.Join(dir1, secretPluginPrefix+"a")},
+		{name: "b", path: filepath.Join(dir1, secretPluginPrefix+"b")},
+		{name: "c", path: filepath.Join(dir2, secretPluginPrefix+"c")},
+	}, plugins)
+}
+
+func TestSecretPlugins(t *testing.T) {
+	dir, cleanup := newSecretPluginTestDir(t, "mystore", "my-store", "env", "existing")
+	defer cleanup()
+
+	fs, fsCleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer fsCleanup()
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(fs, withStdout(stdout))
+	parentCmd := &cobra.Command{}
+	parentCmd.AddCommand(&cobra.Command{Use: "existing"})
+	c.registerSecretPlugins(parentCmd, dir)
+
+	pluginErrs := make(map[string]bool)
+	for _, plugin := range c.secretPlugins {
+		pluginErrs[plugin.name] = plugin.err != nil
+	}
+	assert.Equal(t, map[string]bool{
+		"env":      true,
+		"existing": true,
+		"my-store": true,
+		"mystore":  false,
+	}, pluginErrs)
+
+	t.Run("template_funcs", func(t *testing.T) {
+		stdout.Reset()
+		require.NoError(t, c.runExecuteTemplateCmd(nil, []string{
+			`{{ mystore "password" }} {{ (mystoreJSON "login").username }}`,
+		}))
+		assert.Equal(t, "hunter2 user", stdout.String())
+		assert.Error(t, c.runExecuteTemplateCmd(nil, []string{`{{ mystore "missing" }}`}))
+	})
+
+	t.Run("cmd", func(t *testing.T) {
+		cmd, _, err := parentCmd.Find([]string{"mystore"})
+		require.NoError(t, err)
+		stdout.Reset()
+		require.NoError(t, cmd.RunE(cmd, []string{"list"}))
+		require.NoError(t, cmd.RunE(cmd, []string{"health"}))
+		assert.Equal(t, "a\nb\nok\n", stdout.String())
+		assert.Error(t, cmd.RunE(cmd, []string{"unknown"}))
+	})
+
+	t.Run("secrets_redacted", func(t *testing.T) {
+		_, ok := c.secretTemplateFuncs["mystoreJSON"]
+		assert.True(t, ok)
+	})
+}
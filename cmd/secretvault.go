@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/twpayne/chezmoi/internal/chezmoi"
@@ -18,14 +19,26 @@ var vaultCmd = &cobra.Command{
 }
 
 type vaultCmdConfig struct {
-	Command string
+	Command   string
+	CacheTTL  time.Duration
+	Client    string
+	Address   string
+	Namespace string
+	TokenFile string
 }
 
+// Vault clients.
+const (
+	vaultClientCLI  = "cli"
+	vaultClientHTTP = "http"
+)
+
 var vaultCache = make(map[string]interface{})
 
 func init() {
 	config.Vault.Command = "vault"
-	config.addTemplateFunc("vault", config.vaultFunc)
+	config.Vault.Client = vaultClientCLI
+	config.addSecretTemplateFunc("vault", config.vaultFunc)
 
 	secretCmd.AddCommand(vaultCmd)
 }
@@ -38,18 +51,37 @@ func (c *Config) vaultFunc(key string) interface{} {
 	if data, ok := vaultCache[key]; ok {
 		return data
 	}
-	name := c.Vault.Command
-	args := []string{"kv", "get", "-format=json", key}
-	cmd := exec.Command(name, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stderr = os.Stderr
-	output, err := c.mutator.IdempotentCmdOutput(cmd)
-	if err != nil {
-		panic(fmt.Errorf("vault: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
+	var output []byte
+	switch c.Vault.Client {
+	case vaultClientCLI:
+		name := c.Vault.Command
+		args := []string{"kv", "get", "-format=json", key}
+		cmd := exec.Command(name, args...)
+		cmd.Stdin = os.Stdin
+		cmd.Stderr = os.Stderr
+		var err error
+		output, err = c.secretCmdOutput(cmd, c.Vault.CacheTTL)
+		if err != nil {
+			panic(fmt.Errorf("vault: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
+		}
+	case vaultClientHTTP:
+		vaultClient, err := c.getVaultClient()
+		if err != nil {
+			panic(fmt.Errorf("vault: %s: %w", key, err))
+		}
+		argv := []string{vaultClientHTTP, vaultClient.address, vaultClient.namespace, key}
+		output, err = c.cachedSecretOutput(argv, c.Vault.CacheTTL, func() ([]byte, error) {
+			return vaultClient.kvGet(key)
+		})
+		if err != nil {
+			panic(fmt.Errorf("vault: %s: %w", key, err))
+		}
+	default:
+		panic(fmt.Errorf("vault: %s: invalid client", c.Vault.Client))
 	}
 	var data interface{}
 	if err := json.Unmarshal(output, &data); err != nil {
-		panic(fmt.Errorf("vault: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
+		panic(fmt.Errorf("vault: %s: %w\n%s", key, err, output))
 	}
 	vaultCache[key] = data
 	return data
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+type sopsConfig struct {
+	Files   []string
+	DataKey string
+}
+
+// A sopsFile is a sops-encrypted data file. It is decrypted at most once, when
+// its data is first needed.
+type sopsFile struct {
+	path     string
+	document *chezmoi.SOPSDocument
+	once     sync.Once
+	data     map[string]interface{}
+	err      error
+}
+
+var sopsCache = make(map[string]interface{})
+
+func init() {
+	config.addSecretTemplateFunc("sops", config.sopsFunc)
+}
+
+func (c *Config) sopsFunc(document string) interface{} {
+	if data, ok := sopsCache[document]; ok {
+		return data
+	}
+	sopsDocument, err := chezmoi.ParseSOPSDocument([]byte(document))
+	if err != nil {
+		panic(fmt.Errorf("sops: %w", err))
+	}
+	data, err := sopsDocument.Decrypt(c.decryptSOPSKey)
+	if err != nil {
+		panic(fmt.Errorf("sops: %w", err))
+	}
+	sopsCache[document] = data
+	return data
+}
+
+// decryptSOPSKey decrypts key, an encrypted copy of a sops document's data
+// key, with age or gpg. The key is passed to and read from the command
+// directly so that it is never written to disk.
+func (c *Config) decryptSOPSKey(key chezmoi.SOPSKey) ([]byte, error) {
+	var cmd *exec.Cmd
+	switch key.Type {
+	case "age":
+		// Use the same identities as sops.
+		keyFile := os.Getenv("SOPS_AGE_KEY_FILE")
+		if keyFile == "" {
+			keyFile = filepath.Join(c.bds.ConfigHome, "sops", "age", "keys.txt")
+		}
+		cmd = exec.Command("age", "--decrypt", "--identity", keyFile)
+	case "pgp":
+		cmd = exec.Command("gpg", "--quiet", "--decrypt")
+	default:
+		return nil, fmt.Errorf("%s: unsupported key type", key.Type)
+	}
+	cmd.Stdin = strings.NewReader(key.Enc)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	if err != nil {
+		if stderr.Len() != 0 {
+			return nil, fmt.Errorf("%s: %w: %s", cmd.Args[0], err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return nil, fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return output, nil
+}
+
+// decrypt returns the decrypted data in f, recording it as secret.
+func (f *sopsFile) decrypt(c *Config) (map[string]interface{}, error) {
+	f.once.Do(func() {
+		f.data, f.err = f.document.Decrypt(c.decryptSOPSKey)
+		if f.err != nil {
+			f.err = fmt.Errorf("%s: %w", f.path, f.err)
+			return
+		}
+		c.redactor.AddSecret(f.data)
+	})
+	return f.data, f.err
+}
+
+// getSOPSData returns the template data in the sops-encrypted data files. The
+// data files are the first .chezmoidata.sops file found in the root of the
+// source directory, followed by sops.files, with later files taking precedence.
+// Each top level value is a secret LazyValue so that a file is only decrypted
+// if a template references its data. If sops.dataKey is set then the data is
+// returned under that key.
+func (c *Config) getSOPSData() (map[string]interface{}, error) {
+	type sopsPath struct {
+		fs   vfs.FS
+		path string
+	}
+	var paths []sopsPath
+	for _, ext := range chezmoi.SOPSDataExts {
+		fs, path := c.getSourceFilePath(chezmoi.SOPSDataName + "." + ext)
+		if _, err := fs.Stat(path); err == nil {
+			paths = append(paths, sopsPath{fs: fs, path: path})
+			break
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	for _, path := range c.SOPS.Files {
+		fs, path := c.getSourceFilePath(path)
+		paths = append(paths, sopsPath{fs: fs, path: path})
+	}
+
+	data := make(map[string]interface{})
+	for _, sp := range paths {
+		path := sp.path
+		contents, err := sp.fs.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		document, err := chezmoi.ParseSOPSDocument(contents)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		file := &sopsFile{
+			path:     path,
+			document: document,
+		}
+		for _, key := range document.Keys() {
+			key := key
+			data[key] = chezmoi.NewSecretLazyValue(func() (interface{}, error) {
+				fileData, err := file.decrypt(c)
+				if err != nil {
+					return nil, err
+				}
+				return fileData[key], nil
+			})
+		}
+	}
+
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if c.SOPS.DataKey != "" {
+		return map[string]interface{}{
+			c.SOPS.DataKey: data,
+		}, nil
+	}
+	return data, nil
+}
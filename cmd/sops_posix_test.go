@@ -0,0 +1,164 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+// testSOPSDocumentFormat is a document encrypted with sops with the data key
+// testSOPSDataKey. Its only PGP key's encrypted data key is a format verb.
+const testSOPSDocumentFormat = `github:
+  user: ENC[AES256_GCM,data:jZ2lNQ==,iv:Qg8SJVIVu4R69LLVKEgJ4r3vPVfd5zMnbXOgnWSw6OY=,tag:/lnfBMd0n9W+PgTC7h/dZg==,type:str]
+  token: ENC[AES256_GCM,data:ikRHygMh+Hxcrg==,iv:XHKFTStZrDrXX36tr5nMNqgy/GBRzfi75hit8NSPxj4=,tag:K8vMOhYlPawqjj3EUGAopA==,type:str]
+port: ENC[AES256_GCM,data:oiicxQ==,iv:Yr/zln38DaAIJPZNgwdvcHlylby8laeyQPQ2w8P/RVE=,tag:1EAY6CXmR9Gb/N+DzeOdZg==,type:int]
+sops:
+  kms: []
+  gcp_kms: []
+  azure_kv: []
+  hc_vault: []
+  age: []
+  lastmodified: "2020-06-01T12:00:00Z"
+  mac: ENC[AES256_GCM,data:8tXxoDQK/fP1oI324ylzkEwkEAjLwW8erjNkxkSjhSC8riDpHUKyqp/Mr/2eOL6Md82o91UG3MVA9lifXDqyu8fAwRuc093L/VJzu5GWmwZ0qOIx/9m9SCQKD037gw5HdaJ0aHdvDTLNFZL99Hu/Yp4q0cyaTqLPxBg2oO8kcYk=,iv:ioEHNUjxPemkXzbP6j9hlkcaW8c55vPOTnpFSVpcnRw=,tag:G6M47HRny9b3z13sumh1nQ==,type:str]
+  pgp:
+  - created_at: "2020-06-01T12:00:00Z"
+    enc: %s
+    fp: 0123456789ABCDEF
+  unencrypted_suffix: _unencrypted
+  version: 3.6.1
+`
+
+var testSOPSDataKey = sha256.Sum256([]byte("chezmoi sops test data key"))
+
+func TestSOPS(t *testing.T) {
+	withTestGPGHome(t, func() {
+		cmd := exec.Command("gpg", "--armor", "--encrypt", "--quiet", "--recipient", "user@example.com")
+		cmd.Stdin = bytes.NewReader(testSOPSDataKey[:])
+		enc, err := cmd.Output()
+		require.NoError(t, err)
+		document := fmt.Sprintf(testSOPSDocumentFormat, strconv.Quote(string(enc)))
+
+		fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+			"/home/user/.local/share/chezmoi": map[string]interface{}{
+				".chezmoidata.sops.yaml": document,
+				".invalid.sops.yaml":     fmt.Sprintf(testSOPSDocumentFormat, "invalid"),
+			},
+		})
+		require.NoError(t, err)
+		defer cleanup()
+
+		for _, tc := range []struct {
+			name        string
+			sops        sopsConfig
+			template    string
+			expectedErr bool
+			expected    string
+		}{
+			{
+				name:     "data",
+				template: `{{ .github.token }} {{ .port }}`,
+				expected: "ghp_secret 8080",
+			},
+			{
+				name: "data_key",
+				sops: sopsConfig{
+					DataKey: "secrets",
+				},
+				template: `{{ .secrets.github.user }}`,
+				expected: "user",
+			},
+			{
+				name: "lazy",
+				sops: sopsConfig{
+					Files: []string{".invalid.sops.yaml"},
+				},
+				template: `{{ .chezmoi.sourceDir }}`,
+				expected: "/home/user/.local/share/chezmoi",
+			},
+			{
+				name: "invalid_key",
+				sops: sopsConfig{
+					Files: []string{".invalid.sops.yaml"},
+				},
+				template:    `{{ .github.token }}`,
+				expectedErr: true,
+			},
+			{
+				name:     "func",
+				template: `{{ (include ".chezmoidata.sops.yaml" | sops).github.token }}`,
+				expected: "ghp_secret",
+			},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				stdout := &bytes.Buffer{}
+				c := newTestConfig(fs, withStdout(stdout))
+				c.SOPS = tc.sops
+				c.addTemplateFunc("include", c.includeFunc)
+				c.addSecretTemplateFunc("sops", c.sopsFunc)
+				err := c.runExecuteTemplateCmd(nil, []string{tc.template})
+				if tc.expectedErr {
+					assert.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, stdout.String())
+			})
+		}
+
+		t.Run("facts_export", func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withStdout(stdout))
+			c.factsExport.format = "json"
+			require.NoError(t, c.runFactsExportCmd(nil, nil))
+			assert.NotContains(t, stdout.String(), "ghp_secret")
+			assert.NotContains(t, stdout.String(), "github")
+		})
+
+		for _, showSecrets := range []bool{false, true} {
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withStdout(stdout))
+			c.SOPS.Files = []string{".invalid.sops.yaml"}
+			c.data.format = "json"
+			c.showSecrets = showSecrets
+			if showSecrets {
+				// Secret values are decrypted, so the invalid file fails.
+				assert.Error(t, c.runDataCmd(nil, nil))
+				c.SOPS.Files = nil
+				require.NoError(t, c.runDataCmd(nil, nil))
+				assert.Contains(t, stdout.String(), "ghp_secret")
+			} else {
+				// Secret values are redacted without being decrypted.
+				require.NoError(t, c.runDataCmd(nil, nil))
+				assert.NotContains(t, stdout.String(), "ghp_secret")
+				assert.Contains(t, stdout.String(), `"github": "[redacted]"`)
+			}
+		}
+	})
+}
+
+func TestLintSOPS(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".chezmoidata.sops.yaml": fmt.Sprintf(testSOPSDocumentFormat, "invalid"),
+			"dot_gitconfig.tmpl":     "[github]\n\tuser = {{ .github.user }}\n\tport = {{ .port }}\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	// The data file cannot be decrypted, so lint only succeeds if it never
+	// tries.
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(fs, withStdout(stdout))
+	assert.NoError(t, c.runLintCmd(nil, nil))
+	assert.Empty(t, stdout.String())
+}
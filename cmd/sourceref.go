@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// getTargetStateAtRef returns the target state of the source directory at the
+// git revision ref. The source directory is not modified: the contents of ref
+// are extracted into a temporary directory, which is removed when the returned
+// cleanup function is called. Templates are executed before
+// getTargetStateAtRef returns so that the files that they include, and the
+// sops-encrypted data files, are also read at ref. The returned target state's
+// source directory is the real source directory, so that, in symlink mode,
+// targets link to it rather than to the temporary directory.
+func (c *Config) getTargetStateAtRef(ref string) (*chezmoi.TargetState, func(), error) {
+	if filepath.Base(c.SourceVCS.Command) != "git" {
+		return nil, nil, fmt.Errorf("%s: source revisions not supported", c.SourceVCS.Command)
+	}
+
+	// Do not let ref be interpreted as an option.
+	if strings.HasPrefix(ref, "-") {
+		return nil, nil, fmt.Errorf("%s: invalid revision", ref)
+	}
+	tree, err := c.output(c.SourceDir, c.SourceVCS.Command, "rev-parse", "--verify", "--quiet", ref+"^{tree}")
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: invalid revision", ref)
+	}
+
+	// The source directory might be a subdirectory of the repository, so
+	// archive only the corresponding tree.
+	prefix, err := c.output(c.SourceDir, c.SourceVCS.Command, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, nil, err
+	}
+	treeish := strings.TrimSpace(string(tree)) + ":" + strings.TrimSpace(string(prefix))
+	data, err := c.output(c.SourceDir, c.SourceVCS.Command, "archive", "--format=tar", treeish)
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) != 0 {
+		return nil, nil, fmt.Errorf("%s: %s", ref, bytes.TrimSpace(exitErr.Stderr))
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", ref, err)
+	}
+
+	tempDir, err := ioutil.TempDir("", "chezmoi-source")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		_ = os.RemoveAll(tempDir)
+	}
+	if err := extractTAR(tar.NewReader(bytes.NewReader(data)), tempDir); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	c.sourceRefFS = vfs.NewReadOnlyFS(vfs.OSFS)
+	c.sourceRefDir = tempDir
+	defer func() {
+		c.sourceRefFS = nil
+		c.sourceRefDir = ""
+	}()
+	ts, err := c.getTargetStateFromFS(c.sourceRefFS, tempDir, nil)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if err := evaluateTemplates(ts); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	ts.SourceDir = c.SourceDir
+	return ts, cleanup, nil
+}
+
+// evaluateTemplates executes the templates in ts that are not ignored.
+func evaluateTemplates(ts *chezmoi.TargetState) error {
+	var err error
+	walkEntriesNotIgnored(ts.Entries, ts.TargetIgnore.Match, func(entry chezmoi.Entry) {
+		if err != nil {
+			return
+		}
+		switch entry := entry.(type) {
+		case *chezmoi.File:
+			if entry.Template {
+				_, err = entry.Contents()
+			}
+		case *chezmoi.Script:
+			if entry.Template {
+				_, err = entry.Contents()
+			}
+		case *chezmoi.Symlink:
+			if entry.Template {
+				_, err = entry.Linkname()
+			}
+		}
+	})
+	return err
+}
+
+// getSourceFilePath returns the filesystem and the path from which to read the
+// file at path, which is relative to the source directory if it is not
+// absolute. While the source state is read at a git revision, files in the
+// source directory are read at that revision.
+func (c *Config) getSourceFilePath(path string) (vfs.FS, string) {
+	switch {
+	case filepath.IsAbs(path):
+		return c.fs, path
+	case c.sourceRefFS != nil:
+		return c.sourceRefFS, filepath.Join(c.sourceRefDir, path)
+	default:
+		return c.fs, filepath.Join(c.SourceDir, path)
+	}
+}
+
+// extractTAR extracts the directories, regular files, and symlinks in r into
+// dir.
+func extractTAR(r *tar.Reader, dir string) error {
+	for {
+		header, err := r.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s: invalid path in archive", header.Name)
+		}
+		path := filepath.Join(dir, name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			//nolint:gosec
+			if _, err := io.Copy(f, r); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, path); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// getSourceRefDestFS returns the filesystem in which to compare a target state
+// at a source revision with the destination directory using applyOptions. In
+// symlink mode, targets link to the working copy of the source directory, so
+// comparing them with the target state would ignore all changes to the files'
+// contents. Instead, the target state is compared in file mode, with targets
+// that link into the source directory read as the files that they link to.
+func (c *Config) getSourceRefDestFS(applyOptions *chezmoi.ApplyOptions) (vfs.FS, error) {
+	fs := vfs.NewReadOnlyFS(c.fs)
+	if applyOptions.Mode != chezmoi.ModeSymlink {
+		return fs, nil
+	}
+	applyOptions.Mode = chezmoi.ModeFile
+	rawSourceDir, err := c.fs.RawPath(c.SourceDir)
+	if err != nil {
+		return nil, err
+	}
+	return &sourceLinkFS{
+		FS:           fs,
+		rawSourceDir: rawSourceDir,
+	}, nil
+}
+
+// A sourceLinkFS is a vfs.FS in which symlinks to files in the source directory
+// appear to be the files that they link to.
+type sourceLinkFS struct {
+	vfs.FS
+	rawSourceDir string
+}
+
+// Lstat implements vfs.FS.Lstat.
+func (fs *sourceLinkFS) Lstat(name string) (os.FileInfo, error) {
+	info, err := fs.FS.Lstat(name)
+	if err != nil || info.Mode()&os.ModeType != os.ModeSymlink {
+		return info, err
+	}
+	linkname, err := fs.FS.Readlink(name)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(linkname, fs.rawSourceDir+string(filepath.Separator)) {
+		return info, nil
+	}
+	return fs.FS.Stat(name)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+)
+
+var targetPathCmd = &cobra.Command{
+	Use:     "target-path [source-paths...]",
+	Short:   "Print the target path of a source path",
+	Long:    mustGetLongHelp("target-path"),
+	Example: getExample("target-path"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runTargetPathCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(targetPathCmd)
+
+	markRemainingZshCompPositionalArgumentsAsFiles(targetPathCmd, 1)
+}
+
+func (c *Config) runTargetPathCmd(cmd *cobra.Command, args []string) error {
+	destDir, err := filepath.Abs(c.DestDir)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		_, err := fmt.Fprintln(c.Stdout, destDir)
+		return err
+	}
+	sourceDir, err := filepath.Abs(c.SourceDir)
+	if err != nil {
+		return err
+	}
+	for _, arg := range args {
+		sourcePath, err := filepath.Abs(arg)
+		if err != nil {
+			return err
+		}
+		relPath, ok := relPathWithin(sourceDir, sourcePath)
+		if !ok {
+			return fmt.Errorf("%s: not in source directory", arg)
+		}
+		isDir := false
+		if info, err := c.fs.Stat(sourcePath); err == nil {
+			isDir = info.IsDir()
+		}
+		targetName, err := chezmoi.TargetName(relPath, isDir)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(c.Stdout, filepath.Join(destDir, targetName)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestTargetPathCommand(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "dest_dir",
+			want: []string{"/home/user"},
+		},
+		{
+			name: "files",
+			args: []string{
+				"/home/user/.local/share/chezmoi/dot_bashrc",
+				"/home/user/.local/share/chezmoi/private_dot_ssh/encrypted_private_id_rsa.tmpl",
+				"/home/user/.local/share/chezmoi/exact_dot_dir",
+				"/home/user/.local/share/chezmoi/exact_dot_dir/symlink_dot_link",
+				"/home/user/.local/share/chezmoi/dot_dir/run_once_install.sh",
+			},
+			want: []string{
+				"/home/user/.bashrc",
+				"/home/user/.ssh/id_rsa",
+				"/home/user/.dir",
+				"/home/user/.dir/.link",
+				"/home/user/.dir/install.sh",
+			},
+		},
+		{
+			name:    "special_file",
+			args:    []string{"/home/user/.local/share/chezmoi/.chezmoiignore"},
+			wantErr: true,
+		},
+		{
+			name:    "special_dir",
+			args:    []string{"/home/user/.local/share/chezmoi/.git/config"},
+			wantErr: true,
+		},
+		{
+			name:    "outside_source_dir",
+			args:    []string{"/home/user/.bashrc"},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi/exact_dot_dir": &vfst.Dir{Perm: 0755},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withStdout(stdout))
+			if tc.wantErr {
+				assert.Error(t, c.runTargetPathCmd(nil, tc.args))
+				return
+			}
+			require.NoError(t, c.runTargetPathCmd(nil, tc.args))
+			want := ""
+			for _, path := range tc.want {
+				want += filepath.FromSlash(path) + "\n"
+			}
+			assert.Equal(t, want, stdout.String())
+		})
+	}
+}
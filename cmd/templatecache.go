@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	templateCacheKeyringService = "chezmoi"
+	templateCacheKeyringUser    = "template-cache-key"
+)
+
+// templateCacheTTL is how long template outputs are cached for, so that the
+// outputs of templates that are no longer used are eventually removed.
+const templateCacheTTL = 30 * 24 * time.Hour
+
+// volatileTemplateFuncs are template functions whose output can change between
+// runs even if their arguments do not. The output of templates that call them
+// is never cached.
+var volatileTemplateFuncs = map[string]struct{}{
+	"ago":               {},
+	"encryptAES":        {},
+	"env":               {},
+	"expandenv":         {},
+	"genCA":             {},
+	"genPrivateKey":     {},
+	"genSelfSignedCert": {},
+	"genSignedCert":     {},
+	"getHostByName":     {},
+	"include":           {},
+	"lookPath":          {},
+	"now":               {},
+	"output":            {},
+	"randAlpha":         {},
+	"randAlphaNum":      {},
+	"randAscii":         {},
+	"randNumeric":       {},
+	"shuffle":           {},
+	"stat":              {},
+	"uuidv4":            {},
+}
+
+// A templateCacheEntry is a cached template output, which expires at Expires.
+// Secret is true if the template called secret template functions, in which
+// case Secrets contains the encrypted secrets that the output contains.
+type templateCacheEntry struct {
+	Encrypted bool      `json:"encrypted"`
+	Secret    bool      `json:"secret,omitempty"`
+	Secrets   []byte    `json:"secrets,omitempty"`
+	Expires   time.Time `json:"expires"`
+	Output    []byte    `json:"output"`
+}
+
+// A persistentTemplateCache is a chezmoi.TemplateCache that stores template
+// outputs in a chezmoi.PersistentState. Outputs of templates that were
+// encrypted or that call secret template functions are encrypted with a key
+// stored in the OS keyring. If the keyring is not available then these outputs
+// are not cached. The cache key does not include the secrets themselves, so
+// outputs of templates that call secret template functions are only cached
+// for as long as the secret managers' outputs may be cached, as returned by
+// secretFuncTTL. The secrets recorded in recordedSecrets that these outputs
+// contain are cached with them, and are recorded in redactor when the outputs
+// are used, so that they are redacted as if the templates had been executed.
+type persistentTemplateCache struct {
+	persistentState chezmoi.PersistentState
+	bucket          []byte
+	secretFuncs     map[string]struct{}
+	secretFuncTTL   func(string) time.Duration
+	recordedSecrets *chezmoi.Redactor
+	redactor        *chezmoi.Redactor
+	aead            *keyringAEAD
+	now             func() time.Time
+}
+
+func newPersistentTemplateCache(persistentState chezmoi.PersistentState, bucket []byte, secretFuncs map[string]struct{}, secretFuncTTL func(string) time.Duration) *persistentTemplateCache {
+	return &persistentTemplateCache{
+		persistentState: persistentState,
+		bucket:          bucket,
+		secretFuncs:     secretFuncs,
+		secretFuncTTL:   secretFuncTTL,
+		aead: &keyringAEAD{
+			service: templateCacheKeyringService,
+			user:    templateCacheKeyringUser,
+		},
+		now: time.Now,
+	}
+}
+
+// ClearSecrets removes all cached outputs of templates that call secret
+// template functions.
+func (c *persistentTemplateCache) ClearSecrets() error {
+	return c.removeEntries(func(entry *templateCacheEntry) bool {
+		return entry.Secret
+	})
+}
+
+// RemoveExpired removes all expired cached outputs.
+func (c *persistentTemplateCache) RemoveExpired() error {
+	now := c.now()
+	return c.removeEntries(func(entry *templateCacheEntry) bool {
+		return !now.Before(entry.Expires)
+	})
+}
+
+// removeEntries removes all cached outputs for which f returns true, and all
+// invalid entries.
+func (c *persistentTemplateCache) removeEntries(f func(*templateCacheEntry) bool) error {
+	var keys [][]byte
+	if err := c.persistentState.ForEach(c.bucket, func(k, v []byte) error {
+		var entry templateCacheEntry
+		if err := json.Unmarshal(v, &entry); err != nil || f(&entry) {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := c.persistentState.Delete(c.bucket, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get implements chezmoi.TemplateCache.Get.
+func (c *persistentTemplateCache) Get(key []byte, funcs map[string]struct{}) ([]byte, error) {
+	if anyFunc(funcs, volatileTemplateFuncs) {
+		return nil, nil
+	}
+	data, err := c.persistentState.Get(c.bucket, key)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var entry templateCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, nil
+	}
+	if !c.now().Before(entry.Expires) {
+		return nil, c.persistentState.Delete(c.bucket, key)
+	}
+	output := entry.Output
+	if entry.Encrypted {
+		output, err = c.aead.open(output, key)
+		if err != nil || output == nil {
+			return nil, err
+		}
+	}
+	if entry.Secrets != nil {
+		secretsJSON, err := c.aead.open(entry.Secrets, key)
+		if err != nil || secretsJSON == nil {
+			return nil, err
+		}
+		var secrets []string
+		if err := json.Unmarshal(secretsJSON, &secrets); err != nil {
+			return nil, nil
+		}
+		c.redactor.AddSecret(secrets)
+	}
+	if output == nil {
+		output = []byte{}
+	}
+	return output, nil
+}
+
+// Set implements chezmoi.TemplateCache.Set.
+func (c *persistentTemplateCache) Set(key []byte, funcs map[string]struct{}, decrypted bool, output []byte) error {
+	if anyFunc(funcs, volatileTemplateFuncs) {
+		return nil
+	}
+	entry := templateCacheEntry{
+		Expires: c.now().Add(templateCacheTTL),
+		Output:  output,
+	}
+	if ttl, ok := c.secretTTL(funcs); ok {
+		if ttl <= 0 {
+			return nil
+		}
+		entry.Secret = true
+		if ttl < templateCacheTTL {
+			entry.Expires = c.now().Add(ttl)
+		}
+	}
+	if decrypted || entry.Secret {
+		ciphertext, err := c.aead.seal(output, key)
+		if err != nil || ciphertext == nil {
+			return err
+		}
+		entry.Encrypted = true
+		entry.Output = ciphertext
+	}
+	if secrets := c.recordedSecrets.SecretsIn(output); len(secrets) != 0 {
+		secretsJSON, err := json.Marshal(secrets)
+		if err != nil {
+			return err
+		}
+		ciphertext, err := c.aead.seal(secretsJSON, key)
+		if err != nil || ciphertext == nil {
+			return err
+		}
+		entry.Secrets = ciphertext
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := c.persistentState.Set(c.bucket, key, data); !errors.Is(err, bolt.ErrTimeout) {
+		return err
+	}
+	return nil
+}
+
+// secretTTL returns the shortest time for which the outputs of the secret
+// template functions in funcs may be cached, and whether funcs contains any
+// secret template functions.
+func (c *persistentTemplateCache) secretTTL(funcs map[string]struct{}) (time.Duration, bool) {
+	var ttl time.Duration
+	found := false
+	for name := range funcs {
+		if _, ok := c.secretFuncs[name]; !ok {
+			continue
+		}
+		funcTTL := c.secretFuncTTL(name)
+		if !found || funcTTL < ttl {
+			ttl = funcTTL
+		}
+		found = true
+	}
+	return ttl, found
+}
+
+func anyFunc(funcs, set map[string]struct{}) bool {
+	for name := range funcs {
+		if _, ok := set[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getCachePersistentState returns the persistent state that stores cached
+// template outputs and secrets, opening it if needed. It returns nil if caching
+// is disabled or if the cache is in use by another process.
+func (c *Config) getCachePersistentState() (chezmoi.PersistentState, error) {
+	if c.noCache {
+		return nil, nil
+	}
+	if c.templateCachePersistentState != nil {
+		return c.templateCachePersistentState, nil
+	}
+	persistentState, err := chezmoi.NewBoltPersistentState(c.fs, c.getTemplateCacheFile(), os.FileMode(c.Umask), &bolt.Options{
+		Timeout: time.Second,
+	})
+	switch {
+	case errors.Is(err, bolt.ErrTimeout):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	c.templateCachePersistentState = persistentState
+	return c.templateCachePersistentState, nil
+}
+
+// closeCachePersistentState closes the persistent state that stores cached
+// template outputs and secrets, if it is open. It is reopened when next needed.
+func (c *Config) closeCachePersistentState() error {
+	if c.templateCachePersistentState == nil {
+		return nil
+	}
+	err := c.templateCachePersistentState.Close()
+	c.templateCachePersistentState = nil
+	c.templateCache = nil
+	c.secretCache = nil
+	return err
+}
+
+// getTemplateCache returns the template cache, opening it if needed. It returns
+// nil if the template cache is disabled or is in use by another process.
+func (c *Config) getTemplateCache() (chezmoi.TemplateCache, error) {
+	if c.templateCache != nil {
+		return c.templateCache, nil
+	}
+	persistentState, err := c.getCachePersistentState()
+	if err != nil || persistentState == nil {
+		return nil, err
+	}
+	templateCache := newPersistentTemplateCache(persistentState, c.templateCacheBucket, c.secretTemplateFuncs, c.secretTemplateFuncCacheTTL)
+	templateCache.recordedSecrets = c.recordedSecrets
+	templateCache.redactor = c.redactor
+	if err := templateCache.RemoveExpired(); err != nil {
+		return nil, err
+	}
+	c.templateCache = templateCache
+	return c.templateCache, nil
+}
+
+// getTemplateCacheFile returns the path of the file that stores cached template
+// outputs and secrets, next to the persistent state. It is separate from the
+// persistent state because the persistent state is opened read-only by
+// commands like diff and is locked for writing while changes are applied,
+// whereas the cache is written by every command that executes templates, and
+// because the cache can be deleted at any time without losing any state.
+func (c *Config) getTemplateCacheFile() string {
+	return filepath.Join(filepath.Dir(c.getPersistentStateFile()), "chezmoicache.boltdb")
+}
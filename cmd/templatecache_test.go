@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	"github.com/twpayne/go-vfs/vfst"
+	keyring "github.com/zalando/go-keyring"
+)
+
+func TestTemplateCache(t *testing.T) {
+	keyring.MockInit()
+	for _, tc := range []struct {
+		name          string
+		contents      string
+		secret        bool
+		ttl           time.Duration
+		elapsed       time.Duration
+		clear         bool
+		redact        bool
+		noCache       bool
+		wantCalls     int
+		wantStored    bool
+		wantPlaintext bool
+	}{
+		{
+			name:          "cached",
+			contents:      `{{ count }}`,
+			wantCalls:     1,
+			wantStored:    true,
+			wantPlaintext: true,
+		},
+		{
+			name:      "secret",
+			contents:  `{{ count }}`,
+			secret:    true,
+			wantCalls: 2,
+		},
+		{
+			name:       "secret_ttl",
+			contents:   `{{ count }}`,
+			secret:     true,
+			ttl:        time.Hour,
+			wantCalls:  1,
+			wantStored: true,
+		},
+		{
+			name:       "secret_redacted",
+			contents:   `{{ count }}`,
+			secret:     true,
+			ttl:        time.Hour,
+			redact:     true,
+			wantCalls:  1,
+			wantStored: true,
+		},
+		{
+			name:       "expired",
+			contents:   `{{ count }}`,
+			elapsed:    2 * templateCacheTTL,
+			wantCalls:  2,
+			wantStored: true,
+			// The expired entry is removed and then cached again.
+			wantPlaintext: true,
+		},
+		{
+			name:       "secret_expired",
+			contents:   `{{ count }}`,
+			secret:     true,
+			ttl:        time.Hour,
+			elapsed:    2 * time.Hour,
+			wantCalls:  2,
+			wantStored: true,
+		},
+		{
+			name:       "secret_clear",
+			contents:   `{{ count }}`,
+			secret:     true,
+			ttl:        time.Hour,
+			clear:      true,
+			wantCalls:  2,
+			wantStored: true,
+		},
+		{
+			name:      "volatile",
+			contents:  `{{ count }}{{ env "HOME" | len | not }}`,
+			wantCalls: 2,
+		},
+		{
+			name:      "no_cache",
+			contents:  `{{ count }}`,
+			noCache:   true,
+			wantCalls: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.local/share/chezmoi/dot_file.tmpl": tc.contents,
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			calls := 0
+			start := time.Now()
+			for i := 0; i < 2; i++ {
+				c := newTestConfig(fs, withNoCache(tc.noCache))
+				countFunc := func() string {
+					calls++
+					return "output"
+				}
+				if tc.secret {
+					c.addSecretTemplateFunc("count", countFunc)
+					c.secretPlugins = []*secretPlugin{
+						{name: "count"},
+					}
+					c.SecretPlugin.CacheTTL = tc.ttl
+				} else {
+					c.addTemplateFunc("count", countFunc)
+				}
+				if tc.redact {
+					c.redactSecrets()
+				}
+				if i == 1 && tc.clear {
+					require.NoError(t, c.runSecretCacheClearCmd(nil, nil))
+				}
+				if templateCache, err := c.getTemplateCache(); err == nil && templateCache != nil {
+					i := i
+					templateCache.(*persistentTemplateCache).now = func() time.Time {
+						return start.Add(time.Duration(i) * tc.elapsed)
+					}
+					// Remove expired entries at the overridden time.
+					require.NoError(t, templateCache.(*persistentTemplateCache).RemoveExpired())
+				}
+				ts, err := c.getTargetState(nil)
+				require.NoError(t, err)
+				entry, err := ts.Get(fs, filepath.Join("/", "home", "user", ".file"))
+				require.NoError(t, err)
+				contents, err := entry.(*chezmoi.File).Contents()
+				require.NoError(t, err)
+				assert.Contains(t, string(contents), "output")
+				if tc.redact {
+					// Secrets in cached outputs are redacted too.
+					assert.Equal(t, chezmoi.RedactedText, string(c.redactor.Redact(contents)))
+				}
+				require.NoError(t, c.persistentPostRunRootE(nil, nil))
+			}
+			assert.Equal(t, tc.wantCalls, calls)
+
+			data, err := fs.ReadFile(filepath.Join("/", "home", "user", ".config", "chezmoi", "chezmoicache.boltdb"))
+			if !tc.wantStored {
+				assert.True(t, os.IsNotExist(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantPlaintext, bytes.Contains(data, []byte(`"output":"b3V0cHV0"`)))
+		})
+	}
+}
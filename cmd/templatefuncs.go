@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func init() {
+	config.addTemplateFunc("fromJson", config.fromJSONFunc)
+	config.addTemplateFunc("fromToml", config.fromTOMLFunc)
+	config.addTemplateFunc("fromYaml", config.fromYAMLFunc)
+	config.addTemplateFunc("include", config.includeFunc)
+	config.addTemplateFunc("joinPath", config.joinPathFunc)
+	config.addTemplateFunc("lookPath", config.lookPathFunc)
+	config.addTemplateFunc("output", config.outputFunc)
+	config.addTemplateFunc("stat", config.statFunc)
+	config.addTemplateFunc("toToml", config.toTOMLFunc)
+	config.addTemplateFunc("toYaml", config.toYAMLFunc)
+}
+
+func (c *Config) fromJSONFunc(s string) interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(s), &value); err != nil {
+		panic(fmt.Errorf("fromJson: %w", err))
+	}
+	return value
+}
+
+func (c *Config) fromTOMLFunc(s string) interface{} {
+	tree, err := toml.Load(s)
+	if err != nil {
+		panic(fmt.Errorf("fromToml: %w", err))
+	}
+	return tree.ToMap()
+}
+
+func (c *Config) fromYAMLFunc(s string) interface{} {
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(s), &value); err != nil {
+		panic(fmt.Errorf("fromYaml: %w", err))
+	}
+	return stringKeyMap(value)
+}
+
+func (c *Config) includeFunc(filename string) string {
+	fs, path := c.getSourceFilePath(filename)
+	contents, err := fs.ReadFile(path)
+	if err != nil {
+		panic(fmt.Errorf("include: %w", err))
+	}
+	return string(contents)
+}
+
+func (c *Config) joinPathFunc(elem ...string) string {
+	return filepath.Join(elem...)
+}
+
+func (c *Config) lookPathFunc(file string) string {
+	path, err := exec.LookPath(file)
+	switch {
+	case err == nil:
+		return path
+	case errors.Is(err, exec.ErrNotFound):
+		return ""
+	default:
+		panic(fmt.Errorf("lookPath: %s: %w", file, err))
+	}
+}
+
+func (c *Config) outputFunc(name string, args ...string) string {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = c.Stdin
+	cmd.Stderr = c.Stderr
+	output, err := c.mutator.IdempotentCmdOutput(cmd)
+	if err != nil {
+		panic(fmt.Errorf("output: %s %s: %w\n%s", name, chezmoi.ShellQuoteArgs(args), err, output))
+	}
+	return string(output)
+}
+
+func (c *Config) statFunc(name string) interface{} {
+	info, err := c.fs.Stat(name)
+	switch {
+	case err == nil:
+		return map[string]interface{}{
+			"name":    info.Name(),
+			"size":    info.Size(),
+			"mode":    int(info.Mode()),
+			"perm":    int(info.Mode().Perm()),
+			"modTime": info.ModTime().Unix(),
+			"isDir":   info.IsDir(),
+		}
+	case os.IsNotExist(err):
+		return nil
+	default:
+		panic(fmt.Errorf("stat: %w", err))
+	}
+}
+
+func (c *Config) toTOMLFunc(value interface{}) string {
+	return c.formatFunc("toToml", "toml", value)
+}
+
+func (c *Config) toYAMLFunc(value interface{}) string {
+	return c.formatFunc("toYaml", "yaml", value)
+}
+
+// formatFunc returns value formatted with the format format, panicking with
+// an error prefixed with funcName if value cannot be formatted.
+func (c *Config) formatFunc(funcName, format string, value interface{}) string {
+	sb := &strings.Builder{}
+	if err := formatMap[format](sb, value); err != nil {
+		panic(fmt.Errorf("%s: %w", funcName, err))
+	}
+	return sb.String()
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func newTemplateFuncsTestConfig(t *testing.T, root interface{}) (*Config, *bytes.Buffer, func()) {
+	fs, cleanup, err := vfst.NewTestFS(root)
+	require.NoError(t, err)
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withStdout(stdout),
+	)
+	c.addTemplateFunc("fromJson", c.fromJSONFunc)
+	c.addTemplateFunc("fromToml", c.fromTOMLFunc)
+	c.addTemplateFunc("fromYaml", c.fromYAMLFunc)
+	c.addTemplateFunc("include", c.includeFunc)
+	c.addTemplateFunc("joinPath", c.joinPathFunc)
+	c.addTemplateFunc("lookPath", c.lookPathFunc)
+	c.addTemplateFunc("output", c.outputFunc)
+	c.addTemplateFunc("stat", c.statFunc)
+	c.addTemplateFunc("toToml", c.toTOMLFunc)
+	c.addTemplateFunc("toYaml", c.toYAMLFunc)
+	return c, stdout, cleanup
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		template    string
+		expectedErr bool
+		expected    string
+	}{
+		{
+			name:     "fromJson",
+			template: `{{ (fromJson "{\"a\":{\"b\":1}}").a.b }}`,
+			expected: "1",
+		},
+		{
+			name:        "fromJson_invalid",
+			template:    `{{ fromJson "{" }}`,
+			expectedErr: true,
+		},
+		{
+			name:     "fromToml",
+			template: `{{ (fromToml "[a]\nb = \"c\"").a.b }}`,
+			expected: "c",
+		},
+		{
+			name:     "fromYaml",
+			template: `{{ (fromYaml "a:\n  b: c\n").a.b }}`,
+			expected: "c",
+		},
+		{
+			name:     "include",
+			template: `{{ include ".chezmoidata/email" }}`,
+			expected: "user@example.com\n",
+		},
+		{
+			name:     "include_absolute",
+			template: `{{ include "/home/user/.bashrc" }}`,
+			expected: "# contents of .bashrc\n",
+		},
+		{
+			name:        "include_missing",
+			template:    `{{ include "missing" }}`,
+			expectedErr: true,
+		},
+		{
+			name:     "joinPath",
+			template: `{{ joinPath "a" "b" "c" }}`,
+			expected: filepath.Join("a", "b", "c"),
+		},
+		{
+			name:     "lookPath_missing",
+			template: `{{ lookPath "chezmoi-test-missing" }}`,
+			expected: "",
+		},
+		{
+			name:     "stat",
+			template: `{{ with stat "/home/user/.bashrc" }}{{ .name }} {{ .size }} {{ .isDir }}{{ end }}`,
+			expected: ".bashrc 22 false",
+		},
+		{
+			name:     "stat_missing",
+			template: `{{ if stat "/home/user/.missing" }}exists{{ else }}missing{{ end }}`,
+			expected: "missing",
+		},
+		{
+			name:     "toToml",
+			template: `{{ dict "a" "b" | toToml }}`,
+			expected: "a = \"b\"\n",
+		},
+		{
+			name:     "toYaml",
+			template: `{{ dict "a" (list 1 2) | toYaml }}`,
+			expected: "a:\n- 1\n- 2\n",
+		},
+		{
+			name:     "round_trip",
+			template: `{{ (fromYaml (toYaml .config)).key }}`,
+			expected: "value",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c, stdout, cleanup := newTemplateFuncsTestConfig(t, map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bashrc": "# contents of .bashrc\n",
+					".local/share/chezmoi/.chezmoidata": map[string]interface{}{
+						"email": "user@example.com\n",
+					},
+				},
+			})
+			defer cleanup()
+			c.Data = map[string]interface{}{
+				"config": map[string]interface{}{
+					"key": "value",
+				},
+			}
+			err := c.runExecuteTemplateCmd(nil, []string{tc.template})
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, stdout.String())
+		})
+	}
+}
+
+func TestTemplateFuncsCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX only")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found in $PATH")
+	}
+
+	c, stdout, cleanup := newTemplateFuncsTestConfig(t, map[string]interface{}{
+		"/home/user": &vfst.Dir{Perm: 0755},
+	})
+	defer cleanup()
+
+	require.NoError(t, c.runExecuteTemplateCmd(nil, []string{
+		`{{ lookPath "sh" }}`,
+		`|{{ output "sh" "-c" "echo hello" }}`,
+	}))
+	assert.Equal(t, sh+"|hello\n", stdout.String())
+
+	assert.Error(t, c.runExecuteTemplateCmd(nil, []string{
+		`{{ output "sh" "-c" "exit 1" }}`,
+	}))
+}
@@ -1,14 +1,21 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	bolt "go.etcd.io/bbolt"
 )
 
 type updateCmdConfig struct {
-	apply bool
+	apply   bool
+	preview bool
 }
 
 var updateCmd = &cobra.Command{
@@ -26,6 +33,7 @@ func init() {
 
 	persistentFlags := updateCmd.PersistentFlags()
 	persistentFlags.BoolVarP(&config.update.apply, "apply", "a", true, "apply after pulling")
+	persistentFlags.BoolVar(&config.update.preview, "preview", false, "preview incoming changes before pulling")
 }
 
 func (c *Config) runUpdateCmd(cmd *cobra.Command, args []string) error {
@@ -50,7 +58,17 @@ func (c *Config) runUpdateCmd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("%s: pull not supported", c.SourceVCS.Command)
 	}
 
-	if err := c.run(c.SourceDir, c.SourceVCS.Command, pullArgs...); err != nil {
+	if c.update.preview {
+		// Integrate exactly the previewed revision, rather than pulling
+		// again, so that changes that were not previewed are never merged.
+		upstream, err := c.previewUpdate()
+		if err != nil || upstream == "" {
+			return err
+		}
+		if err := c.run(c.SourceDir, c.SourceVCS.Command, gitIntegrateArgs(pullArgs, upstream)...); err != nil {
+			return err
+		}
+	} else if err := c.run(c.SourceDir, c.SourceVCS.Command, pullArgs...); err != nil {
 		return err
 	}
 
@@ -60,10 +78,171 @@ func (c *Config) runUpdateCmd(cmd *cobra.Command, args []string) error {
 			return err
 		}
 		defer persistentState.Close()
-		if err := c.applyArgs(nil, persistentState); err != nil {
+		if err := c.applyArgsWithHistory(nil, persistentState); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// gitIntegrateArgs returns the arguments to git to integrate the fetched
+// revision upstream in the same way as git pull with pullArgs would: by
+// rebasing onto it if pullArgs request a rebase, otherwise by merging it.
+func gitIntegrateArgs(pullArgs []string, upstream string) []string {
+	rebase := ""
+	var mergeArgs []string
+	var sharedArgs []string
+	for _, arg := range pullArgs {
+		switch arg {
+		case "-r", "--rebase", "--rebase=true", "--rebase=interactive", "--rebase=i":
+			rebase = "true"
+		case "--rebase=merges", "--rebase=m":
+			rebase = "merges"
+		case "--no-rebase", "--rebase=false":
+			rebase = ""
+		case "--ff", "--ff-only", "--no-ff":
+			mergeArgs = append(mergeArgs, arg)
+		case "--autostash", "--no-autostash":
+			sharedArgs = append(sharedArgs, arg)
+		}
+	}
+	switch rebase {
+	case "true":
+		return append(append([]string{"rebase"}, sharedArgs...), upstream)
+	case "merges":
+		return append(append([]string{"rebase", "--rebase-merges"}, sharedArgs...), upstream)
+	default:
+		return append(append(append([]string{"merge"}, mergeArgs...), sharedArgs...), upstream)
+	}
+}
+
+// previewUpdate fetches changes to the source directory without merging them
+// and shows the changes that they would make to the destination directory,
+// including the scripts that would be run. If the user chooses to continue
+// with the update then it returns the previewed revision, otherwise it returns
+// an empty string.
+func (c *Config) previewUpdate() (string, error) {
+	if filepath.Base(c.SourceVCS.Command) != "git" {
+		return "", fmt.Errorf("%s: preview not supported", c.SourceVCS.Command)
+	}
+	c.redactSecrets()
+	// Do not pass stdin to git fetch so that it remains available for the
+	// prompt.
+	fetchCmd := exec.Command(c.SourceVCS.Command, "fetch")
+	var err error
+	fetchCmd.Dir, err = c.fs.RawPath(c.SourceDir)
+	if err != nil {
+		return "", err
+	}
+	fetchCmd.Stdout = c.Stdout
+	fetchCmd.Stderr = c.Stdout
+	if err := c.mutator.RunCmd(fetchCmd); err != nil {
+		return "", err
+	}
+	head, err := c.output(c.SourceDir, c.SourceVCS.Command, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	upstream, err := c.output(c.SourceDir, c.SourceVCS.Command, "rev-parse", "@{upstream}")
+	if err != nil {
+		return "", err
+	}
+	head, upstream = bytes.TrimSpace(head), bytes.TrimSpace(upstream)
+	if bytes.Equal(head, upstream) {
+		_, err := fmt.Fprintln(c.Stdout, "Already up to date.")
+		return "", err
+	}
+
+	log, err := c.output(c.SourceDir, c.SourceVCS.Command, "log", "--oneline", string(head)+".."+string(upstream))
+	if err != nil {
+		return "", err
+	}
+	if _, err := fmt.Fprintf(c.Stdout, "Incoming commits:\n%s", log); err != nil {
+		return "", err
+	}
+
+	ts, cleanup, err := c.getTargetStateAtRef(string(upstream))
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	if err := c.previewTargetState(ts); err != nil {
+		return "", err
+	}
+
+	prompt := "Merge"
+	if c.update.apply {
+		prompt = "Merge and apply"
+	}
+	choice, err := c.prompt(prompt, "yn")
+	if err != nil || choice != 'y' {
+		return "", err
+	}
+	return string(upstream), nil
+}
+
+// previewTargetState prints the diff between ts and the destination directory,
+// and the scripts that applying ts would run.
+func (c *Config) previewTargetState(ts *chezmoi.TargetState) error {
+	// The persistent state is closed before returning so that it can be
+	// reopened for writing if the user chooses to continue.
+	persistentState, err := c.getPersistentState(&bolt.Options{
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer persistentState.Close()
+
+	applyOptions := c.getApplyOptions(ts, persistentState)
+	applyOptions.DryRun = true
+	applyOptions.Verbose = false
+	fs, err := c.getSourceRefDestFS(applyOptions)
+	if err != nil {
+		return err
+	}
+	mutator := chezmoi.NewVerboseMutator(c.Stdout, chezmoi.NullMutator{}, c.colored, c.maxDiffDataSize, c.redactor)
+	if err := ts.Apply(fs, mutator, c.Follow, applyOptions); err != nil {
+		return err
+	}
+
+	var pendingScripts []*chezmoi.Script
+	var walkErr error
+	walkEntriesNotIgnored(ts.Entries, applyOptions.Ignore, func(entry chezmoi.Entry) {
+		script, ok := entry.(*chezmoi.Script)
+		if !ok || walkErr != nil {
+			return
+		}
+		pending, err := script.Pending(applyOptions)
+		if err != nil {
+			walkErr = err
+			return
+		}
+		if pending {
+			pendingScripts = append(pendingScripts, script)
+		}
+	})
+	if walkErr != nil {
+		return walkErr
+	}
+	if len(pendingScripts) == 0 {
+		return nil
+	}
+	sort.Slice(pendingScripts, func(i, j int) bool {
+		return pendingScripts[i].TargetName() < pendingScripts[j].TargetName()
+	})
+	if _, err := fmt.Fprintln(c.Stdout, "Scripts that would run:"); err != nil {
+		return err
+	}
+	for _, script := range pendingScripts {
+		suffix := ""
+		if script.Once {
+			suffix = " (once)"
+		}
+		if _, err := fmt.Fprintf(c.Stdout, "  %s%s\n", script.SourceName(), suffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
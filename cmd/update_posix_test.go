@@ -0,0 +1,232 @@
+// +build !windows
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestUpdatePreview(t *testing.T) {
+	skipIfGitNotFound(t)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# old\n",
+		},
+		"/remote": map[string]interface{}{
+			"dot_bashrc": "# old\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	runTestGit(t, fs, "/remote", "init", "--quiet")
+	runTestGit(t, fs, "/remote", "add", ".")
+	runTestGit(t, fs, "/remote", "commit", "--quiet", "--message", "Initial commit")
+	rawRemoteDir, err := fs.RawPath("/remote")
+	require.NoError(t, err)
+	runTestGit(t, fs, "/home/user", "clone", "--quiet", rawRemoteDir, ".local/share/chezmoi")
+
+	require.NoError(t, fs.WriteFile("/remote/dot_bashrc", []byte("# new\n"), 0666))
+	require.NoError(t, fs.WriteFile("/remote/run_once_install.sh", []byte("#!/bin/sh\ntrue\n"), 0777))
+	runTestGit(t, fs, "/remote", "add", ".")
+	runTestGit(t, fs, "/remote", "commit", "--quiet", "--message", "Update bashrc")
+
+	newUpdateTestConfig := func(stdin string, stdout *bytes.Buffer) *Config {
+		c := newTestConfig(
+			fs,
+			withDestDir("/home/user"),
+			withStdin(strings.NewReader(stdin)),
+			withStdout(stdout),
+		)
+		c.update.apply = true
+		c.update.preview = true
+		return c
+	}
+
+	// Declining the update leaves the source and destination directories
+	// unchanged.
+	stdout := &bytes.Buffer{}
+	c := newUpdateTestConfig("n\n", stdout)
+	require.NoError(t, c.runUpdateCmd(nil, nil))
+	assert.Contains(t, stdout.String(), "Update bashrc")
+	assert.Contains(t, stdout.String(), "+# new\n")
+	assert.Contains(t, stdout.String(), "run_once_install.sh (once)")
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+			vfst.TestContentsString("# old\n"),
+		),
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# old\n"),
+		),
+	)
+
+	// Accepting the update merges and applies it.
+	stdout.Reset()
+	c = newUpdateTestConfig("y\n", stdout)
+	require.NoError(t, c.runUpdateCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+			vfst.TestContentsString("# new\n"),
+		),
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# new\n"),
+		),
+	)
+
+	// Once up to date, there is nothing to preview.
+	stdout.Reset()
+	c = newUpdateTestConfig("", stdout)
+	require.NoError(t, c.runUpdateCmd(nil, nil))
+	assert.Contains(t, stdout.String(), "Already up to date.")
+}
+
+// A funcReader calls f before its first read from r.
+type funcReader struct {
+	f func()
+	r io.Reader
+}
+
+func (r *funcReader) Read(p []byte) (int, error) {
+	if r.f != nil {
+		r.f()
+		r.f = nil
+	}
+	return r.r.Read(p)
+}
+
+func TestUpdatePreviewMergesPreviewedRevision(t *testing.T) {
+	skipIfGitNotFound(t)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# old\n",
+		},
+		"/remote": map[string]interface{}{
+			"dot_bashrc": "# old\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	runTestGit(t, fs, "/remote", "init", "--quiet")
+	runTestGit(t, fs, "/remote", "add", ".")
+	runTestGit(t, fs, "/remote", "commit", "--quiet", "--message", "Initial commit")
+	rawRemoteDir, err := fs.RawPath("/remote")
+	require.NoError(t, err)
+	runTestGit(t, fs, "/home/user", "clone", "--quiet", rawRemoteDir, ".local/share/chezmoi")
+
+	require.NoError(t, fs.WriteFile("/remote/dot_bashrc", []byte("# new\n"), 0666))
+	runTestGit(t, fs, "/remote", "commit", "--quiet", "--all", "--message", "Previewed")
+
+	// Push another commit while the user is answering the prompt.
+	stdin := &funcReader{
+		f: func() {
+			require.NoError(t, fs.WriteFile("/remote/dot_bashrc", []byte("# not previewed\n"), 0666))
+			runTestGit(t, fs, "/remote", "commit", "--quiet", "--all", "--message", "Not previewed")
+		},
+		r: strings.NewReader("y\n"),
+	}
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(
+		fs,
+		withDestDir("/home/user"),
+		withStdin(stdin),
+		withStdout(stdout),
+	)
+	c.update.apply = true
+	c.update.preview = true
+	require.NoError(t, c.runUpdateCmd(nil, nil))
+	assert.Nil(t, stdin.f)
+	assert.NotContains(t, stdout.String(), "not previewed")
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.local/share/chezmoi/dot_bashrc",
+			vfst.TestContentsString("# new\n"),
+		),
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# new\n"),
+		),
+	)
+}
+
+func TestUpdatePreviewDiverged(t *testing.T) {
+	skipIfGitNotFound(t)
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# old\n",
+		},
+		"/remote": map[string]interface{}{
+			"dot_bashrc": "# old\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	runTestGit(t, fs, "/remote", "init", "--quiet")
+	runTestGit(t, fs, "/remote", "add", ".")
+	runTestGit(t, fs, "/remote", "commit", "--quiet", "--message", "Initial commit")
+	rawRemoteDir, err := fs.RawPath("/remote")
+	require.NoError(t, err)
+	runTestGit(t, fs, "/home/user", "clone", "--quiet", rawRemoteDir, ".local/share/chezmoi")
+
+	require.NoError(t, fs.WriteFile("/remote/dot_bashrc", []byte("# new\n"), 0666))
+	runTestGit(t, fs, "/remote", "commit", "--quiet", "--all", "--message", "Remote")
+	require.NoError(t, fs.WriteFile("/home/user/.local/share/chezmoi/dot_profile", []byte("# local\n"), 0666))
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "add", ".")
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "commit", "--quiet", "--message", "Local")
+	// chezmoi runs git without the test's identity.
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "config", "user.name", "user")
+	runTestGit(t, fs, "/home/user/.local/share/chezmoi", "config", "user.email", "user@example.com")
+
+	// The default pull arguments rebase the local commits onto the previewed
+	// revision rather than requiring a fast-forward.
+	c := newTestConfig(
+		fs,
+		withDestDir("/home/user"),
+		withStdin(strings.NewReader("y\n")),
+		withStdout(&bytes.Buffer{}),
+	)
+	c.update.apply = true
+	c.update.preview = true
+	require.NoError(t, c.runUpdateCmd(nil, nil))
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# new\n"),
+		),
+		vfst.TestPath("/home/user/.profile",
+			vfst.TestContentsString("# local\n"),
+		),
+	)
+}
+
+// runTestGit runs git with args in dir in fs.
+func runTestGit(t *testing.T, fs *vfst.TestFS, dir string, args ...string) {
+	rawDir, err := fs.RawPath(dir)
+	require.NoError(t, err)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = rawDir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=user",
+		"GIT_AUTHOR_EMAIL=user@example.com",
+		"GIT_COMMITTER_NAME=user",
+		"GIT_COMMITTER_EMAIL=user@example.com",
+	)
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func skipIfGitNotFound(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in $PATH")
+	}
+}
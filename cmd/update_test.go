@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitIntegrateArgs(t *testing.T) {
+	for _, tc := range []struct {
+		pullArgs []string
+		want     []string
+	}{
+		{
+			pullArgs: []string{"pull", "--rebase"},
+			want:     []string{"rebase", "upstream"},
+		},
+		{
+			pullArgs: []string{"pull", "--rebase=merges", "--autostash"},
+			want:     []string{"rebase", "--rebase-merges", "--autostash", "upstream"},
+		},
+		{
+			pullArgs: []string{"pull", "--rebase", "--no-rebase"},
+			want:     []string{"merge", "upstream"},
+		},
+		{
+			pullArgs: []string{"pull", "--ff-only"},
+			want:     []string{"merge", "--ff-only", "upstream"},
+		},
+		{
+			pullArgs: []string{"pull", "--no-ff", "origin", "master"},
+			want:     []string{"merge", "--no-ff", "upstream"},
+		},
+	} {
+		assert.Equal(t, tc.want, gitIntegrateArgs(tc.pullArgs, "upstream"))
+	}
+}
@@ -4,6 +4,7 @@ package cmd
 
 import (
 	"io"
+	"os/exec"
 	"syscall"
 )
 
@@ -18,6 +19,11 @@ func getUmask() int {
 	return umask
 }
 
+// shellCmd returns a command that runs command with the shell.
+func shellCmd(command string) *exec.Cmd {
+	return exec.Command("sh", "-c", command)
+}
+
 func trimExecutableSuffix(s string) string {
 	return s
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"io"
 	"os"
+	"os/exec"
 	"strings"
 
 	"golang.org/x/sys/windows"
@@ -27,6 +28,11 @@ func getUmask() int {
 	return 0
 }
 
+// shellCmd returns a command that runs command with the shell.
+func shellCmd(command string) *exec.Cmd {
+	return exec.Command("cmd.exe", "/c", command)
+}
+
 func trimExecutableSuffix(s string) string {
 	return strings.TrimSuffix(s, ".exe")
 }
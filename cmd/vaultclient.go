@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	vaultDefaultAddress = "https://127.0.0.1:8200"
+	vaultTokenFileName  = ".vault-token"
+)
+
+var errVaultSecretNotFound = errors.New("secret not found")
+
+// A vaultClient retrieves secrets from Vault's KV secrets engine using Vault's
+// HTTP API.
+type vaultClient struct {
+	httpClient *http.Client
+	address    string
+	namespace  string
+	token      string
+	mounts     []*vaultMount
+}
+
+// A vaultMount is a KV secrets engine mount.
+type vaultMount struct {
+	path    string
+	version int
+}
+
+// A vaultErrorResponse is the body of an error response from Vault.
+type vaultErrorResponse struct {
+	Errors []string `json:"errors"`
+}
+
+// A vaultMountResponse is the body of a response from Vault's
+// sys/internal/ui/mounts endpoint.
+type vaultMountResponse struct {
+	Data struct {
+		Path    string `json:"path"`
+		Type    string `json:"type"`
+		Options struct {
+			Version string `json:"version"`
+		} `json:"options"`
+	} `json:"data"`
+}
+
+func newVaultClient(address, namespace, token string) *vaultClient {
+	return &vaultClient{
+		httpClient: &http.Client{
+			Timeout: time.Minute,
+		},
+		address:   strings.TrimSuffix(address, "/"),
+		namespace: namespace,
+		token:     token,
+	}
+}
+
+// kvGet returns the secret at key. The response has the same format as the
+// output of vault kv get -format=json, so for KV version 2 mounts the secret's
+// data is in .data.data and for KV version 1 mounts it is in .data.
+func (vc *vaultClient) kvGet(key string) ([]byte, error) {
+	key = strings.Trim(key, "/")
+	mount, err := vc.getMount(key)
+	if err != nil {
+		return nil, err
+	}
+	path := key
+	if mount.version == 2 {
+		path = mount.path + "data/" + strings.TrimPrefix(key, mount.path)
+	}
+	body, err := vc.get(path)
+	if errors.Is(err, errVaultSecretNotFound) {
+		return nil, fmt.Errorf("no value found at %s", path)
+	}
+	return body, err
+}
+
+// get returns the body of the response to a GET request for path.
+func (vc *vaultClient) get(path string) ([]byte, error) {
+	u := vc.address + "/v1/" + (&url.URL{Path: path}).EscapedPath()
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Request", "true")
+	req.Header.Set("X-Vault-Token", vc.token)
+	if vc.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", vc.namespace)
+	}
+	resp, err := vc.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", u, err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return body, nil
+	}
+	var errorResponse vaultErrorResponse
+	if err := json.Unmarshal(body, &errorResponse); err != nil || len(errorResponse.Errors) == 0 {
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, errVaultSecretNotFound
+		}
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return nil, fmt.Errorf("GET %s: %s: %s", u, resp.Status, strings.Join(errorResponse.Errors, ", "))
+}
+
+// getMount returns the KV secrets engine mount that contains key. Mounts are
+// cached. If Vault does not report the mount, for example because it is too
+// old, then key is assumed to be in a KV version 1 mount.
+func (vc *vaultClient) getMount(key string) (*vaultMount, error) {
+	for _, mount := range vc.mounts {
+		if strings.HasPrefix(key, mount.path) {
+			return mount, nil
+		}
+	}
+	body, err := vc.get("sys/internal/ui/mounts/" + key)
+	switch {
+	case errors.Is(err, errVaultSecretNotFound):
+		return &vaultMount{
+			version: 1,
+		}, nil
+	case err != nil:
+		return nil, err
+	}
+	var mountResponse vaultMountResponse
+	if err := json.Unmarshal(body, &mountResponse); err != nil {
+		return nil, err
+	}
+	if mountResponse.Data.Type != "kv" && mountResponse.Data.Type != "generic" {
+		return nil, fmt.Errorf("%s: not a KV secrets engine (type %s)", mountResponse.Data.Path, mountResponse.Data.Type)
+	}
+	mount := &vaultMount{
+		path:    mountResponse.Data.Path,
+		version: 1,
+	}
+	if mountResponse.Data.Options.Version == "2" {
+		mount.version = 2
+	}
+	vc.mounts = append(vc.mounts, mount)
+	return mount, nil
+}
+
+// getVaultClient returns a Vault HTTP API client configured from c.Vault and
+// the environment, creating it if needed.
+func (c *Config) getVaultClient() (*vaultClient, error) {
+	if c.vaultClient != nil {
+		return c.vaultClient, nil
+	}
+
+	address := c.Vault.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		address = vaultDefaultAddress
+	}
+
+	namespace := c.Vault.Namespace
+	if namespace == "" {
+		namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		tokenFile := c.Vault.TokenFile
+		if tokenFile == "" {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			tokenFile = filepath.Join(homeDir, vaultTokenFileName)
+		}
+		data, err := c.fs.ReadFile(tokenFile)
+		switch {
+		case os.IsNotExist(err):
+			return nil, fmt.Errorf("no token: set VAULT_TOKEN or log in to create %s", tokenFile)
+		case err != nil:
+			return nil, err
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	c.vaultClient = newVaultClient(address, namespace, token)
+	return c.vaultClient, nil
+}
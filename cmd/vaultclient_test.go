@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+// A testVaultServer imitates the parts of Vault's HTTP API used by vaultClient.
+type testVaultServer struct {
+	*httptest.Server
+	token         string
+	namespaces    []string
+	mountRequests int
+}
+
+func newTestVaultServer(token string) *testVaultServer {
+	s := &testVaultServer{
+		token: token,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *testVaultServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	writeJSON := func(statusCode int, value interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_ = json.NewEncoder(w).Encode(value)
+	}
+	s.namespaces = append(s.namespaces, r.Header.Get("X-Vault-Namespace"))
+	if r.Header.Get("X-Vault-Token") != s.token {
+		writeJSON(http.StatusForbidden, map[string]interface{}{
+			"errors": []string{"permission denied"},
+		})
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if strings.HasPrefix(path, "sys/internal/ui/mounts/") {
+		s.mountRequests++
+		key := strings.TrimPrefix(path, "sys/internal/ui/mounts/")
+		for mountPath, version := range map[string]string{
+			"kv/":     "1",
+			"secret/": "2",
+		} {
+			if strings.HasPrefix(key, mountPath) {
+				writeJSON(http.StatusOK, map[string]interface{}{
+					"data": map[string]interface{}{
+						"path": mountPath,
+						"type": "kv",
+						"options": map[string]interface{}{
+							"version": version,
+						},
+					},
+				})
+				return
+			}
+		}
+		writeJSON(http.StatusBadRequest, map[string]interface{}{
+			"errors": []string{"no handler for route '" + key + "'"},
+		})
+		return
+	}
+	switch path {
+	case "kv/example":
+		writeJSON(http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"password": "hunter1",
+			},
+		})
+	case "secret/data/example":
+		writeJSON(http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{
+					"password": "hunter2",
+				},
+				"metadata": map[string]interface{}{
+					"version": 1,
+				},
+			},
+		})
+	default:
+		writeJSON(http.StatusNotFound, map[string]interface{}{
+			"errors": []string{},
+		})
+	}
+}
+
+func TestVaultClient(t *testing.T) {
+	s := newTestVaultServer("token")
+	defer s.Close()
+
+	for _, tc := range []struct {
+		name        string
+		token       string
+		key         string
+		expectedErr string
+		expected    string
+	}{
+		{
+			name:     "kv_v1",
+			token:    "token",
+			key:      "kv/example",
+			expected: `{"data":{"password":"hunter1"}}`,
+		},
+		{
+			name:     "kv_v2",
+			token:    "token",
+			key:      "secret/example",
+			expected: `{"data":{"data":{"password":"hunter2"},"metadata":{"version":1}}}`,
+		},
+		{
+			name:        "kv_v2_missing",
+			token:       "token",
+			key:         "secret/missing",
+			expectedErr: "no value found at secret/data/missing",
+		},
+		{
+			name:        "no_mount",
+			token:       "token",
+			key:         "missing/example",
+			expectedErr: "400 Bad Request: no handler for route 'missing/example'",
+		},
+		{
+			name:        "permission_denied",
+			token:       "wrong-token",
+			key:         "secret/example",
+			expectedErr: "403 Forbidden: permission denied",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vc := newVaultClient(s.URL, "", tc.token)
+			actual, err := vc.kvGet(tc.key)
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestVaultClientMountCache(t *testing.T) {
+	s := newTestVaultServer("token")
+	defer s.Close()
+
+	vc := newVaultClient(s.URL, "", "token")
+	for i := 0; i < 2; i++ {
+		_, err := vc.kvGet("secret/example")
+		require.NoError(t, err)
+		_, err = vc.kvGet("kv/example")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, s.mountRequests)
+}
+
+func TestVaultFuncHTTP(t *testing.T) {
+	s := newTestVaultServer("token")
+	defer s.Close()
+
+	for _, key := range []string{"VAULT_ADDR", "VAULT_NAMESPACE", "VAULT_TOKEN"} {
+		if value, ok := os.LookupEnv(key); ok {
+			require.NoError(t, os.Unsetenv(key))
+			defer os.Setenv(key, value) //nolint:errcheck
+		}
+	}
+
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.vault-token": "token\n",
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	stdout := &bytes.Buffer{}
+	c := newTestConfig(fs, withStdout(stdout))
+	c.Vault = vaultCmdConfig{
+		Client:    vaultClientHTTP,
+		Address:   s.URL,
+		Namespace: "ns1",
+		TokenFile: "/home/user/.vault-token",
+	}
+	c.addSecretTemplateFunc("vault", c.vaultFunc)
+
+	require.NoError(t, c.runExecuteTemplateCmd(nil, []string{
+		`{{ (vault "secret/example").data.data.password }}`,
+	}))
+	assert.Equal(t, "hunter2", stdout.String())
+	assert.NotEmpty(t, s.namespaces)
+	for _, namespace := range s.namespaces {
+		assert.Equal(t, "ns1", namespace)
+	}
+
+	c = newTestConfig(fs)
+	c.Vault = vaultCmdConfig{
+		Client:    vaultClientHTTP,
+		Address:   s.URL,
+		TokenFile: "/home/user/.missing-token",
+	}
+	c.addSecretTemplateFunc("vault", c.vaultFunc)
+	err = c.runExecuteTemplateCmd(nil, []string{`{{ vault "secret/other" }}`})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no token")
+}
@@ -0,0 +1,410 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"github.com/twpayne/chezmoi/internal/chezmoi"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+var watchCmd = &cobra.Command{
+	Use:     "watch",
+	Args:    cobra.NoArgs,
+	Short:   "Apply changes to the source directory as they happen",
+	Long:    mustGetLongHelp("watch"),
+	Example: getExample("watch"),
+	PreRunE: config.ensureNoError,
+	RunE:    config.runWatchCmd,
+}
+
+type watchCmdConfig struct {
+	debounce time.Duration
+	drift    string
+}
+
+// specialSourceNames are the names of files and directories in the source
+// directory that, when changed, require the whole target state to be applied.
+// Changes to sops-encrypted data files, whose names begin with
+// chezmoi.SOPSDataName, also require this.
+var specialSourceNames = map[string]struct{}{
+	".chezmoiignore":    {},
+	".chezmoiremove":    {},
+	".chezmoitemplates": {},
+	".chezmoitriggers":  {},
+	".chezmoiversion":   {},
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	persistentFlags := watchCmd.PersistentFlags()
+	persistentFlags.DurationVar(&config.watch.debounce, "debounce", 250*time.Millisecond, "wait for changes to settle")
+	persistentFlags.StringVar(&config.watch.drift, "drift", "ignore", "action on destination changes (ignore, report, or apply)")
+}
+
+// A watcher watches the source and destination directories. The persistent
+// state and the template cache are only opened while changes are being
+// handled, so that other chezmoi commands can run while watching.
+type watcher struct {
+	c           *Config
+	fs          vfs.FS
+	mutator     chezmoi.Mutator
+	ts          *chezmoi.TargetState
+	w           *fsnotify.Watcher
+	watchedDirs map[string]struct{}
+}
+
+func (c *Config) runWatchCmd(cmd *cobra.Command, args []string) error {
+	switch c.watch.drift {
+	case "apply", "ignore", "report":
+	default:
+		return fmt.Errorf("invalid --drift value: %s", c.watch.drift)
+	}
+
+	ts, err := c.getTargetState(nil)
+	if err != nil {
+		return err
+	}
+	if err := c.closeCachePersistentState(); err != nil {
+		return err
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsWatcher.Close()
+
+	// Always log changes, even if not in verbose mode.
+	mutator := c.mutator
+	if !c.Verbose {
+		mutator = chezmoi.NewVerboseMutator(c.Stdout, mutator, c.colored, c.maxDiffDataSize, c.redactor)
+	}
+
+	w := &watcher{
+		c:           c,
+		fs:          vfs.NewReadOnlyFS(c.fs),
+		mutator:     mutator,
+		ts:          ts,
+		w:           fsWatcher,
+		watchedDirs: make(map[string]struct{}),
+	}
+	if err := w.addSourceDir(c.SourceDir); err != nil {
+		return err
+	}
+	if c.watch.drift != "ignore" {
+		w.addDestDirs()
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	sourceChanges := make(map[string]struct{})
+	destChanges := make(map[string]struct{})
+	timer := time.NewTimer(c.watch.debounce)
+	timer.Stop()
+	for {
+		select {
+		case event := <-fsWatcher.Events:
+			if relPath, ok := relPathWithin(c.SourceDir, event.Name); ok {
+				if info, err := c.fs.Stat(event.Name); err == nil && info.IsDir() && event.Op&fsnotify.Create != 0 {
+					if err := w.addSourceDir(event.Name); err != nil {
+						w.printError(err)
+					}
+				}
+				sourceChanges[relPath] = struct{}{}
+			} else if relPath, ok := relPathWithin(ts.DestDir, event.Name); ok {
+				destChanges[relPath] = struct{}{}
+			}
+			timer.Reset(c.watch.debounce)
+		case err := <-fsWatcher.Errors:
+			w.printError(err)
+		case <-timer.C:
+			if len(sourceChanges) != 0 {
+				if err := w.applySourceChanges(sourceChanges); err != nil {
+					w.printError(err)
+				}
+				sourceChanges = make(map[string]struct{})
+			}
+			if len(destChanges) != 0 {
+				if err := w.handleDestChanges(destChanges); err != nil {
+					w.printError(err)
+				}
+				destChanges = make(map[string]struct{})
+			}
+		case <-interrupt:
+			return nil
+		}
+	}
+}
+
+// addDestDirs watches the destination directories that contain managed
+// targets.
+func (w *watcher) addDestDirs() {
+	for _, entry := range entriesByTargetName(w.ts.Entries) {
+		if _, ok := entry.(*chezmoi.Script); ok {
+			continue
+		}
+		targetPath := filepath.Join(w.ts.DestDir, entry.TargetName())
+		dirs := []string{filepath.Dir(targetPath)}
+		if _, ok := entry.(*chezmoi.Dir); ok {
+			dirs = append(dirs, targetPath)
+		}
+		for _, dir := range dirs {
+			if _, ok := w.watchedDirs[dir]; ok {
+				continue
+			}
+			// Ignore errors, for example if the directory does not exist yet.
+			if err := w.w.Add(dir); err == nil {
+				w.watchedDirs[dir] = struct{}{}
+			}
+		}
+	}
+}
+
+// addSourceDir recursively watches the directory dir in the source directory.
+func (w *watcher) addSourceDir(dir string) error {
+	return vfs.Walk(w.fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != w.c.SourceDir && strings.HasPrefix(info.Name(), ".") && !isSpecialSourceName(info.Name()) {
+			return filepath.SkipDir
+		}
+		if _, ok := w.watchedDirs[path]; ok {
+			return nil
+		}
+		if err := w.w.Add(path); err != nil {
+			return err
+		}
+		w.watchedDirs[path] = struct{}{}
+		return nil
+	})
+}
+
+// applySourceChanges re-reads the target state and applies the entries
+// affected by changes to relPaths in the source directory.
+func (w *watcher) applySourceChanges(relPaths map[string]struct{}) (err error) {
+	all, sourceNames := classifySourceChanges(relPaths)
+	if !all && len(sourceNames) == 0 {
+		return nil
+	}
+
+	ts, persistentState, err := w.openState()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := w.closeState(persistentState); err == nil {
+			err = closeErr
+		}
+	}()
+	if w.c.watch.drift != "ignore" {
+		w.addDestDirs()
+	}
+
+	applyOptions := w.c.getApplyOptions(ts, persistentState)
+	mutator := chezmoi.NewRecordingMutator(w.mutator)
+	if all {
+		if err := ts.Apply(w.fs, mutator, w.c.Follow, applyOptions); err != nil {
+			return err
+		}
+		return w.c.runTriggers(ts, mutator)
+	}
+	for _, entry := range findEntriesOrAncestors(entriesBySourceName(ts.Entries), sourceNames) {
+		if err := entry.Apply(w.fs, mutator, w.c.Follow, applyOptions); err != nil {
+			return err
+		}
+	}
+	return w.c.runTriggers(ts, mutator)
+}
+
+// handleDestChanges reports or re-applies managed targets affected by changes
+// to relPaths in the destination directory.
+func (w *watcher) handleDestChanges(relPaths map[string]struct{}) (err error) {
+	ts, persistentState, err := w.openState()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := w.closeState(persistentState); err == nil {
+			err = closeErr
+		}
+	}()
+
+	applyOptions := w.c.getApplyOptions(ts, persistentState)
+	var mutator *chezmoi.RecordingMutator
+	switch w.c.watch.drift {
+	case "apply":
+		mutator = chezmoi.NewRecordingMutator(w.mutator)
+	case "report":
+		mutator = chezmoi.NewRecordingMutator(chezmoi.NewVerboseMutator(w.c.Stdout, chezmoi.NullMutator{}, w.c.colored, w.c.maxDiffDataSize, w.c.redactor))
+	}
+	for _, entry := range findEntriesOrAncestors(entriesByTargetName(ts.Entries), relPaths) {
+		if _, ok := entry.(*chezmoi.Script); ok {
+			continue
+		}
+
+		// Changes made by chezmoi itself, or that were subsequently reverted,
+		// leave the target in its target state.
+		anyMutator := chezmoi.NewAnyMutator(chezmoi.NullMutator{})
+		if err := entry.Apply(w.fs, anyMutator, w.c.Follow, applyOptions); err != nil {
+			return err
+		}
+		if !anyMutator.Mutated() {
+			continue
+		}
+
+		targetPath := filepath.Join(ts.DestDir, entry.TargetName())
+		fmt.Fprintf(w.c.Stdout, "%s: changed outside chezmoi\n", targetPath)
+		if err := entry.Apply(w.fs, mutator, w.c.Follow, applyOptions); err != nil {
+			return err
+		}
+	}
+	return w.c.runTriggers(ts, mutator)
+}
+
+// openState re-reads the target state, which opens the template cache, and
+// opens the persistent state. They must be closed with closeState.
+func (w *watcher) openState() (*chezmoi.TargetState, chezmoi.PersistentState, error) {
+	ts, err := w.c.getTargetState(nil)
+	if err != nil {
+		_ = w.c.closeCachePersistentState()
+		return nil, nil, err
+	}
+	w.ts = ts
+	persistentState, err := w.c.getPersistentState(nil)
+	if err != nil {
+		_ = w.c.closeCachePersistentState()
+		return nil, nil, err
+	}
+	return ts, persistentState, nil
+}
+
+// closeState closes persistentState and the template cache.
+func (w *watcher) closeState(persistentState chezmoi.PersistentState) error {
+	err := persistentState.Close()
+	if closeErr := w.c.closeCachePersistentState(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (w *watcher) printError(err error) {
+	fmt.Fprintf(w.c.Stderr, "chezmoi: %v\n", err)
+}
+
+// classifySourceChanges classifies changes to relPaths in the source directory.
+// It returns true if the whole target state must be applied, otherwise it
+// returns the set of relPaths that might affect entries. Changes to files and
+// directories beginning with a . are ignored, except for chezmoi's special
+// files.
+func classifySourceChanges(relPaths map[string]struct{}) (bool, map[string]struct{}) {
+	sourceNames := make(map[string]struct{})
+FOR:
+	for relPath := range relPaths {
+		for _, component := range strings.Split(filepath.ToSlash(relPath), "/") {
+			if !strings.HasPrefix(component, ".") {
+				continue
+			}
+			if isSpecialSourceName(component) {
+				return true, nil
+			}
+			continue FOR
+		}
+		sourceNames[relPath] = struct{}{}
+	}
+	return false, sourceNames
+}
+
+// isSpecialSourceName returns whether changes to the file or directory name in
+// the source directory require the whole target state to be applied.
+func isSpecialSourceName(name string) bool {
+	if _, ok := specialSourceNames[name]; ok {
+		return true
+	}
+	return strings.HasPrefix(name, chezmoi.SOPSDataName+".")
+}
+
+// entriesBySourceName returns a map of all entries in entries, recursively,
+// indexed by source name.
+func entriesBySourceName(entries map[string]chezmoi.Entry) map[string]chezmoi.Entry {
+	result := make(map[string]chezmoi.Entry)
+	walkEntries(entries, func(entry chezmoi.Entry) {
+		result[entry.SourceName()] = entry
+	})
+	return result
+}
+
+// entriesByTargetName returns a map of all entries in entries, recursively,
+// indexed by target name.
+func entriesByTargetName(entries map[string]chezmoi.Entry) map[string]chezmoi.Entry {
+	result := make(map[string]chezmoi.Entry)
+	walkEntries(entries, func(entry chezmoi.Entry) {
+		result[entry.TargetName()] = entry
+	})
+	return result
+}
+
+// findEntriesOrAncestors returns the entries in index named by names or, if
+// there is no such entry, by their closest ancestor. Entries whose ancestors
+// are also returned are omitted.
+func findEntriesOrAncestors(index map[string]chezmoi.Entry, names map[string]struct{}) []chezmoi.Entry {
+	found := make(map[string]struct{})
+	for name := range names {
+		for ; name != "."; name = filepath.Dir(name) {
+			if _, ok := index[name]; ok {
+				found[name] = struct{}{}
+				break
+			}
+		}
+	}
+	sortedNames := make([]string, 0, len(found))
+FOR:
+	for name := range found {
+		for ancestor := filepath.Dir(name); ancestor != "."; ancestor = filepath.Dir(ancestor) {
+			if _, ok := found[ancestor]; ok {
+				continue FOR
+			}
+		}
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+	entries := make([]chezmoi.Entry, 0, len(sortedNames))
+	for _, name := range sortedNames {
+		entries = append(entries, index[name])
+	}
+	return entries
+}
+
+// relPathWithin returns the path of path relative to dir, and whether path is
+// in dir.
+func relPathWithin(dir, path string) (string, bool) {
+	relPath, err := filepath.Rel(dir, path)
+	if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return relPath, true
+}
+
+// walkEntries calls f on every entry in entries, recursively.
+func walkEntries(entries map[string]chezmoi.Entry, f func(chezmoi.Entry)) {
+	for _, entry := range entries {
+		f(entry)
+		if dir, ok := entry.(*chezmoi.Dir); ok {
+			walkEntries(dir.Entries, f)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	vfs "github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestClassifySourceChanges(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		relPaths        []string
+		wantAll         bool
+		wantSourceNames map[string]struct{}
+	}{
+		{
+			name:     "files",
+			relPaths: []string{"dot_bashrc", filepath.Join("dot_config", "foo")},
+			wantSourceNames: map[string]struct{}{
+				"dot_bashrc":                       {},
+				filepath.Join("dot_config", "foo"): {},
+			},
+		},
+		{
+			name:            "vcs",
+			relPaths:        []string{filepath.Join(".git", "index")},
+			wantSourceNames: map[string]struct{}{},
+		},
+		{
+			name:     "special",
+			relPaths: []string{"dot_bashrc", filepath.Join("dot_config", ".chezmoiignore")},
+			wantAll:  true,
+		},
+		{
+			name:     "triggers",
+			relPaths: []string{".chezmoitriggers"},
+			wantAll:  true,
+		},
+		{
+			name:     "sops_data",
+			relPaths: []string{".chezmoidata.sops.yaml"},
+			wantAll:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			relPaths := make(map[string]struct{})
+			for _, relPath := range tc.relPaths {
+				relPaths[relPath] = struct{}{}
+			}
+			all, sourceNames := classifySourceChanges(relPaths)
+			assert.Equal(t, tc.wantAll, all)
+			if !tc.wantAll {
+				assert.Equal(t, tc.wantSourceNames, sourceNames)
+			}
+		})
+	}
+}
+
+func TestWatchApplySourceChanges(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user": map[string]interface{}{
+			".bashrc": "# old contents of .bashrc\n",
+			".local/share/chezmoi": map[string]interface{}{
+				"dot_bashrc":                  "# contents of .bashrc\n",
+				"exact_dot_dir/file":          "# contents of .dir/file\n",
+				"dot_inputrc":                 "# contents of .inputrc\n",
+				".git/index":                  "",
+				"exact_dot_dir/dot_unchanged": "# contents of .dir/.unchanged\n",
+			},
+			".dir/extra": "# extra\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	w := newTestWatcher(t, fs, newTestConfig(fs))
+	defer w.w.Close()
+	require.NoError(t, w.applySourceChanges(map[string]struct{}{
+		filepath.Join(".git", "index"):                 {},
+		filepath.Join("exact_dot_dir", "deleted_file"): {},
+		"dot_bashrc": {},
+	}))
+	// The template cache is closed so that other commands can use it.
+	assert.Nil(t, w.c.templateCachePersistentState)
+
+	vfst.RunTests(t, fs, "",
+		vfst.TestPath("/home/user/.bashrc",
+			vfst.TestContentsString("# contents of .bashrc\n"),
+		),
+		vfst.TestPath("/home/user/.dir/file",
+			vfst.TestContentsString("# contents of .dir/file\n"),
+		),
+		vfst.TestPath("/home/user/.dir/extra",
+			vfst.TestDoesNotExist,
+		),
+		vfst.TestPath("/home/user/.inputrc",
+			vfst.TestDoesNotExist,
+		),
+	)
+}
+
+func TestWatchHandleDestChanges(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		drift        string
+		wantContents string
+	}{
+		{
+			name:         "report",
+			drift:        "report",
+			wantContents: "# edited contents of .bashrc\n",
+		},
+		{
+			name:         "apply",
+			drift:        "apply",
+			wantContents: "# contents of .bashrc\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user": map[string]interface{}{
+					".bashrc":                          "# edited contents of .bashrc\n",
+					".inputrc":                         "# contents of .inputrc\n",
+					".local/share/chezmoi/dot_bashrc":  "# contents of .bashrc\n",
+					".local/share/chezmoi/dot_inputrc": "# contents of .inputrc\n",
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			stdout := &bytes.Buffer{}
+			c := newTestConfig(fs, withStdout(stdout))
+			c.watch.drift = tc.drift
+			w := newTestWatcher(t, fs, c)
+			defer w.w.Close()
+			require.NoError(t, w.handleDestChanges(map[string]struct{}{
+				".bashrc":    {},
+				".inputrc":   {},
+				".unmanaged": {},
+			}))
+
+			assert.Contains(t, stdout.String(), filepath.Join("/", "home", "user", ".bashrc")+": changed outside chezmoi\n")
+			assert.NotContains(t, stdout.String(), ".inputrc")
+			vfst.RunTests(t, fs, "",
+				vfst.TestPath("/home/user/.bashrc",
+					vfst.TestContentsString(tc.wantContents),
+				),
+			)
+		})
+	}
+}
+
+func newTestWatcher(t *testing.T, fs vfs.FS, c *Config) *watcher {
+	fsWatcher, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	ts, err := c.getTargetState(nil)
+	require.NoError(t, err)
+	return &watcher{
+		c:           c,
+		fs:          vfs.NewReadOnlyFS(fs),
+		mutator:     c.mutator,
+		ts:          ts,
+		w:           fsWatcher,
+		watchedDirs: make(map[string]struct{}),
+	}
+}
@@ -1,84 +1,212 @@
 package chezmoi
 
 import (
+	"fmt"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 )
 
+// An AutoTemplateOptions contains options for generating templates
+// automatically.
+type AutoTemplateOptions struct {
+	// MinMatchLength is the minimum length of a value for it to be replaced.
+	// Values shorter than this are never replaced.
+	MinMatchLength int
+	// Accept, if not nil, is called for each possible substitution. The
+	// substitution is only made if it returns true.
+	Accept func(*AutoTemplateSubstitution) (bool, error)
+	// Templates maps additional values to the template expressions that
+	// replace them. They take precedence over template data with the same
+	// value and are not passed to Accept.
+	Templates map[string]string
+}
+
+// An AutoTemplateSubstitution is a replacement of a value with a template
+// expression.
+type AutoTemplateSubstitution struct {
+	Line   int
+	Column int
+	Value  string
+	Expr   string
+}
+
+// A templateVariable is a value that can be replaced by a template expression.
 type templateVariable struct {
-	name  string
-	value string
+	expr   string
+	value  string
+	accept bool
 }
 
-type byValueLength []templateVariable
+var identifierRegexp = regexp.MustCompile(`\A[\pL_][\pL\p{Nd}_]*\z`)
 
-func (b byValueLength) Len() int { return len(b) }
-func (b byValueLength) Less(i, j int) bool {
-	switch {
-	case len(b[i].value) < len(b[j].value):
-		return true
-	case len(b[i].value) == len(b[j].value):
-		// Fallback to name
-		return b[i].name > b[j].name
-	default:
-		return false
+// Escaped template delimiters.
+const (
+	escapedLeftDelim  = `{{ "{{" }}`
+	escapedRightDelim = `{{ "}}" }}`
+)
+
+// autoTemplate returns contents with template data values replaced by template
+// expressions that reference them, and the substitutions made. Only values on
+// word boundaries are replaced, longer values are preferred to shorter ones,
+// and each part of contents is replaced at most once. Existing template
+// delimiters in contents are escaped so that the result evaluates to contents.
+func autoTemplate(contents []byte, data map[string]interface{}, options *AutoTemplateOptions) ([]byte, []AutoTemplateSubstitution, error) {
+	if options == nil {
+		options = &AutoTemplateOptions{}
 	}
-}
-func (b byValueLength) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
 
-func autoTemplate(contents []byte, data map[string]interface{}) []byte {
-	// FIXME this naive approach will generate incorrect templates if the
-	// variable names match variable values
-	// FIXME the algorithm here is probably O(N^2), we can do better
-	variables := extractVariables(nil, nil, data)
-	sort.Sort(sort.Reverse(byValueLength(variables)))
-	contentsStr := string(contents)
-	for _, variable := range variables {
-		if variable.value == "" {
-			continue
+	// Index the variables by the first byte of their value so that only the
+	// variables that might match at each position are considered.
+	variablesByFirstByte := make(map[byte][]templateVariable)
+	addVariable := func(variable templateVariable) {
+		if variable.value == "" || len(variable.value) < options.MinMatchLength {
+			return
 		}
-		index := strings.Index(contentsStr, variable.value)
-		for index != -1 && index != len(contentsStr) {
-			if !inWord(contentsStr, index) && !inWord(contentsStr, index+len(variable.value)) {
-				// Replace variable.value which is on word boundaries at both
-				// ends.
-				replacement := "{{ ." + variable.name + " }}"
-				contentsStr = contentsStr[:index] + replacement + contentsStr[index+len(variable.value):]
-				index += len(replacement)
-			} else {
-				// Otherwise, keep looking. Consume at least one byte so we
-				// make progress.
-				index++
+		b := variable.value[0]
+		variablesByFirstByte[b] = append(variablesByFirstByte[b], variable)
+	}
+	for value, expr := range options.Templates {
+		addVariable(templateVariable{
+			expr:   expr,
+			value:  value,
+			accept: true,
+		})
+	}
+	for _, variable := range extractVariables(nil, nil, data) {
+		addVariable(variable)
+	}
+	for _, variables := range variablesByFirstByte {
+		sort.Slice(variables, func(i, j int) bool {
+			switch {
+			case len(variables[i].value) != len(variables[j].value):
+				return len(variables[i].value) > len(variables[j].value)
+			case variables[i].accept != variables[j].accept:
+				return variables[i].accept
+			default:
+				return variables[i].expr < variables[j].expr
 			}
-			// Look for the next occurrence of variable.value.
-			j := strings.Index(contentsStr[index:], variable.value)
-			if j == -1 {
-				// No more occurrences found, so terminate the loop.
+		})
+	}
+
+	s := string(contents)
+	sb := &strings.Builder{}
+	sb.Grow(len(s))
+	var substitutions []AutoTemplateSubstitution
+	line, lineStart := 1, 0
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "{{"):
+			sb.WriteString(escapedLeftDelim)
+			i += 2
+			continue
+		case strings.HasPrefix(s[i:], "}}"):
+			sb.WriteString(escapedRightDelim)
+			i += 2
+			continue
+		}
+		matched := false
+		if !inWord(s, i) {
+			for _, variable := range variablesByFirstByte[s[i]] {
+				if !strings.HasPrefix(s[i:], variable.value) || inWord(s, i+len(variable.value)) {
+					continue
+				}
+				substitution := AutoTemplateSubstitution{
+					Line:   line,
+					Column: i - lineStart + 1,
+					Value:  variable.value,
+					Expr:   variable.expr,
+				}
+				if !variable.accept && options.Accept != nil {
+					accept, err := options.Accept(&substitution)
+					if err != nil {
+						return nil, nil, err
+					}
+					if !accept {
+						continue
+					}
+				}
+				sb.WriteString("{{ " + variable.expr + " }}")
+				substitutions = append(substitutions, substitution)
+				for j := i; j < i+len(variable.value); j++ {
+					if s[j] == '\n' {
+						line, lineStart = line+1, j+1
+					}
+				}
+				i += len(variable.value)
+				matched = true
 				break
-			} else {
-				// Advance to the next occurrence.
-				index += j
 			}
 		}
+		if !matched {
+			if s[i] == '\n' {
+				line, lineStart = line+1, i+1
+			}
+			sb.WriteByte(s[i])
+			i++
+		}
 	}
-	return []byte(contentsStr)
+	return []byte(sb.String()), substitutions, nil
 }
 
-func extractVariables(variables []templateVariable, parent []string, data map[string]interface{}) []templateVariable {
-	for name, value := range data {
-		switch value := value.(type) {
-		case string:
-			variables = append(variables, templateVariable{
-				name:  strings.Join(append(parent, name), "."),
-				value: value,
-			})
-		case map[string]interface{}:
-			variables = extractVariables(variables, append(parent, name), value)
+// extractVariables appends the string, numeric, and list values in data to
+// variables. Other values, including LazyValues, are ignored.
+func extractVariables(variables []templateVariable, path []interface{}, data interface{}) []templateVariable {
+	switch data := data.(type) {
+	case map[string]interface{}:
+		for name, value := range data {
+			variables = extractVariables(variables, append(path[:len(path):len(path)], name), value)
 		}
+	case []interface{}:
+		for i, value := range data {
+			variables = extractVariables(variables, append(path[:len(path):len(path)], i), value)
+		}
+	case []string:
+		for i, value := range data {
+			variables = extractVariables(variables, append(path[:len(path):len(path)], i), value)
+		}
+	case string:
+		variables = append(variables, templateVariable{
+			expr:  dataExpr(path),
+			value: data,
+		})
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		variables = append(variables, templateVariable{
+			expr:  dataExpr(path),
+			value: fmt.Sprint(data),
+		})
 	}
 	return variables
 }
 
+// dataExpr returns a template expression for the template data value at path,
+// whose elements are map keys or list indexes.
+func dataExpr(path []interface{}) string {
+	fields := make([]string, 0, len(path))
+	for _, element := range path {
+		key, ok := element.(string)
+		if !ok || !identifierRegexp.MatchString(key) {
+			break
+		}
+		fields = append(fields, key)
+	}
+	expr := "." + strings.Join(fields, ".")
+	if len(fields) == len(path) {
+		return expr
+	}
+	args := []string{"index", expr}
+	for _, element := range path[len(fields):] {
+		switch element := element.(type) {
+		case string:
+			args = append(args, strconv.Quote(element))
+		default:
+			args = append(args, fmt.Sprint(element))
+		}
+	}
+	return strings.Join(args, " ")
+}
+
 // inWord returns true if splitting s at position i would split a word.
 func inWord(s string, i int) bool {
 	return i > 0 && i < len(s) && isWord(s[i-1]) && isWord(s[i])
@@ -1,9 +1,13 @@
 package chezmoi
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"text/template"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAutoTemplate(t *testing.T) {
@@ -122,13 +126,141 @@ func TestAutoTemplate(t *testing.T) {
 			},
 			wantStr: "a",
 		},
+		{
+			name:        "name_equals_value",
+			contentsStr: "email = email@example.com\nkey = email\n",
+			data: map[string]interface{}{
+				"email": "email@example.com",
+				"key":   "email",
+			},
+			wantStr: "{{ .key }} = {{ .email }}\nkey = {{ .key }}\n",
+		},
+		{
+			name:        "escape_delimiters",
+			contentsStr: "{{ .name }} John {{",
+			data: map[string]interface{}{
+				"name": "John",
+			},
+			wantStr: `{{ "{{" }} .name {{ "}}" }} {{ .name }} {{ "{{" }}`,
+		},
+		{
+			name:        "numbers",
+			contentsStr: "port = 8080\nratio = 1.5\nid = 80801\n",
+			data: map[string]interface{}{
+				"port":  int64(8080),
+				"ratio": 1.5,
+			},
+			wantStr: "port = {{ .port }}\nratio = {{ .ratio }}\nid = 80801\n",
+		},
+		{
+			name:        "lists",
+			contentsStr: "hosts = alpha, beta\n",
+			data: map[string]interface{}{
+				"hosts": []interface{}{"alpha", "beta"},
+			},
+			wantStr: "hosts = {{ index .hosts 0 }}, {{ index .hosts 1 }}\n",
+		},
+		{
+			name:        "non_identifier_keys",
+			contentsStr: "john.smith@company.com",
+			data: map[string]interface{}{
+				"work-email": map[string]interface{}{
+					"address": "john.smith@company.com",
+				},
+			},
+			wantStr: `{{ index . "work-email" "address" }}`,
+		},
+		{
+			name:        "lazy_values",
+			contentsStr: "linux",
+			data: map[string]interface{}{
+				"os": NewLazyValue(func() (interface{}, error) {
+					return "linux", nil
+				}),
+			},
+			wantStr: "linux",
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			assert.Equal(t, tc.wantStr, string(autoTemplate([]byte(tc.contentsStr), tc.data)))
+			actual, _, err := autoTemplate([]byte(tc.contentsStr), tc.data, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantStr, string(actual))
+
+			// Executing the generated template must give the original contents.
+			tmpl, err := template.New(tc.name).Option("missingkey=error").Parse(string(actual))
+			require.NoError(t, err)
+			sb := &strings.Builder{}
+			require.NoError(t, tmpl.Execute(sb, tc.data))
+			assert.Equal(t, tc.contentsStr, sb.String())
 		})
 	}
 }
 
+func TestAutoTemplateOptions(t *testing.T) {
+	contents := []byte("[user]\n\tname = John Smith\n\temail = js@example.com\n\tid = 42\n")
+	data := map[string]interface{}{
+		"email": "js@example.com",
+		"id":    42,
+		"name":  "John Smith",
+	}
+
+	t.Run("substitutions", func(t *testing.T) {
+		actual, substitutions, err := autoTemplate(contents, data, &AutoTemplateOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "[user]\n\tname = {{ .name }}\n\temail = {{ .email }}\n\tid = {{ .id }}\n", string(actual))
+		assert.Equal(t, []AutoTemplateSubstitution{
+			{Line: 2, Column: 9, Value: "John Smith", Expr: ".name"},
+			{Line: 3, Column: 10, Value: "js@example.com", Expr: ".email"},
+			{Line: 4, Column: 7, Value: "42", Expr: ".id"},
+		}, substitutions)
+	})
+
+	t.Run("min_match_length", func(t *testing.T) {
+		actual, substitutions, err := autoTemplate(contents, data, &AutoTemplateOptions{
+			MinMatchLength: 3,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "[user]\n\tname = {{ .name }}\n\temail = {{ .email }}\n\tid = 42\n", string(actual))
+		assert.Len(t, substitutions, 2)
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		var offered []string
+		actual, substitutions, err := autoTemplate(contents, data, &AutoTemplateOptions{
+			Accept: func(substitution *AutoTemplateSubstitution) (bool, error) {
+				offered = append(offered, substitution.Expr)
+				return substitution.Expr != ".name", nil
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "[user]\n\tname = John Smith\n\temail = {{ .email }}\n\tid = {{ .id }}\n", string(actual))
+		assert.Equal(t, []string{".name", ".email", ".id"}, offered)
+		assert.Len(t, substitutions, 2)
+	})
+
+	t.Run("accept_error", func(t *testing.T) {
+		_, _, err := autoTemplate(contents, data, &AutoTemplateOptions{
+			Accept: func(*AutoTemplateSubstitution) (bool, error) {
+				return false, errors.New("quit")
+			},
+		})
+		assert.EqualError(t, err, "quit")
+	})
+
+	t.Run("templates", func(t *testing.T) {
+		actual, _, err := autoTemplate(contents, data, &AutoTemplateOptions{
+			Accept: func(*AutoTemplateSubstitution) (bool, error) {
+				return true, nil
+			},
+			Templates: map[string]string{
+				"js@example.com": `pass "email"`,
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "[user]\n\tname = {{ .name }}\n\temail = {{ pass \"email\" }}\n\tid = {{ .id }}\n", string(actual))
+	})
+}
+
 func TestInWord(t *testing.T) {
 	for _, tc := range []struct {
 		s    string
@@ -67,6 +67,22 @@ func (b *BoltPersistentState) Delete(bucket, key []byte) error {
 	})
 }
 
+// ForEach calls fn for each key and value in bucket, in key order. If bucket
+// does not exist then ForEach does nothing. The slices passed to fn are only
+// valid until fn returns.
+func (b *BoltPersistentState) ForEach(bucket []byte, fn func(k, v []byte) error) error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(fn)
+	})
+}
+
 // Get returns the value associated with key in bucket.
 func (b *BoltPersistentState) Get(bucket, key []byte) ([]byte, error) {
 	var value []byte
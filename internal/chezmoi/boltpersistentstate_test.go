@@ -1,6 +1,7 @@
 package chezmoi
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -118,3 +119,30 @@ func TestBoltPersistentStateReadOnly(t *testing.T) {
 	require.NoError(t, b.Close())
 	require.NoError(t, c.Close())
 }
+
+func TestBoltPersistentStateForEach(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.config/chezmoi": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	b, err := NewBoltPersistentState(fs, "/home/user/.config/chezmoi/chezmoistate.boltdb", vfst.DefaultUmask, nil)
+	require.NoError(t, err)
+	defer b.Close()
+
+	bucket := []byte("bucket")
+	require.NoError(t, b.ForEach(bucket, func(k, v []byte) error {
+		return fmt.Errorf("unexpected key %q", k)
+	}))
+
+	require.NoError(t, b.Set(bucket, []byte("b"), []byte("2")))
+	require.NoError(t, b.Set(bucket, []byte("a"), []byte("1")))
+
+	var actual []string
+	require.NoError(t, b.ForEach(bucket, func(k, v []byte) error {
+		actual = append(actual, string(k)+"="+string(v))
+		return nil
+	}))
+	assert.Equal(t, []string{"a=1", "b=2"}, actual)
+}
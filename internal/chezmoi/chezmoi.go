@@ -3,11 +3,14 @@ package chezmoi
 import (
 	"archive/tar"
 	"bytes"
+	"crypto/sha256"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	vfs "github.com/twpayne/go-vfs"
 )
@@ -26,25 +29,44 @@ const (
 	TemplateSuffix   = ".tmpl"
 )
 
+// A Mode is a mode of operation.
+type Mode string
+
+// Modes.
+const (
+	ModeFile    Mode = "file"
+	ModeSymlink Mode = "symlink"
+)
+
 // A PersistentState is an interface to a persistent state.
 type PersistentState interface {
 	Close() error
 	Delete(bucket, key []byte) error
+	ForEach(bucket []byte, fn func(k, v []byte) error) error
 	Get(bucket, key []byte) ([]byte, error)
 	Set(bucket, key, value []byte) error
 }
 
 // An ApplyOptions is a big ball of mud for things that affect Entry.Apply.
 type ApplyOptions struct {
-	DestDir           string
-	DryRun            bool
-	Ignore            func(string) bool
-	PersistentState   PersistentState
-	Remove            bool
-	ScriptStateBucket []byte
-	Stdout            io.Writer
-	Umask             os.FileMode
-	Verbose           bool
+	ConfirmScript      func(name string, contents []byte) (bool, error)
+	DestDir            string
+	DryRun             bool
+	HistoryBucket      []byte
+	Ignore             func(string) bool
+	Interpreters       map[string]Interpreter
+	Mode               Mode
+	PersistentState    PersistentState
+	RecordScriptOutput bool
+	Redactor           *Redactor
+	Remove             bool
+	ScriptStateBucket  []byte
+	ScriptTimeout      time.Duration
+	SourceDir          string
+	Stdout             io.Writer
+	TemplateData       map[string]interface{}
+	Umask              os.FileMode
+	Verbose            bool
 }
 
 // An Entry is either a Dir, a File, or a Symlink.
@@ -63,6 +85,30 @@ type parsedSourceFilePath struct {
 	scriptAttributes *ScriptAttributes
 }
 
+// TargetName returns the target name of the source file or directory relPath,
+// which is relative to the source directory. It returns an error if relPath, or
+// any of its parent directories, is a special file or directory, i.e. begins
+// with a ".".
+func TargetName(relPath string, isDir bool) (string, error) {
+	components := splitPathList(relPath)
+	for _, component := range components {
+		if strings.HasPrefix(component, ".") {
+			return "", fmt.Errorf("%s: not a source file", relPath)
+		}
+	}
+	if isDir {
+		return filepath.Join(dirNames(parseDirNameComponents(components))...), nil
+	}
+	psfp := parseSourceFilePath(relPath)
+	name := ""
+	if psfp.scriptAttributes != nil {
+		name = psfp.scriptAttributes.Name
+	} else {
+		name = psfp.fileAttributes.Name
+	}
+	return filepath.Join(append(dirNames(psfp.dirAttributes), name)...), nil
+}
+
 // dirNames returns the dir names from dirAttributes.
 func dirNames(dirAttributes []DirAttributes) []string {
 	dns := make([]string, len(dirAttributes))
@@ -106,6 +152,21 @@ func parseSourceFilePath(path string) parsedSourceFilePath {
 	}
 }
 
+// sha256File returns the SHA256 sum of the contents of path in fs, reading the
+// contents as a stream.
+func sha256File(fs vfs.FS, path string) ([]byte, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
 // sortedEntryNames returns a sorted slice of all entry names.
 func sortedEntryNames(entries map[string]Entry) []string {
 	entryNames := []string{}
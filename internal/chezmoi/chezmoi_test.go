@@ -2,6 +2,7 @@ package chezmoi
 
 import (
 	"errors"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -30,3 +31,31 @@ func TestReturnTemplateError(t *testing.T) {
 		})
 	}
 }
+
+func TestTargetName(t *testing.T) {
+	for _, tc := range []struct {
+		relPath string
+		isDir   bool
+		want    string
+		wantErr bool
+	}{
+		{relPath: "dot_bashrc", want: ".bashrc"},
+		{relPath: "exact_private_dot_dir", isDir: true, want: ".dir"},
+		{relPath: filepath.Join("private_dot_ssh", "encrypted_private_id_rsa.tmpl"), want: filepath.Join(".ssh", "id_rsa")},
+		{relPath: filepath.Join("dot_dir", "symlink_dot_link.tmpl"), want: filepath.Join(".dir", ".link")},
+		{relPath: "run_once_install.sh.tmpl", want: "install.sh"},
+		{relPath: ".chezmoiignore", wantErr: true},
+		{relPath: filepath.Join("dot_dir", ".chezmoiignore"), wantErr: true},
+		{relPath: filepath.Join(".chezmoitemplates", "foo"), wantErr: true},
+	} {
+		t.Run(tc.relPath, func(t *testing.T) {
+			actual, err := TargetName(tc.relPath, tc.isDir)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, actual)
+		})
+	}
+}
@@ -2,11 +2,16 @@ package chezmoi
 
 import (
 	"archive/tar"
+	"bufio"
 	"bytes"
+	"crypto/sha256"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode"
 
 	vfs "github.com/twpayne/go-vfs"
 )
@@ -30,7 +35,17 @@ type File struct {
 	Template         bool
 	contents         []byte
 	contentsErr      error
+	contentsSummary  *fileContentsSummary
 	evaluateContents func() ([]byte, error)
+	openContents     func() (io.ReadCloser, int64, error)
+}
+
+// A fileContentsSummary summarizes a file's contents without holding them in
+// memory.
+type fileContentsSummary struct {
+	size   int64
+	sha256 []byte
+	empty  bool
 }
 
 type fileConcreteValue struct {
@@ -130,11 +145,14 @@ func (f *File) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Appl
 	if applyOptions.Ignore(f.targetName) {
 		return nil
 	}
-	contents, err := f.Contents()
+	summary, err := f.summarizeContents()
 	if err != nil {
 		return err
 	}
 	targetPath := filepath.Join(applyOptions.DestDir, f.targetName)
+	if applyOptions.Mode == ModeSymlink && f.symlinkable() && (!summary.empty || f.Empty) {
+		return f.applySymlink(fs, mutator, targetPath, applyOptions)
+	}
 	var info os.FileInfo
 	if follow {
 		info, err = fs.Stat(targetPath)
@@ -144,14 +162,20 @@ func (f *File) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Appl
 	var currData []byte
 	switch {
 	case err == nil && info.Mode().IsRegular():
-		if isEmpty(contents) && !f.Empty {
+		if summary.empty && !f.Empty {
 			return mutator.RemoveAll(targetPath)
 		}
-		currData, err = fs.ReadFile(targetPath)
+		// Compare sizes and then hashes, so that targets which are already
+		// in the target state are streamed rather than read into memory.
+		equal, err := targetContentsEqual(fs, targetPath, info, summary)
 		if err != nil {
 			return err
 		}
-		if !bytes.Equal(currData, contents) {
+		if !equal {
+			currData, err = fs.ReadFile(targetPath)
+			if err != nil {
+				return err
+			}
 			break
 		}
 		if info.Mode().Perm() != f.Perm&^applyOptions.Umask {
@@ -168,9 +192,13 @@ func (f *File) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Appl
 	default:
 		return err
 	}
-	if isEmpty(contents) && !f.Empty {
+	if summary.empty && !f.Empty {
 		return nil
 	}
+	contents, err := f.Contents()
+	if err != nil {
+		return err
+	}
 	return mutator.WriteFile(targetPath, contents, f.Perm&^applyOptions.Umask, currData)
 }
 
@@ -200,10 +228,20 @@ func (f *File) Contents() ([]byte, error) {
 	if f.evaluateContents != nil {
 		f.contents, f.contentsErr = f.evaluateContents()
 		f.evaluateContents = nil
+		f.openContents = nil
 	}
 	return f.contents, f.contentsErr
 }
 
+// ContentsSHA256 returns the SHA256 sum of f's contents.
+func (f *File) ContentsSHA256() ([]byte, error) {
+	summary, err := f.summarizeContents()
+	if err != nil {
+		return nil, err
+	}
+	return summary.sha256, nil
+}
+
 // Evaluate evaluates f's contents.
 func (f *File) Evaluate(ignore func(string) bool) error {
 	if ignore(f.targetName) {
@@ -218,6 +256,20 @@ func (f *File) Executable() bool {
 	return f.Perm&0111 != 0
 }
 
+// Open returns a reader of f's contents and the size of f's contents. If f's
+// contents have not yet been evaluated and can be read directly from the source
+// state then they are streamed, otherwise they are evaluated.
+func (f *File) Open() (io.ReadCloser, int64, error) {
+	if f.openContents != nil {
+		return f.openContents()
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, 0, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(contents)), int64(len(contents)), nil
+}
+
 // Private returns true if f is private.
 func (f *File) Private() bool {
 	return f.Perm&077 == 0
@@ -233,26 +285,118 @@ func (f *File) TargetName() string {
 	return f.targetName
 }
 
+// symlinkable returns true if f's target can be a symlink to its source file,
+// i.e. if the source file's contents are exactly the target contents and the
+// target permissions do not need to differ from the source file's.
+func (f *File) symlinkable() bool {
+	return !f.Encrypted && !f.Template && !f.Executable() && !f.Private()
+}
+
+// applySymlink ensures that targetPath in fs is a symlink to f's source file.
+func (f *File) applySymlink(fs vfs.FS, mutator Mutator, targetPath string, applyOptions *ApplyOptions) error {
+	sourcePath := filepath.Join(applyOptions.SourceDir, f.sourceName)
+	info, err := fs.Lstat(targetPath)
+	switch {
+	case err == nil && info.Mode()&os.ModeType == os.ModeSymlink:
+		// Compare the files themselves rather than the link names so that
+		// equivalent relative and absolute symlinks are both considered
+		// correct.
+		targetInfo, err := fs.Stat(targetPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		sourceInfo, err := fs.Stat(sourcePath)
+		if err != nil {
+			return err
+		}
+		if targetInfo != nil && os.SameFile(targetInfo, sourceInfo) {
+			return nil
+		}
+	case err == nil && info.IsDir():
+		if err := mutator.RemoveAll(targetPath); err != nil {
+			return err
+		}
+	case err == nil:
+	case os.IsNotExist(err):
+	default:
+		return err
+	}
+	return mutator.WriteSymlink(sourcePath, targetPath)
+}
+
 // archive writes f to w.
 func (f *File) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
 	if ignore(f.targetName) {
 		return nil
 	}
-	contents, err := f.Contents()
+	r, size, err := f.Open()
 	if err != nil {
 		return err
 	}
-	if len(contents) == 0 && !f.Empty {
+	defer r.Close()
+	if size == 0 && !f.Empty {
 		return nil
 	}
 	header := *headerTemplate
 	header.Typeflag = tar.TypeReg
 	header.Name = f.targetName
-	header.Size = int64(len(contents))
+	header.Size = size
 	header.Mode = int64(f.Perm &^ umask)
 	if err := w.WriteHeader(&header); err != nil {
 		return nil
 	}
-	_, err = w.Write(contents)
+	_, err = io.Copy(w, r)
 	return err
 }
+
+// summarizeContents returns a summary of f's contents. The contents are read
+// with Open, so they are streamed if possible.
+func (f *File) summarizeContents() (*fileContentsSummary, error) {
+	if f.contentsSummary != nil {
+		return f.contentsSummary, nil
+	}
+	r, size, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	h := sha256.New()
+	br := bufio.NewReader(io.TeeReader(r, h))
+	// The contents are empty if they contain only whitespace, which can
+	// usually be determined from the first rune.
+	empty := true
+	for {
+		c, _, err := br.ReadRune()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if !unicode.IsSpace(c) {
+			empty = false
+			break
+		}
+	}
+	if _, err := io.Copy(ioutil.Discard, br); err != nil {
+		return nil, err
+	}
+	f.contentsSummary = &fileContentsSummary{
+		size:   size,
+		sha256: h.Sum(nil),
+		empty:  empty,
+	}
+	return f.contentsSummary, nil
+}
+
+// targetContentsEqual returns true if the contents of targetPath in fs, whose
+// info is info, have the size and SHA256 sum in summary.
+func targetContentsEqual(fs vfs.FS, targetPath string, info os.FileInfo, summary *fileContentsSummary) (bool, error) {
+	if info.Size() != summary.size {
+		return false, nil
+	}
+	targetSHA256, err := sha256File(fs, targetPath)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(targetSHA256, summary.sha256), nil
+}
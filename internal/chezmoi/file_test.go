@@ -1,10 +1,17 @@
 package chezmoi
 
 import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
 )
 
 func TestFileAttributes(t *testing.T) {
@@ -121,3 +128,134 @@ func TestFileAttributes(t *testing.T) {
 		})
 	}
 }
+
+// newStreamingTestFile returns a new File whose contents are contents, counting
+// the number of times that its contents are evaluated in evaluations.
+func newStreamingTestFile(contents string, evaluations *int) *File {
+	return &File{
+		sourceName: "dot_file",
+		targetName: ".file",
+		Perm:       0644,
+		evaluateContents: func() ([]byte, error) {
+			*evaluations++
+			return []byte(contents), nil
+		},
+		openContents: func() (io.ReadCloser, int64, error) {
+			return ioutil.NopCloser(strings.NewReader(contents)), int64(len(contents)), nil
+		},
+	}
+}
+
+func TestFileOpen(t *testing.T) {
+	evaluations := 0
+	f := newStreamingTestFile("contents", &evaluations)
+
+	r, size, err := f.Open()
+	require.NoError(t, err)
+	actual, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "contents", string(actual))
+	assert.Equal(t, int64(8), size)
+	assert.Equal(t, 0, evaluations)
+
+	// Once the contents are evaluated, they are read from memory.
+	contents, err := f.Contents()
+	require.NoError(t, err)
+	assert.Equal(t, "contents", string(contents))
+	assert.Equal(t, 1, evaluations)
+	r, size, err = f.Open()
+	require.NoError(t, err)
+	actual, err = ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "contents", string(actual))
+	assert.Equal(t, int64(8), size)
+	assert.Equal(t, 1, evaluations)
+}
+
+func TestFileApplyStreaming(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		contents            string
+		targetContents      string
+		expectedEvaluations int
+	}{
+		{
+			name:                "equal",
+			contents:            "contents\n",
+			targetContents:      "contents\n",
+			expectedEvaluations: 0,
+		},
+		{
+			name:                "different_size",
+			contents:            "contents\n",
+			targetContents:      "other contents\n",
+			expectedEvaluations: 1,
+		},
+		{
+			name:                "same_size_different_hash",
+			contents:            "contents\n",
+			targetContents:      "CONTENTS\n",
+			expectedEvaluations: 1,
+		},
+		{
+			name:                "empty",
+			contents:            " \n",
+			targetContents:      "contents\n",
+			expectedEvaluations: 0,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+				"/home/user/.file": &vfst.File{
+					Perm:     0644,
+					Contents: []byte(tc.targetContents),
+				},
+			})
+			require.NoError(t, err)
+			defer cleanup()
+
+			evaluations := 0
+			f := newStreamingTestFile(tc.contents, &evaluations)
+			require.NoError(t, f.Apply(fs, NewFSMutator(fs), false, &ApplyOptions{
+				DestDir: "/home/user",
+				Ignore:  func(string) bool { return false },
+				Umask:   022,
+			}))
+			assert.Equal(t, tc.expectedEvaluations, evaluations)
+			if strings.TrimSpace(tc.contents) == "" {
+				vfst.RunTests(t, fs, "",
+					vfst.TestPath("/home/user/.file",
+						vfst.TestDoesNotExist,
+					),
+				)
+				return
+			}
+			vfst.RunTests(t, fs, "",
+				vfst.TestPath("/home/user/.file",
+					vfst.TestModeIsRegular,
+					vfst.TestContentsString(tc.contents),
+				),
+			)
+		})
+	}
+}
+
+func TestFileArchiveStreaming(t *testing.T) {
+	evaluations := 0
+	f := newStreamingTestFile("contents\n", &evaluations)
+	b := &bytes.Buffer{}
+	w := tar.NewWriter(b)
+	require.NoError(t, f.archive(w, func(string) bool { return false }, &tar.Header{}, 022))
+	require.NoError(t, w.Close())
+	assert.Equal(t, 0, evaluations)
+
+	r := tar.NewReader(b)
+	header, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, ".file", header.Name)
+	assert.Equal(t, int64(9), header.Size)
+	actual, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "contents\n", string(actual))
+}
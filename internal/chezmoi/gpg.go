@@ -72,7 +72,7 @@ func (g *GPG) Encrypt(filename string, plaintext []byte) ([]byte, error) {
 		}
 		args = append(args, "--encrypt")
 	}
-	args = append(args, filename)
+	args = append(args, inputFilename)
 	cmd := exec.Command("gpg", args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
@@ -0,0 +1,123 @@
+package chezmoi
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Types of history entries.
+const (
+	HistoryTypeApply  = "apply"
+	HistoryTypeScript = "script"
+)
+
+// MaxHistoryEntries is the maximum number of history entries kept.
+const MaxHistoryEntries = 1000
+
+// historyKeyFormat is the format of history keys. It sorts lexically in
+// chronological order.
+const historyKeyFormat = "2006-01-02T15:04:05.000000000Z"
+
+// A HistoryEntry records a script run or an apply.
+type HistoryEntry struct {
+	Type      string        `json:"type"`
+	Name      string        `json:"name"`
+	StartedAt time.Time     `json:"startedAt"`
+	Duration  time.Duration `json:"duration"`
+	ExitCode  int           `json:"exitCode"`
+	TimedOut  bool          `json:"timedOut,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Stdout    string        `json:"stdout,omitempty"`
+	Stderr    string        `json:"stderr,omitempty"`
+}
+
+// AddHistoryEntry adds entry to bucket in persistentState, removing the oldest
+// entries so that at most MaxHistoryEntries are kept.
+func AddHistoryEntry(persistentState PersistentState, bucket []byte, entry *HistoryEntry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	key := []byte(entry.StartedAt.UTC().Format(historyKeyFormat))
+	// Ensure that keys are unique even if two entries start at the same time.
+	for {
+		existingValue, err := persistentState.Get(bucket, key)
+		if err != nil {
+			return err
+		}
+		if existingValue == nil {
+			break
+		}
+		key = append(key, '+')
+	}
+	if err := persistentState.Set(bucket, key, value); err != nil {
+		return err
+	}
+
+	var keys [][]byte
+	if err := persistentState.ForEach(bucket, func(k, _ []byte) error {
+		keys = append(keys, append([]byte(nil), k...))
+		return nil
+	}); err != nil {
+		return err
+	}
+	for len(keys) > MaxHistoryEntries {
+		if err := persistentState.Delete(bucket, keys[0]); err != nil {
+			return err
+		}
+		keys = keys[1:]
+	}
+	return nil
+}
+
+// HistoryEntries returns all the history entries in bucket in persistentState,
+// oldest first.
+func HistoryEntries(persistentState PersistentState, bucket []byte) ([]*HistoryEntry, error) {
+	var entries []*HistoryEntry
+	if err := persistentState.ForEach(bucket, func(_, v []byte) error {
+		var entry HistoryEntry
+		if err := json.Unmarshal(v, &entry); err != nil {
+			return err
+		}
+		entries = append(entries, &entry)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// A limitedBuffer is a bytes.Buffer that silently discards writes beyond a
+// maximum size. It is safe for concurrent use.
+type limitedBuffer struct {
+	mutex     sync.Mutex
+	buffer    bytes.Buffer
+	maxSize   int
+	truncated bool
+}
+
+// Write implements io.Writer.Write.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if n := b.maxSize - b.buffer.Len(); n < len(p) {
+		b.truncated = true
+		if n > 0 {
+			_, _ = b.buffer.Write(p[:n])
+		}
+		return len(p), nil
+	}
+	return b.buffer.Write(p)
+}
+
+// String returns the contents of b, with a marker if it was truncated.
+func (b *limitedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.truncated {
+		return b.buffer.String() + "\n[output truncated]\n"
+	}
+	return b.buffer.String()
+}
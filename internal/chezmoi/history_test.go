@@ -0,0 +1,98 @@
+package chezmoi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestHistory(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.config/chezmoi": &vfst.Dir{Perm: 0755},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	p, err := NewBoltPersistentState(fs, "/home/user/.config/chezmoi/chezmoistate.boltdb", vfst.DefaultUmask, nil)
+	require.NoError(t, err)
+	defer p.Close()
+
+	bucket := []byte("history")
+	entries, err := HistoryEntries(p, bucket)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	startedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := []*HistoryEntry{
+		{
+			Type:      HistoryTypeScript,
+			Name:      "run_foo",
+			StartedAt: startedAt,
+			Duration:  time.Second,
+			Stdout:    "foo\n",
+		},
+		{
+			Type:      HistoryTypeScript,
+			Name:      "run_bar",
+			StartedAt: startedAt,
+			ExitCode:  1,
+			Error:     "exit status 1",
+		},
+		{
+			Type:      HistoryTypeApply,
+			Name:      "apply",
+			StartedAt: startedAt.Add(time.Minute),
+		},
+	}
+	for _, entry := range want {
+		require.NoError(t, AddHistoryEntry(p, bucket, entry))
+	}
+
+	entries, err = HistoryEntries(p, bucket)
+	require.NoError(t, err)
+	assert.Equal(t, want, entries)
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{maxSize: 4}
+	for _, s := range []string{"ab", "cd", "ef"} {
+		n, err := b.Write([]byte(s))
+		require.NoError(t, err)
+		assert.Equal(t, len(s), n)
+	}
+	assert.Equal(t, "abcd\n[output truncated]\n", b.String())
+}
+
+func TestParseScriptTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		contents    string
+		wantTimeout time.Duration
+		wantOK      bool
+		wantErr     bool
+	}{
+		{
+			contents: "#!/bin/sh\n",
+		},
+		{
+			contents:    "#!/bin/sh\n# chezmoi:timeout=30s\n",
+			wantTimeout: 30 * time.Second,
+			wantOK:      true,
+		},
+		{
+			contents: "#!/bin/sh\n# chezmoi:timeout=forever\n",
+			wantErr:  true,
+		},
+	} {
+		timeout, ok, err := parseScriptTimeout([]byte(tc.contents))
+		if tc.wantErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, tc.wantTimeout, timeout)
+		assert.Equal(t, tc.wantOK, ok)
+	}
+}
@@ -0,0 +1,230 @@
+package chezmoi
+
+import (
+	"encoding/json"
+	"sync"
+	"text/template"
+	"text/template/parse"
+)
+
+// A LazyValue is a template data value that is only computed when it is
+// needed, typically because a template references it. It is computed at most
+// once.
+type LazyValue struct {
+	once   sync.Once
+	f      func() (interface{}, error)
+	secret bool
+	value  interface{}
+	err    error
+}
+
+// A dataRefs records references to template data. If all is true then the
+// whole value is referenced, otherwise only the values in keys are.
+type dataRefs struct {
+	all  bool
+	keys map[string]*dataRefs
+}
+
+// allDataRefs references all template data.
+var allDataRefs = &dataRefs{all: true}
+
+// NewLazyValue returns a new LazyValue whose value is computed by f.
+func NewLazyValue(f func() (interface{}, error)) *LazyValue {
+	return &LazyValue{
+		f: f,
+	}
+}
+
+// NewSecretLazyValue returns a new LazyValue whose value is computed by f and
+// is secret, for example because it was decrypted. The outputs of templates
+// that reference secret values are treated like those of encrypted templates.
+func NewSecretLazyValue(f func() (interface{}, error)) *LazyValue {
+	return &LazyValue{
+		f:      f,
+		secret: true,
+	}
+}
+
+// Get returns v's value, computing it if needed.
+func (v *LazyValue) Get() (interface{}, error) {
+	v.once.Do(func() {
+		v.value, v.err = v.f()
+	})
+	return v.value, v.err
+}
+
+// MarshalJSON implements encoding/json.Marshaler.
+func (v *LazyValue) MarshalJSON() ([]byte, error) {
+	value, err := v.Get()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// ResolveTemplateData returns a copy of data with the LazyValues referenced by
+// tmpl replaced by their values and all other LazyValues removed. If tmpl is
+// nil then all LazyValues are replaced by their values.
+func ResolveTemplateData(data map[string]interface{}, tmpl *template.Template) (map[string]interface{}, error) {
+	resolvedData, _, err := resolveTemplateData(data, tmpl)
+	return resolvedData, err
+}
+
+// resolveTemplateData is like ResolveTemplateData but also returns whether any
+// secret LazyValues were resolved.
+func resolveTemplateData(data map[string]interface{}, tmpl *template.Template) (map[string]interface{}, bool, error) {
+	refs := allDataRefs
+	if tmpl != nil && tmpl.Tree != nil {
+		refs = &dataRefs{}
+		walkDataReferences(tmpl.Tree.Root, true, func(_ parse.Node, keys []string) {
+			refs.add(keys)
+		})
+	}
+	secret := false
+	value, _, err := resolveLazyValues(data, refs, &secret)
+	if err != nil {
+		return nil, false, err
+	}
+	resolvedData, _ := value.(map[string]interface{})
+	return resolvedData, secret, nil
+}
+
+// add records a reference to the value at keys.
+func (r *dataRefs) add(keys []string) {
+	for _, key := range keys {
+		if r.all {
+			return
+		}
+		if r.keys == nil {
+			r.keys = make(map[string]*dataRefs)
+		}
+		child, ok := r.keys[key]
+		if !ok {
+			child = &dataRefs{}
+			r.keys[key] = child
+		}
+		r = child
+	}
+	r.all = true
+	r.keys = nil
+}
+
+// get returns the references to the value at key, or nil if there are none.
+func (r *dataRefs) get(key string) *dataRefs {
+	switch {
+	case r == nil:
+		return nil
+	case r.all:
+		return r
+	default:
+		return r.keys[key]
+	}
+}
+
+// resolveLazyValues returns value with the LazyValues referenced by refs
+// replaced by their values and all other LazyValues removed. It returns false
+// if value itself should be removed. It sets *secret to true if any secret
+// LazyValues are resolved.
+func resolveLazyValues(value interface{}, refs *dataRefs, secret *bool) (interface{}, bool, error) {
+	switch value := value.(type) {
+	case *LazyValue:
+		if refs == nil {
+			return nil, false, nil
+		}
+		v, err := value.Get()
+		if err != nil {
+			return nil, false, err
+		}
+		if value.secret {
+			*secret = true
+		}
+		return resolveLazyValues(v, refs, secret)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			resolvedValue, ok, err := resolveLazyValues(v, refs.get(k), secret)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				result[k] = resolvedValue
+			}
+		}
+		return result, true, nil
+	default:
+		return value, true, nil
+	}
+}
+
+// resolvedTemplateData returns a copy of data with all non-secret LazyValues
+// replaced by their values and all secret LazyValues removed. Unlike
+// ResolveTemplateData, it never computes secret values, so it never decrypts
+// secrets, and its result does not depend on which values templates have
+// already referenced.
+func resolvedTemplateData(data map[string]interface{}) (map[string]interface{}, error) {
+	value, _, err := resolveNonSecretValues(data)
+	if err != nil {
+		return nil, err
+	}
+	resolvedData, _ := value.(map[string]interface{})
+	return resolvedData, nil
+}
+
+// resolveNonSecretValues returns value with the non-secret LazyValues replaced
+// by their values and all secret LazyValues removed. It returns false if value
+// itself should be removed.
+func resolveNonSecretValues(value interface{}) (interface{}, bool, error) {
+	switch value := value.(type) {
+	case *LazyValue:
+		if value.secret {
+			return nil, false, nil
+		}
+		v, err := value.Get()
+		if err != nil {
+			return nil, false, err
+		}
+		return resolveNonSecretValues(v)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			resolvedValue, ok, err := resolveNonSecretValues(v)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				result[k] = resolvedValue
+			}
+		}
+		return result, true, nil
+	default:
+		return value, true, nil
+	}
+}
+
+// RedactTemplateData returns a copy of data with all secret LazyValues
+// replaced by RedactedText. Secret values are never computed, so they are
+// never decrypted.
+func RedactTemplateData(data map[string]interface{}) map[string]interface{} {
+	redactedData, _ := redactSecretValues(data).(map[string]interface{})
+	return redactedData
+}
+
+// redactSecretValues returns value with all secret LazyValues replaced by
+// RedactedText.
+func redactSecretValues(value interface{}) interface{} {
+	switch value := value.(type) {
+	case *LazyValue:
+		if value.secret {
+			return RedactedText
+		}
+		return value
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			result[k] = redactSecretValues(v)
+		}
+		return result
+	default:
+		return value
+	}
+}
@@ -0,0 +1,164 @@
+package chezmoi
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveTemplateData(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		text             string
+		expectedComputed []string
+		expectedOutput   string
+	}{
+		{
+			name:             "no_references",
+			text:             "{{ .chezmoi.os }}",
+			expectedComputed: []string{},
+			expectedOutput:   "linux",
+		},
+		{
+			name:             "field",
+			text:             "{{ .chezmoi.cpuCount }}",
+			expectedComputed: []string{"cpuCount"},
+			expectedOutput:   "4",
+		},
+		{
+			name:             "variable",
+			text:             "{{ range .list }}{{ $.chezmoi.wsl }}{{ end }}",
+			expectedComputed: []string{"wsl"},
+			expectedOutput:   "falsefalse",
+		},
+		{
+			name:             "nested",
+			text:             "{{ .chezmoi.memory.total }}",
+			expectedComputed: []string{"memory"},
+			expectedOutput:   "1024",
+		},
+		{
+			name:             "whole_map",
+			text:             "{{ range $key, $value := .chezmoi }}{{ $key }}{{ end }}",
+			expectedComputed: []string{"cpuCount", "memory", "wsl"},
+			expectedOutput:   "cpuCountmemoryoswsl",
+		},
+		{
+			name:             "dot",
+			text:             "{{ template \"t\" . }}{{ define \"t\" }}{{ .chezmoi.os }}{{ end }}",
+			expectedComputed: []string{"cpuCount", "memory", "wsl"},
+			expectedOutput:   "linux",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			computed := []string{}
+			lazyValue := func(name string, value interface{}) *LazyValue {
+				return NewLazyValue(func() (interface{}, error) {
+					computed = append(computed, name)
+					return value, nil
+				})
+			}
+			data := map[string]interface{}{
+				"chezmoi": map[string]interface{}{
+					"cpuCount": lazyValue("cpuCount", 4),
+					"memory": lazyValue("memory", map[string]interface{}{
+						"total": 1024,
+					}),
+					"os":  "linux",
+					"wsl": lazyValue("wsl", false),
+				},
+				"list": []interface{}{1, 2},
+			}
+			tmpl, err := template.New(tc.name).Option("missingkey=error").Parse(tc.text)
+			require.NoError(t, err)
+
+			// Resolve the data twice to check that each value is only
+			// computed once.
+			for i := 0; i < 2; i++ {
+				resolvedData, err := ResolveTemplateData(data, tmpl)
+				require.NoError(t, err)
+				sb := &strings.Builder{}
+				require.NoError(t, tmpl.Execute(sb, resolvedData))
+				assert.Equal(t, tc.expectedOutput, sb.String())
+			}
+			sort.Strings(computed)
+			assert.Equal(t, tc.expectedComputed, computed)
+		})
+	}
+}
+
+func TestResolveTemplateDataAll(t *testing.T) {
+	data := map[string]interface{}{
+		"chezmoi": map[string]interface{}{
+			"cpuCount": NewLazyValue(func() (interface{}, error) {
+				return 4, nil
+			}),
+		},
+	}
+	resolvedData, err := ResolveTemplateData(data, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"chezmoi": map[string]interface{}{
+			"cpuCount": 4,
+		},
+	}, resolvedData)
+}
+
+func TestResolvedTemplateData(t *testing.T) {
+	data := map[string]interface{}{
+		"chezmoi": map[string]interface{}{
+			"os": "linux",
+			// Values are computed even if no template referenced them.
+			"shell": NewLazyValue(func() (interface{}, error) {
+				return "zsh", nil
+			}),
+		},
+		"password": NewSecretLazyValue(func() (interface{}, error) {
+			t.Fatal("secret value computed")
+			return nil, nil
+		}),
+	}
+	resolvedData, err := resolvedTemplateData(data)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"chezmoi": map[string]interface{}{
+			"os":    "linux",
+			"shell": "zsh",
+		},
+	}, resolvedData)
+
+	_, err = resolvedTemplateData(map[string]interface{}{
+		"failed": NewLazyValue(func() (interface{}, error) {
+			return nil, assert.AnError
+		}),
+	})
+	assert.Error(t, err)
+}
+
+func TestRedactTemplateData(t *testing.T) {
+	data := map[string]interface{}{
+		"chezmoi": map[string]interface{}{
+			"os": "linux",
+			"shell": NewLazyValue(func() (interface{}, error) {
+				return "zsh", nil
+			}),
+		},
+		"password": NewSecretLazyValue(func() (interface{}, error) {
+			t.Fatal("secret value computed")
+			return nil, nil
+		}),
+	}
+	resolvedData, err := ResolveTemplateData(RedactTemplateData(data), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"chezmoi": map[string]interface{}{
+			"os":    "linux",
+			"shell": "zsh",
+		},
+		"password": RedactedText,
+	}, resolvedData)
+}
@@ -0,0 +1,151 @@
+package chezmoi
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/diff"
+)
+
+// A LineDiff is a line-by-line diff between two versions of a file, split into
+// hunks that can be applied individually.
+type LineDiff struct {
+	lines  *diffLines
+	ranges []diff.IndexRanges
+	hunks  [][]diff.IndexRanges
+}
+
+// diffLines is a diff.PairWriterTo over lines that include their line
+// terminators. Lines are compared including their terminators, but written
+// without them.
+type diffLines struct {
+	a, b []string
+}
+
+// NewLineDiff returns a new LineDiff between a and b with contextSize lines of
+// context around each hunk.
+func NewLineDiff(a, b []byte, contextSize int) *LineDiff {
+	lines := &diffLines{
+		a: splitLinesKeepEnds(a),
+		b: splitLinesKeepEnds(b),
+	}
+	e := diff.Myers(context.Background(), lines)
+
+	// Group the ranges of the diff with context into hunks in the same way as
+	// diff.EditScript.WriteUnified, i.e. a new hunk starts at each
+	// discontinuity.
+	var hunks [][]diff.IndexRanges
+	var hunk []diff.IndexRanges
+	for _, r := range e.WithContextSize(contextSize).IndexRanges {
+		if len(hunk) != 0 {
+			if prev := hunk[len(hunk)-1]; prev.HighA != r.LowA || prev.HighB != r.LowB {
+				hunks = append(hunks, hunk)
+				hunk = nil
+			}
+		}
+		hunk = append(hunk, r)
+	}
+	if len(hunk) != 0 {
+		hunks = append(hunks, hunk)
+	}
+
+	return &LineDiff{
+		lines:  lines,
+		ranges: e.IndexRanges,
+		hunks:  hunks,
+	}
+}
+
+// Apply returns the result of applying the hunks of d for which selected
+// returns true to a.
+func (d *LineDiff) Apply(selected func(int) bool) []byte {
+	// Index the hunk that contains each change by the change's start.
+	type position struct{ lowA, lowB int }
+	hunkIndexes := make(map[position]int)
+	for i, hunk := range d.hunks {
+		for _, r := range hunk {
+			if !r.IsEqual() {
+				hunkIndexes[position{r.LowA, r.LowB}] = i
+			}
+		}
+	}
+
+	b := &bytes.Buffer{}
+	for _, r := range d.ranges {
+		lines := d.lines.a[r.LowA:r.HighA]
+		if !r.IsEqual() && selected(hunkIndexes[position{r.LowA, r.LowB}]) {
+			lines = d.lines.b[r.LowB:r.HighB]
+		}
+		for _, line := range lines {
+			b.WriteString(line)
+		}
+	}
+	return b.Bytes()
+}
+
+// Hunks returns the number of hunks in d.
+func (d *LineDiff) Hunks() int {
+	return len(d.hunks)
+}
+
+// WriteHunk writes the ith hunk of d to w as a unified diff of name.
+func (d *LineDiff) WriteHunk(w io.Writer, i int, name string, colored bool) error {
+	_, err := diff.EditScript{IndexRanges: d.hunks[i]}.WriteUnified(w, d.lines, writeOpts(name, colored)...)
+	return err
+}
+
+// WriteUnified writes all the hunks of d to w as a unified diff of name.
+func (d *LineDiff) WriteUnified(w io.Writer, name string, colored bool) error {
+	var ranges []diff.IndexRanges
+	for _, hunk := range d.hunks {
+		ranges = append(ranges, hunk...)
+	}
+	_, err := diff.EditScript{IndexRanges: ranges}.WriteUnified(w, d.lines, writeOpts(name, colored)...)
+	return err
+}
+
+func (l *diffLines) LenA() int { return len(l.a) }
+func (l *diffLines) LenB() int { return len(l.b) }
+
+func (l *diffLines) Equal(ai, bi int) bool {
+	return l.a[ai] == l.b[bi]
+}
+
+func (l *diffLines) WriteATo(w io.Writer, ai int) (int, error) {
+	return io.WriteString(w, strings.TrimRight(l.a[ai], "\r\n"))
+}
+
+func (l *diffLines) WriteBTo(w io.Writer, bi int) (int, error) {
+	return io.WriteString(w, strings.TrimRight(l.b[bi], "\r\n"))
+}
+
+// splitLinesKeepEnds splits data into lines, including their line terminators.
+func splitLinesKeepEnds(data []byte) []string {
+	var lines []string
+	for len(data) != 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i == -1 {
+			i = len(data) - 1
+		}
+		lines = append(lines, string(data[:i+1]))
+		data = data[i+1:]
+	}
+	return lines
+}
+
+// writeOpts returns the options for writing a unified diff of name.
+func writeOpts(name string, colored bool) []diff.WriteOpt {
+	opts := []diff.WriteOpt{
+		diff.Names(
+			filepath.Join("a", name),
+			filepath.Join("b", name),
+		),
+	}
+	if colored {
+		opts = append(opts, diff.TerminalColor())
+	}
+	return opts
+}
@@ -0,0 +1,64 @@
+package chezmoi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineDiff(t *testing.T) {
+	a := []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12")
+	b := []byte("one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n")
+	d := NewLineDiff(a, b, 3)
+	require.Equal(t, 2, d.Hunks())
+
+	for _, tc := range []struct {
+		name     string
+		selected []bool
+		want     string
+	}{
+		{
+			name:     "none",
+			selected: []bool{false, false},
+			want:     string(a),
+		},
+		{
+			name:     "first",
+			selected: []bool{true, false},
+			want:     "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12",
+		},
+		{
+			name:     "second",
+			selected: []bool{false, true},
+			want:     "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+		},
+		{
+			name:     "all",
+			selected: []bool{true, true},
+			want:     string(b),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, string(d.Apply(func(i int) bool {
+				return tc.selected[i]
+			})))
+		})
+	}
+
+	w := &bytes.Buffer{}
+	require.NoError(t, d.WriteHunk(w, 0, "file", false))
+	assert.Equal(t, strings.Join([]string{
+		"--- a/file",
+		"+++ b/file",
+		"@@ -1,4 +1,4 @@",
+		"-1",
+		"+one",
+		" 2",
+		" 3",
+		" 4",
+		"",
+	}, "\n"), w.String())
+}
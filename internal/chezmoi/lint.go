@@ -0,0 +1,374 @@
+package chezmoi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/coreos/go-semver/semver"
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// lintExpectedNames are the names beginning with a . that are commonly found in
+// a source directory, typically because they belong to a version control
+// system, and so are not reported by Lint even though they are ignored.
+var lintExpectedNames = map[string]struct{}{
+	".bzr":           {},
+	".git":           {},
+	".gitattributes": {},
+	".github":        {},
+	".gitignore":     {},
+	".gitmodules":    {},
+	".hg":            {},
+	".hgignore":      {},
+	".svn":           {},
+}
+
+// Lint checks the source state in fs for problems. Every template is parsed
+// and executed, references to undefined template data and templates in
+// .chezmoitemplates that are never used are reported, as are files and
+// directories that are ignored because their names begin with a dot. Lint
+// returns all the problems found, and an error only if the source state could
+// not be read. As undefined template data is reported separately, ts's
+// template options should not normally include missingkey=error.
+func (ts *TargetState) Lint(fs vfs.FS) ([]error, error) {
+	var problems []error
+
+	// Parse all the templates in .chezmoitemplates first so that they are
+	// available to all other templates.
+	templatePaths := make(map[string]string)
+	if err := vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == ts.SourceDir || !strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		if info.Name() == templatesDirName {
+			templateProblems, err := ts.lintTemplatesDir(fs, path, templatePaths)
+			if err != nil {
+				return err
+			}
+			problems = append(problems, templateProblems...)
+		}
+		if info.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	usedTemplates := make(map[string]struct{})
+	for _, tmpl := range ts.Templates {
+		addUsedTemplates(usedTemplates, tmpl.Tree.Root)
+	}
+
+	if err := vfs.Walk(fs, ts.SourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(ts.SourceDir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		if name := info.Name(); strings.HasPrefix(name, ".") {
+			dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
+			switch {
+			case name == allowSecretsName || name == ignoreName || name == removeName:
+				problems = append(problems, ts.lintTemplateFile(fs, path, false, usedTemplates, func() error {
+					return ts.addPatterns(fs, NewPatternSet(), path, filepath.Join(dns...))
+				})...)
+			case name == triggersName:
+				problems = append(problems, ts.lintTemplateFile(fs, path, false, usedTemplates, func() error {
+					return ts.addTriggers(fs, path, filepath.Join(dns...))
+				})...)
+			case name == versionName:
+				data, err := fs.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				if _, err := semver.NewVersion(strings.TrimSpace(string(data))); err != nil {
+					problems = append(problems, fmt.Errorf("%s: %w", path, err))
+				}
+			case name == templatesDirName || name == ProfilesDirName:
+			case relPath == name && strings.HasPrefix(name, ".chezmoi.") && strings.HasSuffix(name, TemplateSuffix):
+				// The config file template is used by chezmoi init.
+			case relPath == name && strings.HasPrefix(name, SOPSDataName+"."):
+				// sops-encrypted data files are read as template data.
+			default:
+				if _, ok := lintExpectedNames[name]; !ok {
+					problems = append(problems, fmt.Errorf("%s: ignored because its name begins with a dot", path))
+				}
+			}
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case info.IsDir():
+		case info.Mode().IsRegular():
+			psfp := parseSourceFilePath(relPath)
+			var encrypted, isTemplate bool
+			switch {
+			case psfp.fileAttributes != nil:
+				encrypted = psfp.fileAttributes.Encrypted
+				isTemplate = psfp.fileAttributes.Template
+			case psfp.scriptAttributes != nil:
+				isTemplate = psfp.scriptAttributes.Template
+			}
+			if isTemplate {
+				problems = append(problems, ts.lintTemplateFile(fs, path, encrypted, usedTemplates, nil)...)
+			}
+		default:
+			problems = append(problems, fmt.Errorf("%s: unsupported file type", path))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(templatePaths))
+	for name := range templatePaths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := usedTemplates[name]; !ok {
+			problems = append(problems, fmt.Errorf("%s: template %q is never used", templatePaths[name], name))
+		}
+	}
+
+	return problems, nil
+}
+
+// LintTemplateData parses and executes data as a template, returning all the
+// problems found.
+func (ts *TargetState) LintTemplateData(name string, data []byte) []error {
+	return ts.lintTemplateData(name, data, make(map[string]struct{}), func() error {
+		_, err := ts.executeTemplateData(name, data, false)
+		return err
+	})
+}
+
+// lintTemplateFile lints the template at path, decrypting it first if
+// encrypted is true. If execute is nil then the template is executed as the
+// contents of a file.
+func (ts *TargetState) lintTemplateFile(fs vfs.FS, path string, encrypted bool, usedTemplates map[string]struct{}, execute func() error) []error {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return []error{err}
+	}
+	if encrypted {
+		data, err = ts.GPG.Decrypt(path, data)
+		if err != nil {
+			return []error{err}
+		}
+	}
+	if execute == nil {
+		execute = func() error {
+			_, err := ts.executeTemplateData(path, data, encrypted)
+			return err
+		}
+	}
+	return ts.lintTemplateData(path, data, usedTemplates, execute)
+}
+
+// lintTemplateData parses data as a template, records the templates that it
+// uses in usedTemplates, checks its references to template data, and, if it
+// could be parsed, calls execute to execute it.
+func (ts *TargetState) lintTemplateData(name string, data []byte, usedTemplates map[string]struct{}, execute func() error) []error {
+	tmpl, err := template.New(name).Option(ts.TemplateOptions...).Funcs(ts.TemplateFuncs).Parse(string(data))
+	if err != nil {
+		return []error{err}
+	}
+	var problems []error
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		addUsedTemplates(usedTemplates, t.Tree.Root)
+		// The data passed to templates defined with define or block is not
+		// known, so only check references in the template itself.
+		if t.Name() != name {
+			continue
+		}
+		tree := t.Tree
+		walkDataReferences(tree.Root, true, func(node parse.Node, keys []string) {
+			if !hasDataKeys(ts.TemplateData, keys) {
+				location, _ := tree.ErrorContext(node)
+				problems = append(problems, fmt.Errorf("%s: undefined data key %s", location, node))
+			}
+		})
+	}
+	if err := execute(); err != nil {
+		problems = append(problems, err)
+	}
+	return problems
+}
+
+// lintTemplatesDir parses all the templates in the .chezmoitemplates directory
+// at path, adding them to ts.Templates and recording their paths in
+// templatePaths.
+func (ts *TargetState) lintTemplatesDir(fs vfs.FS, path string, templatePaths map[string]string) ([]error, error) {
+	var problems []error
+	prefix := filepath.ToSlash(path) + "/"
+	if err := vfs.Walk(fs, path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.Mode().IsRegular():
+			contents, err := fs.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			name := strings.TrimPrefix(filepath.ToSlash(path), prefix)
+			tmpl, err := template.New(name).Parse(string(contents))
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", path, err))
+				return nil
+			}
+			if ts.Templates == nil {
+				ts.Templates = make(map[string]*template.Template)
+			}
+			ts.Templates[name] = tmpl
+			templatePaths[name] = path
+		case info.IsDir():
+		default:
+			problems = append(problems, fmt.Errorf("unsupported file in %s: %s", templatesDirName, path))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return problems, nil
+}
+
+// addUsedTemplates adds the names of the templates invoked by node to
+// usedTemplates.
+func addUsedTemplates(usedTemplates map[string]struct{}, node parse.Node) {
+	switch node := node.(type) {
+	case *parse.IfNode:
+		addUsedTemplates(usedTemplates, node.List)
+		addUsedTemplates(usedTemplates, node.ElseList)
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, n := range node.Nodes {
+			addUsedTemplates(usedTemplates, n)
+		}
+	case *parse.RangeNode:
+		addUsedTemplates(usedTemplates, node.List)
+		addUsedTemplates(usedTemplates, node.ElseList)
+	case *parse.TemplateNode:
+		usedTemplates[node.Name] = struct{}{}
+	case *parse.WithNode:
+		addUsedTemplates(usedTemplates, node.List)
+		addUsedTemplates(usedTemplates, node.ElseList)
+	}
+}
+
+// walkDataReferences calls f for every reference to the template data in node,
+// with the keys of the referenced value. References to the template data as a
+// whole have no keys. dotIsData is whether dot is the template data in node.
+func walkDataReferences(node parse.Node, dotIsData bool, f func(parse.Node, []string)) {
+	switch node := node.(type) {
+	case *parse.ActionNode:
+		walkDataReferences(node.Pipe, dotIsData, f)
+	case *parse.ChainNode:
+		walkDataReferences(node.Node, dotIsData, f)
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			walkDataReferences(arg, dotIsData, f)
+		}
+	case *parse.DotNode:
+		if dotIsData {
+			f(node, nil)
+		}
+	case *parse.FieldNode:
+		if dotIsData {
+			f(node, node.Ident)
+		}
+	case *parse.IfNode:
+		walkDataReferences(node.Pipe, dotIsData, f)
+		walkDataReferences(node.List, dotIsData, f)
+		walkDataReferences(node.ElseList, dotIsData, f)
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, n := range node.Nodes {
+			walkDataReferences(n, dotIsData, f)
+		}
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+		for _, cmd := range node.Cmds {
+			walkDataReferences(cmd, dotIsData, f)
+		}
+	case *parse.RangeNode:
+		// Dot is set to each element inside range.
+		walkDataReferences(node.Pipe, dotIsData, f)
+		walkDataReferences(node.List, false, f)
+		walkDataReferences(node.ElseList, dotIsData, f)
+	case *parse.TemplateNode:
+		walkDataReferences(node.Pipe, dotIsData, f)
+	case *parse.VariableNode:
+		if node.Ident[0] == "$" {
+			f(node, node.Ident[1:])
+		}
+	case *parse.WithNode:
+		// Dot is set to the value of the pipeline inside with.
+		walkDataReferences(node.Pipe, dotIsData, f)
+		walkDataReferences(node.List, false, f)
+		walkDataReferences(node.ElseList, dotIsData, f)
+	}
+}
+
+// hasDataKeys returns false if keys definitely do not exist in data. Only maps
+// are checked: if a value on the way is not a map then the remaining keys
+// might be fields or methods, so hasDataKeys returns true. Secret LazyValues
+// are never computed, and are assumed to have every key.
+func hasDataKeys(data interface{}, keys []string) bool {
+	v := reflect.ValueOf(data)
+	for _, key := range keys {
+		if v.IsValid() && v.CanInterface() {
+			if lazyValue, ok := v.Interface().(*LazyValue); ok {
+				if lazyValue.secret {
+					return true
+				}
+				value, err := lazyValue.Get()
+				if err != nil {
+					return true
+				}
+				v = reflect.ValueOf(value)
+			}
+		}
+		for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return true
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+			return true
+		}
+		v = v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+		if !v.IsValid() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package chezmoi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twpayne/go-vfs/vfst"
+)
+
+func TestTargetStateLint(t *testing.T) {
+	fs, cleanup, err := vfst.NewTestFS(map[string]interface{}{
+		"/home/user/.local/share/chezmoi": map[string]interface{}{
+			".bashrc":                "# ignored\n",
+			".chezmoi.toml.tmpl":     "[data]\n  email = \"user@example.com\"\n",
+			".chezmoidata.sops.yaml": "sops: {}\n",
+			".chezmoiignore":         "{{ .undefined }}\n",
+			".chezmoitemplates": map[string]interface{}{
+				"unused": "unused\n",
+				"used":   "{{ .name }}\n",
+			},
+			".chezmoiversion": "bogus\n",
+			".git": map[string]interface{}{
+				"HEAD": "ref: refs/heads/master\n",
+			},
+			"dot_exec.tmpl":          "{{ fail \"boom\" }}\n",
+			"dot_good.tmpl":          "{{ .name }} {{ .chezmoi.os }}\n{{ template \"used\" . }}\n",
+			"dot_parse.tmpl":         "{{ if }}\n",
+			"dot_typo.tmpl":          "{{ .name }}\n{{ .nmae }}\n{{ .chezmoi.hostnme }}\n",
+			"run_script.sh.tmpl":     "{{ range .list }}{{ .x }}{{ end }}\n",
+			"symlink_dot_link.tmpl":  "{{ $.missing }}\n",
+			"symlink_dot_plain_link": "{{ .not_a_template }}\n",
+		},
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	ts := NewTargetState(
+		WithSourceDir("/home/user/.local/share/chezmoi"),
+		WithTemplateData(map[string]interface{}{
+			"chezmoi": map[string]interface{}{
+				"os": "linux",
+			},
+			"list": []interface{}{
+				map[string]interface{}{"x": 1},
+			},
+			"name": "user",
+		}),
+		WithTemplateOptions(nil),
+		WithTemplateFuncs(template.FuncMap{
+			"fail": func(s string) (string, error) {
+				return "", errors.New(s)
+			},
+		}),
+	)
+	problems, err := ts.Lint(fs)
+	require.NoError(t, err)
+
+	var actual []string
+	for _, problem := range problems {
+		actual = append(actual, problem.Error())
+	}
+	for _, expected := range []string{
+		"/home/user/.local/share/chezmoi/.bashrc: ignored because its name begins with a dot",
+		"/home/user/.local/share/chezmoi/.chezmoiignore:1:3: undefined data key .undefined",
+		"/home/user/.local/share/chezmoi/.chezmoiversion: ",
+		"/home/user/.local/share/chezmoi/dot_exec.tmpl:1:3: executing",
+		"/home/user/.local/share/chezmoi/dot_parse.tmpl:1: missing value for if",
+		"/home/user/.local/share/chezmoi/dot_typo.tmpl:2:3: undefined data key .nmae",
+		"/home/user/.local/share/chezmoi/dot_typo.tmpl:3:11: undefined data key .chezmoi.hostnme",
+		"/home/user/.local/share/chezmoi/symlink_dot_link.tmpl:1:4: undefined data key $.missing",
+		"/home/user/.local/share/chezmoi/.chezmoitemplates/unused: template \"unused\" is never used",
+	} {
+		found := false
+		for _, problem := range actual {
+			if strings.Contains(problem, expected) {
+				found = true
+				break
+			}
+		}
+		assert.True(t, found, "expected a problem containing %q in %q", expected, actual)
+	}
+	assert.Len(t, actual, 9)
+}
@@ -0,0 +1,84 @@
+package chezmoi
+
+import (
+	"os"
+	"os/exec"
+	"sort"
+)
+
+// A RecordingMutator wraps another Mutator and records the paths that it
+// mutates.
+type RecordingMutator struct {
+	m     Mutator
+	paths map[string]struct{}
+}
+
+// NewRecordingMutator returns a new RecordingMutator.
+func NewRecordingMutator(m Mutator) *RecordingMutator {
+	return &RecordingMutator{
+		m:     m,
+		paths: make(map[string]struct{}),
+	}
+}
+
+// Chmod implements Mutator.Chmod.
+func (m *RecordingMutator) Chmod(name string, mode os.FileMode) error {
+	m.paths[name] = struct{}{}
+	return m.m.Chmod(name, mode)
+}
+
+// IdempotentCmdOutput implements Mutator.IdempotentCmdOutput.
+func (m *RecordingMutator) IdempotentCmdOutput(cmd *exec.Cmd) ([]byte, error) {
+	return m.m.IdempotentCmdOutput(cmd)
+}
+
+// Mkdir implements Mutator.Mkdir.
+func (m *RecordingMutator) Mkdir(name string, perm os.FileMode) error {
+	m.paths[name] = struct{}{}
+	return m.m.Mkdir(name, perm)
+}
+
+// Paths returns the paths mutated, sorted.
+func (m *RecordingMutator) Paths() []string {
+	paths := make([]string, 0, len(m.paths))
+	for path := range m.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// RemoveAll implements Mutator.RemoveAll.
+func (m *RecordingMutator) RemoveAll(name string) error {
+	m.paths[name] = struct{}{}
+	return m.m.RemoveAll(name)
+}
+
+// Rename implements Mutator.Rename.
+func (m *RecordingMutator) Rename(oldpath, newpath string) error {
+	m.paths[oldpath] = struct{}{}
+	m.paths[newpath] = struct{}{}
+	return m.m.Rename(oldpath, newpath)
+}
+
+// RunCmd implements Mutator.RunCmd.
+func (m *RecordingMutator) RunCmd(cmd *exec.Cmd) error {
+	return m.m.RunCmd(cmd)
+}
+
+// Stat implements Mutator.Stat.
+func (m *RecordingMutator) Stat(path string) (os.FileInfo, error) {
+	return m.m.Stat(path)
+}
+
+// WriteFile implements Mutator.WriteFile.
+func (m *RecordingMutator) WriteFile(name string, data []byte, perm os.FileMode, currData []byte) error {
+	m.paths[name] = struct{}{}
+	return m.m.WriteFile(name, data, perm, currData)
+}
+
+// WriteSymlink implements Mutator.WriteSymlink.
+func (m *RecordingMutator) WriteSymlink(oldname, newname string) error {
+	m.paths[newname] = struct{}{}
+	return m.m.WriteSymlink(oldname, newname)
+}
@@ -0,0 +1,19 @@
+package chezmoi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ Mutator = &RecordingMutator{}
+
+func TestRecordingMutator(t *testing.T) {
+	m := NewRecordingMutator(NullMutator{})
+	require.NoError(t, m.WriteFile("/home/user/b", nil, 0644, nil))
+	require.NoError(t, m.Mkdir("/home/user/a", 0755))
+	require.NoError(t, m.WriteSymlink("target", "/home/user/c"))
+	_, _ = m.Stat("/home/user/d")
+	assert.Equal(t, []string{"/home/user/a", "/home/user/b", "/home/user/c"}, m.Paths())
+}
@@ -0,0 +1,176 @@
+package chezmoi
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RedactedText replaces secrets in redacted output.
+const RedactedText = "[redacted]"
+
+// minSecretLength is the minimum length of a recorded secret value. Shorter
+// values, for example the "1" or "yes" fields of structured secrets, would
+// redact too much unrelated output.
+const minSecretLength = 4
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// A Redactor records secret values and the paths of files whose contents are
+// entirely secret, and redacts them from output. A nil *Redactor records
+// nothing and redacts nothing.
+type Redactor struct {
+	secrets       map[string]struct{}
+	sortedSecrets []string
+	secretPaths   map[string]struct{}
+}
+
+// NewRedactor returns a new Redactor.
+func NewRedactor() *Redactor {
+	return &Redactor{
+		secrets:     make(map[string]struct{}),
+		secretPaths: make(map[string]struct{}),
+	}
+}
+
+// AddSecret records value as a secret. If value is a slice, array, map,
+// pointer, interface, or struct then the values that it contains are recorded
+// recursively.
+func (r *Redactor) AddSecret(value interface{}) {
+	if r == nil || value == nil {
+		return
+	}
+	r.addSecretValue(reflect.ValueOf(value))
+}
+
+// SecretsIn returns the recorded secrets that data contains.
+func (r *Redactor) SecretsIn(data []byte) []string {
+	if r == nil {
+		return nil
+	}
+	var secrets []string
+	for secret := range r.secrets {
+		if bytes.Contains(data, []byte(secret)) {
+			secrets = append(secrets, secret)
+		}
+	}
+	sort.Strings(secrets)
+	return secrets
+}
+
+// AddSecretPath records that the contents of the file at path are secret.
+func (r *Redactor) AddSecretPath(path string) {
+	if r == nil {
+		return
+	}
+	r.secretPaths[path] = struct{}{}
+}
+
+// IsSecretPath returns whether the contents of the file at path are secret.
+func (r *Redactor) IsSecretPath(path string) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.secretPaths[path]
+	return ok
+}
+
+// Redact returns data with all recorded secrets replaced by RedactedText.
+func (r *Redactor) Redact(data []byte) []byte {
+	if r == nil || len(r.secrets) == 0 {
+		return data
+	}
+	if r.sortedSecrets == nil {
+		// Replace longer secrets first so that secrets that contain other
+		// secrets are completely redacted.
+		r.sortedSecrets = make([]string, 0, len(r.secrets))
+		for secret := range r.secrets {
+			r.sortedSecrets = append(r.sortedSecrets, secret)
+		}
+		sort.Slice(r.sortedSecrets, func(i, j int) bool {
+			if len(r.sortedSecrets[i]) != len(r.sortedSecrets[j]) {
+				return len(r.sortedSecrets[i]) > len(r.sortedSecrets[j])
+			}
+			return r.sortedSecrets[i] < r.sortedSecrets[j]
+		})
+	}
+	for _, secret := range r.sortedSecrets {
+		data = bytes.ReplaceAll(data, []byte(secret), []byte(RedactedText))
+	}
+	return data
+}
+
+// RedactConcreteValue redacts secrets from value, a value returned by
+// ConcreteValue, in place. The contents of encrypted files are redacted
+// entirely.
+func (r *Redactor) RedactConcreteValue(value interface{}) {
+	if r == nil {
+		return
+	}
+	switch value := value.(type) {
+	case []interface{}:
+		for _, element := range value {
+			r.RedactConcreteValue(element)
+		}
+	case *dirConcreteValue:
+		r.RedactConcreteValue(value.Entries)
+	case *fileConcreteValue:
+		if value.Encrypted {
+			value.Contents = RedactedText
+		} else {
+			value.Contents = string(r.Redact([]byte(value.Contents)))
+		}
+	case *scriptConcreteValue:
+		value.Contents = string(r.Redact([]byte(value.Contents)))
+	case *symlinkConcreteValue:
+		value.Linkname = string(r.Redact([]byte(value.Linkname)))
+	}
+}
+
+// addSecretString records s as a secret. Surrounding whitespace is not
+// considered part of the secret, so that it is preserved in redacted output.
+func (r *Redactor) addSecretString(s string) {
+	secret := strings.TrimSpace(s)
+	if len(secret) < minSecretLength {
+		return
+	}
+	if _, ok := r.secrets[secret]; !ok {
+		r.secrets[secret] = struct{}{}
+		r.sortedSecrets = nil
+	}
+}
+
+func (r *Redactor) addSecretValue(v reflect.Value) {
+	if !v.IsValid() || v.Type().Implements(errorType) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		r.addSecretString(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			r.addSecretString(string(v.Bytes()))
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			r.addSecretValue(v.Index(i))
+		}
+	case reflect.Map:
+		// Only values are secret. Keys are typically field names.
+		iter := v.MapRange()
+		for iter.Next() {
+			r.addSecretValue(iter.Value())
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			r.addSecretValue(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				r.addSecretValue(v.Field(i))
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package chezmoi
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor()
+	r.AddSecret("s3cr3t\n")
+	r.AddSecret([]byte("bytes-secret"))
+	r.AddSecret(map[string]interface{}{
+		"password": "map-secret",
+		"id":       "1",
+		"nested": []interface{}{
+			"nested-secret",
+		},
+	})
+	r.AddSecret(struct {
+		Token  string
+		hidden string
+	}{
+		Token:  "struct-secret",
+		hidden: "not-recorded",
+	})
+	r.AddSecret(errors.New("error-message"))
+	r.AddSecret("s3cr3t-longer")
+	r.AddSecretPath("/home/user/.netrc")
+
+	for _, tc := range []struct {
+		data     string
+		expected string
+	}{
+		{
+			data:     "password s3cr3t\n",
+			expected: "password [redacted]\n",
+		},
+		{
+			data:     "bytes-secret map-secret nested-secret struct-secret",
+			expected: "[redacted] [redacted] [redacted] [redacted]",
+		},
+		{
+			data:     "password id 1",
+			expected: "password id 1",
+		},
+		{
+			data:     "not-recorded error-message",
+			expected: "not-recorded error-message",
+		},
+		{
+			data:     "s3cr3t-longer",
+			expected: "[redacted]",
+		},
+	} {
+		assert.Equal(t, tc.expected, string(r.Redact([]byte(tc.data))))
+	}
+	assert.True(t, r.IsSecretPath("/home/user/.netrc"))
+	assert.False(t, r.IsSecretPath("/home/user/.bashrc"))
+}
+
+func TestNilRedactor(t *testing.T) {
+	var r *Redactor
+	r.AddSecret("s3cr3t")
+	r.AddSecretPath("/home/user/.netrc")
+	assert.Equal(t, "s3cr3t", string(r.Redact([]byte("s3cr3t"))))
+	assert.False(t, r.IsSecretPath("/home/user/.netrc"))
+}
+
+func TestRedactConcreteValue(t *testing.T) {
+	r := NewRedactor()
+	r.AddSecret("s3cr3t")
+	value := []interface{}{
+		&dirConcreteValue{
+			Entries: []interface{}{
+				&fileConcreteValue{
+					Encrypted: true,
+					Contents:  "anything",
+				},
+				&fileConcreteValue{
+					Contents: "password s3cr3t",
+				},
+			},
+		},
+		&scriptConcreteValue{
+			Contents: "echo s3cr3t",
+		},
+		&symlinkConcreteValue{
+			Linkname: "s3cr3t",
+		},
+	}
+	r.RedactConcreteValue(value)
+	dir := value[0].(*dirConcreteValue)
+	assert.Equal(t, RedactedText, dir.Entries[0].(*fileConcreteValue).Contents)
+	assert.Equal(t, "password [redacted]", dir.Entries[1].(*fileConcreteValue).Contents)
+	assert.Equal(t, "echo [redacted]", value[1].(*scriptConcreteValue).Contents)
+	assert.Equal(t, RedactedText, value[2].(*symlinkConcreteValue).Linkname)
+}
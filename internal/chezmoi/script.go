@@ -6,10 +6,14 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
 	"time"
 
@@ -19,6 +23,19 @@ import (
 // FIXME allow encrypted scripts
 // FIXME add pre- and post- attributes
 
+// An Interpreter is a command used to run scripts.
+type Interpreter struct {
+	Command string
+	Args    []string
+}
+
+// maxScriptOutputSize is the maximum size of each of a script's stdout and
+// stderr recorded in its history entry.
+const maxScriptOutputSize = 64 * 1024
+
+// scriptTimeoutRegexp matches a timeout directive in a script.
+var scriptTimeoutRegexp = regexp.MustCompile(`chezmoi:timeout=(\S+)`)
+
 // A ScriptAttributes holds attributes parsed from a source script name.
 type ScriptAttributes struct {
 	Name     string
@@ -100,8 +117,7 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 
 	var key []byte
 	if s.Once {
-		contentsKeyArr := sha256.Sum256(contents)
-		key = []byte(s.targetName + ":" + hex.EncodeToString(contentsKeyArr[:]))
+		key = s.onceKey(contents)
 		scriptStateData, err := applyOptions.PersistentState.Get(applyOptions.ScriptStateBucket, key)
 		if err != nil {
 			return err
@@ -111,8 +127,14 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 		}
 	}
 
+	if applyOptions.ConfirmScript != nil {
+		if ok, err := applyOptions.ConfirmScript(s.targetName, contents); err != nil || !ok {
+			return err
+		}
+	}
+
 	if applyOptions.Verbose {
-		if _, err := applyOptions.Stdout.Write(contents); err != nil {
+		if _, err := applyOptions.Stdout.Write(applyOptions.Redactor.Redact(contents)); err != nil {
 			return err
 		}
 	}
@@ -140,14 +162,98 @@ func (s *Script) Apply(fs vfs.FS, mutator Mutator, follow bool, applyOptions *Ap
 		return err
 	}
 
+	// Write the template data so that scripts can read it. Secret values are
+	// not written, so that secrets are never written to disk in plaintext.
+	data, err := resolvedTemplateData(applyOptions.TemplateData)
+	if err != nil {
+		return err
+	}
+	dataFile, err := ioutil.TempFile("", "*.json")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(dataFile.Name())
+	}()
+	if err := json.NewEncoder(dataFile).Encode(data); err != nil {
+		dataFile.Close()
+		return err
+	}
+	if err := dataFile.Close(); err != nil {
+		return err
+	}
+
+	sourceDir, err := fs.RawPath(applyOptions.SourceDir)
+	if err != nil {
+		return err
+	}
+	destDir, err := fs.RawPath(applyOptions.DestDir)
+	if err != nil {
+		return err
+	}
+	dir, err := s.workingDir(fs, applyOptions.DestDir)
+	if err != nil {
+		return err
+	}
+
 	// Run the temporary script file.
-	//nolint:gosec
-	c := exec.Command(f.Name())
-	c.Dir = filepath.Join(applyOptions.DestDir, filepath.Dir(s.targetName))
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
+	c := s.cmd(f.Name(), contents, applyOptions.Interpreters)
+	c.Dir = dir
+	c.Env = append(os.Environ(),
+		"CHEZMOI=1",
+		"CHEZMOI_ARCH="+runtime.GOARCH,
+		"CHEZMOI_DATA_FILE="+dataFile.Name(),
+		"CHEZMOI_DEST_DIR="+destDir,
+		"CHEZMOI_OS="+runtime.GOOS,
+		"CHEZMOI_SOURCE_DIR="+sourceDir,
+	)
+	// Only capture the script's output if it is recorded, as capturing it
+	// means that it is no longer written directly to the terminal.
+	recordOutput := applyOptions.RecordScriptOutput && applyOptions.HistoryBucket != nil
+	stdout := &limitedBuffer{maxSize: maxScriptOutputSize}
+	stderr := &limitedBuffer{maxSize: maxScriptOutputSize}
+	if recordOutput {
+		c.Stdout = io.MultiWriter(os.Stdout, stdout)
+		c.Stderr = io.MultiWriter(os.Stderr, stderr)
+	} else {
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+	}
 	c.Stdin = os.Stdin
-	if err := c.Run(); err != nil {
+	timeout := applyOptions.ScriptTimeout
+	if scriptTimeout, ok, err := parseScriptTimeout(contents); err != nil {
+		return fmt.Errorf("%s: %w", s.targetName, err)
+	} else if ok {
+		timeout = scriptTimeout
+	}
+	startedAt := time.Now()
+	timedOut, err := runWithTimeout(c, timeout)
+	historyEntry := &HistoryEntry{
+		Type:      HistoryTypeScript,
+		Name:      s.sourceName,
+		StartedAt: startedAt,
+		Duration:  time.Since(startedAt),
+		TimedOut:  timedOut,
+	}
+	if recordOutput {
+		historyEntry.Stdout = string(applyOptions.Redactor.Redact([]byte(stdout.String())))
+		historyEntry.Stderr = string(applyOptions.Redactor.Redact([]byte(stderr.String())))
+	}
+	if c.ProcessState != nil {
+		historyEntry.ExitCode = c.ProcessState.ExitCode()
+	}
+	if timedOut {
+		err = fmt.Errorf("%s: timed out after %s", s.targetName, timeout)
+	}
+	if err != nil {
+		historyEntry.Error = err.Error()
+	}
+	if applyOptions.HistoryBucket != nil {
+		if err := AddHistoryEntry(applyOptions.PersistentState, applyOptions.HistoryBucket, historyEntry); err != nil {
+			return err
+		}
+	}
+	if err != nil {
 		return err
 	}
 
@@ -205,6 +311,30 @@ func (s *Script) Evaluate(ignore func(string) bool) error {
 	return err
 }
 
+// Pending returns whether applying s with applyOptions would run it, i.e. it
+// is not ignored, is not empty, and, if it is a run_once_ script, has not
+// already been run with the same contents.
+func (s *Script) Pending(applyOptions *ApplyOptions) (bool, error) {
+	if applyOptions.Ignore(s.targetName) {
+		return false, nil
+	}
+	contents, err := s.Contents()
+	if err != nil {
+		return false, err
+	}
+	if len(bytes.TrimSpace(contents)) == 0 {
+		return false, nil
+	}
+	if !s.Once {
+		return true, nil
+	}
+	scriptStateData, err := applyOptions.PersistentState.Get(applyOptions.ScriptStateBucket, s.onceKey(contents))
+	if err != nil {
+		return false, err
+	}
+	return scriptStateData == nil, nil
+}
+
 // SourceName implements Entry.SourceName.
 func (s *Script) SourceName() string {
 	return s.sourceName
@@ -215,6 +345,127 @@ func (s *Script) TargetName() string {
 	return s.targetName
 }
 
+// onceKey returns the key used to record that s has been run with contents.
+func (s *Script) onceKey(contents []byte) []byte {
+	contentsKeyArr := sha256.Sum256(contents)
+	return []byte(s.targetName + ":" + hex.EncodeToString(contentsKeyArr[:]))
+}
+
+// cmd returns the command to run the script written to name. If an interpreter
+// is configured for the script's extension then it is used, unless the script
+// starts with a shebang on a system that honors them.
+func (s *Script) cmd(name string, contents []byte, interpreters map[string]Interpreter) *exec.Cmd {
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(s.targetName), "."))
+	interpreter, ok := interpreters[extension]
+	if !ok || interpreter.Command == "" || runtime.GOOS != "windows" && bytes.HasPrefix(contents, []byte("#!")) {
+		//nolint:gosec
+		return exec.Command(name)
+	}
+	//nolint:gosec
+	return exec.Command(interpreter.Command, append(append([]string{}, interpreter.Args...), name)...)
+}
+
+// workingDir returns the directory in which s should be run. This is the
+// directory containing s's target or, if it does not exist, its closest
+// existing parent.
+func (s *Script) workingDir(fs vfs.FS, destDir string) (string, error) {
+	dir := filepath.Join(destDir, filepath.Dir(s.targetName))
+	for {
+		if info, err := fs.Stat(dir); err == nil && info.IsDir() {
+			break
+		}
+		parentDir := filepath.Dir(dir)
+		if parentDir == dir {
+			break
+		}
+		dir = parentDir
+	}
+	return fs.RawPath(dir)
+}
+
+// parseScriptTimeout returns the timeout set by a chezmoi:timeout=<duration>
+// directive in contents, if any.
+func parseScriptTimeout(contents []byte) (time.Duration, bool, error) {
+	m := scriptTimeoutRegexp.FindSubmatch(contents)
+	if m == nil {
+		return 0, false, nil
+	}
+	timeout, err := time.ParseDuration(string(m[1]))
+	if err != nil {
+		return 0, false, err
+	}
+	return timeout, true, nil
+}
+
+// runWithTimeout runs c. If timeout is positive and c does not complete within
+// timeout then c and any processes that it started are killed and
+// runWithTimeout returns true. runWithTimeout does not wait for processes that
+// escaped c's process group to close c's output. c's output writers must be
+// safe for concurrent use.
+func runWithTimeout(c *exec.Cmd, timeout time.Duration) (bool, error) {
+	if timeout <= 0 {
+		return false, c.Run()
+	}
+	restoreForeground := setProcessGroup(c)
+
+	// Copy c's output through our own pipes, rather than letting c create
+	// them, so that we can stop copying if c times out.
+	var readers, writers []*os.File
+	copyErrs := make(chan error, 2)
+	closeAll := func(files []*os.File) {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}
+	for _, w := range []*io.Writer{&c.Stdout, &c.Stderr} {
+		if _, ok := (*w).(*os.File); ok || *w == nil {
+			continue
+		}
+		r, pw, err := os.Pipe()
+		if err != nil {
+			closeAll(readers)
+			closeAll(writers)
+			return false, err
+		}
+		readers = append(readers, r)
+		writers = append(writers, pw)
+		go func(w io.Writer) {
+			_, err := io.Copy(w, r)
+			copyErrs <- err
+		}(*w)
+		*w = pw
+	}
+
+	err := c.Start()
+	closeAll(writers)
+	if err != nil {
+		restoreForeground()
+		closeAll(readers)
+		return false, err
+	}
+
+	timedOut := make(chan struct{})
+	timer := time.AfterFunc(timeout, func() {
+		close(timedOut)
+		_ = killProcessGroup(c)
+	})
+	err = c.Wait()
+	restoreForeground()
+	if !timer.Stop() {
+		<-timedOut
+		closeAll(readers)
+		return true, err
+	}
+	// c completed, so wait for all of its output, like exec.Cmd.Wait.
+	for range readers {
+		if copyErr := <-copyErrs; copyErr != nil && err == nil {
+			err = copyErr
+		}
+	}
+	closeAll(readers)
+	return false, err
+}
+
 // archive writes s to w.
 func (s *Script) archive(w *tar.Writer, ignore func(string) bool, headerTemplate *tar.Header, umask os.FileMode) error {
 	if ignore(s.targetName) {
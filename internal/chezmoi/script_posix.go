@@ -0,0 +1,58 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// setProcessGroup arranges for cmd to be run in its own process group, so that
+// it and any processes that it starts can be killed together. If cmd's stdin is
+// the terminal and chezmoi is in the foreground then cmd's process group is
+// made the foreground process group, so that cmd can still read from the
+// terminal. The returned function must be called after cmd completes to
+// restore chezmoi's process group as the foreground process group.
+func setProcessGroup(cmd *exec.Cmd) func() {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+	tty, ok := cmd.Stdin.(*os.File)
+	if !ok || !terminal.IsTerminal(int(tty.Fd())) {
+		return func() {}
+	}
+	pgrp := syscall.Getpgrp()
+	if foregroundPgrp, err := ioctlPgrp(tty.Fd(), syscall.TIOCGPGRP, 0); err != nil || foregroundPgrp != pgrp {
+		return func() {}
+	}
+	cmd.SysProcAttr.Foreground = true
+	cmd.SysProcAttr.Ctty = int(tty.Fd())
+	return func() {
+		// chezmoi is now in a background process group, and so would be
+		// stopped by SIGTTOU when changing the foreground process group.
+		signal.Ignore(syscall.SIGTTOU)
+		defer signal.Reset(syscall.SIGTTOU)
+		_, _ = ioctlPgrp(tty.Fd(), syscall.TIOCSPGRP, pgrp)
+	}
+}
+
+// killProcessGroup kills cmd's process group.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// ioctlPgrp calls the terminal process group ioctl req on fd with pgrp, and
+// returns the resulting process group.
+func ioctlPgrp(fd uintptr, req uintptr, pgrp int) (int, error) {
+	value := int32(pgrp)
+	//nolint:gosec
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(&value))); errno != 0 {
+		return 0, errno
+	}
+	return int(value), nil
+}
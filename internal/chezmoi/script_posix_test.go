@@ -0,0 +1,88 @@
+// +build !windows
+
+package chezmoi
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWithTimeout(t *testing.T) {
+	t.Run("stdin", func(t *testing.T) {
+		stdout := &limitedBuffer{maxSize: maxScriptOutputSize}
+		c := exec.Command("sh", "-c", `read line; echo "$line"`)
+		c.Stdin = strings.NewReader("input\n")
+		c.Stdout = stdout
+		timedOut, err := runWithTimeout(c, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, timedOut)
+		assert.Equal(t, "input\n", stdout.String())
+	})
+
+	t.Run("stdin_timeout", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		defer r.Close()
+		defer w.Close()
+		stdout := &limitedBuffer{maxSize: maxScriptOutputSize}
+		c := exec.Command("sh", "-c", `echo waiting; read line`)
+		c.Stdin = r
+		c.Stdout = stdout
+		startedAt := time.Now()
+		timedOut, err := runWithTimeout(c, 100*time.Millisecond)
+		assert.Error(t, err)
+		assert.True(t, timedOut)
+		assert.Less(t, int64(time.Since(startedAt)), int64(5*time.Second))
+		assert.Equal(t, "waiting\n", stdout.String())
+	})
+
+	t.Run("children_killed", func(t *testing.T) {
+		if _, err := exec.LookPath("ps"); err != nil {
+			t.Skip("ps not found")
+		}
+		stdout := &limitedBuffer{maxSize: maxScriptOutputSize}
+		c := exec.Command("sh", "-c", `sleep 10 & echo $!; wait`)
+		c.Stdout = stdout
+		startedAt := time.Now()
+		timedOut, _ := runWithTimeout(c, 500*time.Millisecond)
+		assert.True(t, timedOut)
+		assert.Less(t, int64(time.Since(startedAt)), int64(5*time.Second))
+
+		// The process started by the script is killed, although it might
+		// remain a zombie until it is reaped.
+		pid := strings.TrimSpace(stdout.String())
+		_, err := strconv.Atoi(pid)
+		require.NoError(t, err)
+		output, _ := exec.Command("ps", "-o", "stat=", "-p", pid).Output()
+		if state := strings.TrimSpace(string(output)); state != "" {
+			assert.True(t, strings.HasPrefix(state, "Z"), state)
+		}
+	})
+
+	t.Run("process_group", func(t *testing.T) {
+		// Scripts with a timeout are run in their own process group so that
+		// the processes that they start can be killed.
+		if _, err := exec.LookPath("ps"); err != nil {
+			t.Skip("ps not found")
+		}
+		stdout := &bytes.Buffer{}
+		c := exec.Command("sh", "-c", `ps -o pgid= -p $$; echo $$`)
+		c.Stdout = stdout
+		timedOut, err := runWithTimeout(c, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, timedOut)
+		fields := strings.Fields(stdout.String())
+		require.Len(t, fields, 2)
+		assert.NotEqual(t, strconv.Itoa(syscall.Getpgrp()), fields[0])
+		assert.Equal(t, fields[1], fields[0])
+	})
+}
@@ -0,0 +1,22 @@
+// +build windows
+
+package chezmoi
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// setProcessGroup does nothing on Windows.
+func setProcessGroup(cmd *exec.Cmd) func() {
+	return func() {}
+}
+
+// killProcessGroup kills cmd's process and any processes that it started.
+func killProcessGroup(cmd *exec.Cmd) error {
+	//nolint:gosec
+	if err := exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package chezmoi
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// minHighEntropy is the minimum Shannon entropy, in bits per character, of a
+// high-entropy string.
+const minHighEntropy = 4.0
+
+// A SecretFinding is a possible secret found by ScanSecrets.
+type SecretFinding struct {
+	Kind  string
+	Line  int
+	Value string
+	start int
+	end   int
+}
+
+// A secretPattern matches a kind of secret. If regexp has a subexpression then
+// only the first subexpression is the secret.
+type secretPattern struct {
+	kind   string
+	regexp *regexp.Regexp
+}
+
+// secretPatterns are the patterns of known secrets, in order of precedence.
+var secretPatterns = []secretPattern{
+	{"private key", regexp.MustCompile(`-----BEGIN[A-Z ]* PRIVATE KEY(?: BLOCK)?-----(?s:.*?)-----END[A-Z ]* PRIVATE KEY(?: BLOCK)?-----`)},
+	{"private key", regexp.MustCompile(`-----BEGIN[A-Z ]* PRIVATE KEY(?: BLOCK)?-----`)},
+	{"AWS access key ID", regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`)},
+	{"AWS secret access key", regexp.MustCompile(`(?i)aws_secret_access_key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	{"GitHub token", regexp.MustCompile(`\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"GitLab token", regexp.MustCompile(`\b(glpat-[A-Za-z0-9_\-]{20,})`)},
+	{"Google API key", regexp.MustCompile(`\b(AIza[0-9A-Za-z_\-]{35})`)},
+	{"npm token", regexp.MustCompile(`\b(npm_[A-Za-z0-9]{36})\b`)},
+	{"Slack token", regexp.MustCompile(`\b(xox[abposr]-[A-Za-z0-9\-]{10,})`)},
+	{"Stripe key", regexp.MustCompile(`\b((?:sk|rk)_live_[0-9A-Za-z]{24,})`)},
+	{"netrc password", regexp.MustCompile(`(?m)^[ \t]*(?:(?:machine|login|account)[ \t]+\S+[ \t]+)*password[ \t]+([^\s=:{$]\S*)`)},
+	{"password or token", regexp.MustCompile(`(?i)(?:api[_\-]?key|access[_\-]?key|auth[_\-]?token|client[_\-]?secret|passwd|password|secret|token)["']?\s*[:=]\s*["']?([^\s"'{$][^\s"']{7,})`)},
+}
+
+// highEntropyCandidateRegexp matches candidate high-entropy strings.
+var highEntropyCandidateRegexp = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{20,}`)
+
+// ScanSecrets returns the possible secrets in contents, in the order in which
+// they appear. Values that are already templates are not reported.
+func ScanSecrets(contents []byte) []SecretFinding {
+	var findings []SecretFinding
+	overlaps := func(start, end int) bool {
+		for _, finding := range findings {
+			if start < finding.end && finding.start < end {
+				return true
+			}
+		}
+		return false
+	}
+	add := func(kind string, start, end int) {
+		if overlaps(start, end) {
+			return
+		}
+		findings = append(findings, SecretFinding{
+			Kind:  kind,
+			Line:  bytes.Count(contents[:start], []byte{'\n'}) + 1,
+			Value: string(contents[start:end]),
+			start: start,
+			end:   end,
+		})
+	}
+
+	for _, pattern := range secretPatterns {
+		for _, m := range pattern.regexp.FindAllSubmatchIndex(contents, -1) {
+			if len(m) >= 4 && m[2] != -1 {
+				add(pattern.kind, m[2], m[3])
+			} else {
+				add(pattern.kind, m[0], m[1])
+			}
+		}
+	}
+	for _, m := range highEntropyCandidateRegexp.FindAllIndex(contents, -1) {
+		if isHighEntropy(contents[m[0]:m[1]]) {
+			add("high-entropy string", m[0], m[1])
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].start < findings[j].start
+	})
+	return findings
+}
+
+// MaskedValue returns f's value with all but its first few characters hidden,
+// so that it can be identified without being revealed.
+func (f SecretFinding) MaskedValue() string {
+	if len(f.Value) < 12 {
+		return "..."
+	}
+	return f.Value[:4] + "..."
+}
+
+// isHighEntropy returns whether s looks like a random token. Only strings that
+// contain upper and lower case letters and digits are considered, as paths,
+// identifiers, and hex checksums are common in configuration files.
+func isHighEntropy(s []byte) bool {
+	var hasDigit, hasLower, hasUpper bool
+	counts := make(map[byte]int)
+	for _, b := range s {
+		switch r := rune(b); {
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		}
+		counts[b]++
+	}
+	if !hasDigit || !hasLower || !hasUpper {
+		return false
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(len(s))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= minHighEntropy
+}
+
+// templatizeSecrets returns contents with each secret value in secretTemplates
+// replaced by a template action that calls the corresponding template
+// expression. Existing template delimiters in contents are escaped, in the same
+// way as autoTemplate, so that the result evaluates to contents.
+func templatizeSecrets(contents []byte, secretTemplates map[string]string) []byte {
+	// Try longer values first so that values that contain other values are
+	// completely replaced.
+	values := make([]string, 0, len(secretTemplates))
+	for value := range secretTemplates {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+	s := string(contents)
+	sb := &strings.Builder{}
+	sb.Grow(len(s))
+FOR:
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "{{"):
+			sb.WriteString(escapedLeftDelim)
+			i += 2
+			continue
+		case strings.HasPrefix(s[i:], "}}"):
+			sb.WriteString(escapedRightDelim)
+			i += 2
+			continue
+		}
+		for _, value := range values {
+			if strings.HasPrefix(s[i:], value) {
+				sb.WriteString("{{ " + secretTemplates[value] + " }}")
+				i += len(value)
+				continue FOR
+			}
+		}
+		sb.WriteByte(s[i])
+		i++
+	}
+	return []byte(sb.String())
+}
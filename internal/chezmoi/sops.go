@@ -0,0 +1,280 @@
+package chezmoi
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// SOPSDataName is the name of the sops-encrypted data files in the root of the
+// source directory, without their extension.
+const SOPSDataName = ".chezmoidata.sops"
+
+// SOPSDataExts are the extensions of sops-encrypted data files, in order of
+// precedence.
+var SOPSDataExts = []string{"json", "yaml", "yml"}
+
+const sopsMetadataKey = "sops"
+
+var sopsEncryptedValueRegexp = regexp.MustCompile(`\AENC\[AES256_GCM,data:(.+),iv:(.+),tag:(.+),type:(.+)\]\z`)
+
+// A SOPSKey is an encrypted copy of a sops document's data key.
+type SOPSKey struct {
+	Type string // "age" or "pgp"
+	ID   string // The age recipient or the PGP key fingerprint.
+	Enc  string // The ASCII-armored encrypted data key.
+}
+
+// A SOPSDocument is a YAML or JSON document encrypted with sops.
+type SOPSDocument struct {
+	tree     yaml.MapSlice
+	metadata sopsMetadata
+}
+
+// A sopsMetadata is the metadata that sops stores in a document.
+type sopsMetadata struct {
+	KMS     []interface{} `yaml:"kms"`
+	GCPKMS  []interface{} `yaml:"gcp_kms"`
+	AzureKV []interface{} `yaml:"azure_kv"`
+	HCVault []interface{} `yaml:"hc_vault"`
+	Age     []struct {
+		Recipient string `yaml:"recipient"`
+		Enc       string `yaml:"enc"`
+	} `yaml:"age"`
+	PGP []struct {
+		FP  string `yaml:"fp"`
+		Enc string `yaml:"enc"`
+	} `yaml:"pgp"`
+	KeyGroups        []interface{} `yaml:"key_groups"`
+	LastModified     string        `yaml:"lastmodified"`
+	MAC              string        `yaml:"mac"`
+	MACOnlyEncrypted bool          `yaml:"mac_only_encrypted"`
+	Version          string        `yaml:"version"`
+}
+
+// ParseSOPSDocument parses data, a YAML or JSON document encrypted with sops.
+// The values in the document are not decrypted.
+func ParseSOPSDocument(data []byte) (*SOPSDocument, error) {
+	var tree yaml.MapSlice
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	d := &SOPSDocument{}
+	found := false
+	for _, item := range tree {
+		if item.Key != sopsMetadataKey {
+			d.tree = append(d.tree, item)
+			continue
+		}
+		found = true
+		metadataYAML, err := yaml.Marshal(item.Value)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(metadataYAML, &d.metadata); err != nil {
+			return nil, fmt.Errorf("sops metadata: %w", err)
+		}
+	}
+	if !found {
+		return nil, errors.New("not encrypted with sops: no sops metadata")
+	}
+	if len(d.metadata.KeyGroups) > 0 {
+		return nil, errors.New("sops key groups are not supported")
+	}
+	return d, nil
+}
+
+// Keys returns the top level keys of d. Keys are not encrypted by sops.
+func (d *SOPSDocument) Keys() []string {
+	keys := make([]string, 0, len(d.tree))
+	for _, item := range d.tree {
+		keys = append(keys, fmt.Sprint(item.Key))
+	}
+	return keys
+}
+
+// EncryptedKeys returns the encrypted copies of d's data key, age keys first.
+func (d *SOPSDocument) EncryptedKeys() []SOPSKey {
+	var keys []SOPSKey
+	for _, age := range d.metadata.Age {
+		keys = append(keys, SOPSKey{
+			Type: "age",
+			ID:   age.Recipient,
+			Enc:  age.Enc,
+		})
+	}
+	for _, pgp := range d.metadata.PGP {
+		keys = append(keys, SOPSKey{
+			Type: "pgp",
+			ID:   pgp.FP,
+			Enc:  pgp.Enc,
+		})
+	}
+	return keys
+}
+
+// Decrypt returns the decrypted contents of d. decryptKey is called to decrypt
+// each of d's encrypted data keys in turn until one succeeds. Decrypt verifies
+// d's message authentication code, so any modification of the document is
+// detected.
+func (d *SOPSDocument) Decrypt(decryptKey func(SOPSKey) ([]byte, error)) (map[string]interface{}, error) {
+	keys := d.EncryptedKeys()
+	if len(keys) == 0 {
+		return nil, errors.New("no age or PGP keys: only age and PGP keys are supported")
+	}
+	var dataKey []byte
+	var keyErrs []string
+	for _, key := range keys {
+		var err error
+		dataKey, err = decryptKey(key)
+		if err == nil {
+			break
+		}
+		keyErrs = append(keyErrs, fmt.Sprintf("%s %s: %v", key.Type, key.ID, err))
+	}
+	if dataKey == nil {
+		return nil, fmt.Errorf("could not decrypt data key: %s", strings.Join(keyErrs, "; "))
+	}
+
+	hash := sha512.New()
+	value, err := d.decryptValue(dataKey, d.tree, nil, func(encrypted bool, value interface{}) {
+		if encrypted || !d.metadata.MACOnlyEncrypted {
+			hash.Write(sopsValueBytes(value))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if d.metadata.MAC == "" {
+		return nil, errors.New("no MAC")
+	}
+	mac, err := decryptSOPSValue(dataKey, d.metadata.MAC, d.metadata.LastModified)
+	if err != nil {
+		return nil, fmt.Errorf("MAC: %w", err)
+	}
+	if mac != fmt.Sprintf("%X", hash.Sum(nil)) {
+		return nil, errors.New("MAC mismatch: the document has been modified")
+	}
+
+	result, _ := value.(map[string]interface{})
+	return result, nil
+}
+
+// decryptValue returns value, found at path, with all encrypted values
+// decrypted with dataKey and all maps converted to map[string]interface{}s.
+// addLeaf is called with every leaf value in document order.
+func (d *SOPSDocument) decryptValue(dataKey []byte, value interface{}, path []string, addLeaf func(bool, interface{})) (interface{}, error) {
+	switch value := value.(type) {
+	case yaml.MapSlice:
+		result := make(map[string]interface{}, len(value))
+		for _, item := range value {
+			key := fmt.Sprint(item.Key)
+			v, err := d.decryptValue(dataKey, item.Value, append(path[:len(path):len(path)], key), addLeaf)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = v
+		}
+		return result, nil
+	case []interface{}:
+		// sops does not include list indexes in paths.
+		result := make([]interface{}, 0, len(value))
+		for _, element := range value {
+			v, err := d.decryptValue(dataKey, element, path, addLeaf)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, v)
+		}
+		return result, nil
+	case string:
+		if !sopsEncryptedValueRegexp.MatchString(value) {
+			addLeaf(false, value)
+			return value, nil
+		}
+		plaintext, err := decryptSOPSValue(dataKey, value, strings.Join(path, ":")+":")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+		}
+		addLeaf(true, plaintext)
+		return plaintext, nil
+	default:
+		addLeaf(false, value)
+		return value, nil
+	}
+}
+
+// decryptSOPSValue decrypts the sops-encrypted value s with dataKey and
+// additionalData, and returns it as its original type.
+func decryptSOPSValue(dataKey []byte, s, additionalData string) (interface{}, error) {
+	m := sopsEncryptedValueRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return nil, errors.New("invalid encrypted value")
+	}
+	var fields [3][]byte
+	for i := range fields {
+		var err error
+		fields[i], err = base64.StdEncoding.DecodeString(m[i+1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, iv, tag := fields[0], fields[1], fields[2]
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, err
+	}
+	switch valueType := m[4]; valueType {
+	case "str", "bytes":
+		return string(plaintext), nil
+	case "int":
+		return strconv.Atoi(string(plaintext))
+	case "float":
+		return strconv.ParseFloat(string(plaintext), 64)
+	case "bool":
+		return strconv.ParseBool(string(plaintext))
+	default:
+		return nil, fmt.Errorf("%s: unsupported type", valueType)
+	}
+}
+
+// sopsValueBytes returns the representation of value used by sops to compute a
+// document's message authentication code.
+func sopsValueBytes(value interface{}) []byte {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []byte(value)
+	case int:
+		return []byte(strconv.Itoa(value))
+	case float64:
+		return []byte(strconv.FormatFloat(value, 'f', -1, 64))
+	case bool:
+		// sops capitalizes booleans for compatibility with its original Python
+		// implementation.
+		if value {
+			return []byte("True")
+		}
+		return []byte("False")
+	default:
+		return []byte(fmt.Sprint(value))
+	}
+}
@@ -0,0 +1,234 @@
+package chezmoi
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const testSOPSLastModified = "2020-06-01T12:00:00Z"
+
+// encryptSOPSTestValue encrypts value with dataKey and additionalData in the
+// same way as sops.
+func encryptSOPSTestValue(t *testing.T, dataKey []byte, value interface{}, additionalData string) string {
+	var valueType string
+	switch value.(type) {
+	case string:
+		valueType = "str"
+	case int:
+		valueType = "int"
+	case float64:
+		valueType = "float"
+	case bool:
+		valueType = "bool"
+	default:
+		t.Fatalf("%T: unsupported type", value)
+	}
+	block, err := aes.NewCipher(dataKey)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCMWithNonceSize(block, 32)
+	require.NoError(t, err)
+	iv := make([]byte, 32)
+	_, err = io.ReadFull(rand.Reader, iv)
+	require.NoError(t, err)
+	sealed := aead.Seal(nil, iv, sopsValueBytes(value), []byte(additionalData))
+	tagOffset := len(sealed) - aead.Overhead()
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(sealed[:tagOffset]),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(sealed[tagOffset:]),
+		valueType,
+	)
+}
+
+// newSOPSTestDocument returns tree encrypted with dataKey in the same way as
+// sops, with a single PGP key whose encrypted data key is enc. Values whose
+// keys end with _unencrypted are not encrypted.
+func newSOPSTestDocument(t *testing.T, dataKey []byte, tree yaml.MapSlice, enc string) []byte {
+	hash := sha512.New()
+	var encrypt func(interface{}, []string, bool) interface{}
+	encrypt = func(value interface{}, path []string, unencrypted bool) interface{} {
+		switch value := value.(type) {
+		case yaml.MapSlice:
+			result := make(yaml.MapSlice, 0, len(value))
+			for _, item := range value {
+				key := item.Key.(string)
+				result = append(result, yaml.MapItem{
+					Key:   key,
+					Value: encrypt(item.Value, append(path[:len(path):len(path)], key), unencrypted || strings.HasSuffix(key, "_unencrypted")),
+				})
+			}
+			return result
+		case []interface{}:
+			result := make([]interface{}, 0, len(value))
+			for _, element := range value {
+				result = append(result, encrypt(element, path, unencrypted))
+			}
+			return result
+		default:
+			hash.Write(sopsValueBytes(value))
+			// sops does not encrypt empty strings.
+			if unencrypted || value == "" {
+				return value
+			}
+			return encryptSOPSTestValue(t, dataKey, value, strings.Join(path, ":")+":")
+		}
+	}
+	encryptedTree := encrypt(tree, nil, false).(yaml.MapSlice)
+	mac := encryptSOPSTestValue(t, dataKey, fmt.Sprintf("%X", hash.Sum(nil)), testSOPSLastModified)
+	encryptedTree = append(encryptedTree, yaml.MapItem{
+		Key: "sops",
+		Value: yaml.MapSlice{
+			{Key: "kms", Value: []interface{}{}},
+			{Key: "gcp_kms", Value: []interface{}{}},
+			{Key: "azure_kv", Value: []interface{}{}},
+			{Key: "hc_vault", Value: []interface{}{}},
+			{Key: "age", Value: []interface{}{}},
+			{Key: "lastmodified", Value: testSOPSLastModified},
+			{Key: "mac", Value: mac},
+			{Key: "pgp", Value: []interface{}{
+				yaml.MapSlice{
+					{Key: "created_at", Value: testSOPSLastModified},
+					{Key: "enc", Value: enc},
+					{Key: "fp", Value: "0123456789ABCDEF"},
+				},
+			}},
+			{Key: "unencrypted_suffix", Value: "_unencrypted"},
+			{Key: "version", Value: "3.6.1"},
+		},
+	})
+	data, err := yaml.Marshal(encryptedTree)
+	require.NoError(t, err)
+	return data
+}
+
+func TestSOPSDocument(t *testing.T) {
+	dataKey := make([]byte, 32)
+	_, err := io.ReadFull(rand.Reader, dataKey)
+	require.NoError(t, err)
+	decryptKey := func(key SOPSKey) ([]byte, error) {
+		if key.Enc != "encrypted-data-key" {
+			return nil, errors.New("wrong key")
+		}
+		return dataKey, nil
+	}
+
+	data := newSOPSTestDocument(t, dataKey, yaml.MapSlice{
+		{Key: "github", Value: yaml.MapSlice{
+			{Key: "user", Value: "user"},
+			{Key: "token", Value: "ghp_secret"},
+		}},
+		{Key: "hosts", Value: []interface{}{"alpha", "beta"}},
+		{Key: "port", Value: 8080},
+		{Key: "ratio", Value: 0.5},
+		{Key: "enabled", Value: true},
+		{Key: "empty", Value: ""},
+		{Key: "comment_unencrypted", Value: "visible"},
+	}, "encrypted-data-key")
+	assert.NotContains(t, string(data), "ghp_secret")
+	assert.Contains(t, string(data), "visible")
+
+	document, err := ParseSOPSDocument(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github", "hosts", "port", "ratio", "enabled", "empty", "comment_unencrypted"}, document.Keys())
+	assert.Equal(t, []SOPSKey{
+		{Type: "pgp", ID: "0123456789ABCDEF", Enc: "encrypted-data-key"},
+	}, document.EncryptedKeys())
+
+	actual, err := document.Decrypt(decryptKey)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"github": map[string]interface{}{
+			"user":  "user",
+			"token": "ghp_secret",
+		},
+		"hosts":               []interface{}{"alpha", "beta"},
+		"port":                8080,
+		"ratio":               0.5,
+		"enabled":             true,
+		"empty":               "",
+		"comment_unencrypted": "visible",
+	}, actual)
+
+	t.Run("wrong_key", func(t *testing.T) {
+		_, err := document.Decrypt(func(SOPSKey) ([]byte, error) {
+			return nil, errors.New("no secret key")
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "pgp 0123456789ABCDEF: no secret key")
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		modifiedDocument, err := ParseSOPSDocument([]byte(strings.Replace(string(data), "visible", "modified", 1)))
+		require.NoError(t, err)
+		_, err = modifiedDocument.Decrypt(decryptKey)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "MAC mismatch")
+	})
+
+	t.Run("moved", func(t *testing.T) {
+		// Values are bound to their paths, so moving an encrypted value to a
+		// different key is detected.
+		var tree yaml.MapSlice
+		require.NoError(t, yaml.Unmarshal(data, &tree))
+		github := tree[0].Value.(yaml.MapSlice)
+		github[0].Value, github[1].Value = github[1].Value, github[0].Value
+		movedData, err := yaml.Marshal(tree)
+		require.NoError(t, err)
+		movedDocument, err := ParseSOPSDocument(movedData)
+		require.NoError(t, err)
+		_, err = movedDocument.Decrypt(decryptKey)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseSOPSDocumentErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		data        string
+		expectedErr string
+	}{
+		{
+			name:        "not_sops",
+			data:        "key: value\n",
+			expectedErr: "no sops metadata",
+		},
+		{
+			name:        "invalid",
+			data:        "key: [\n",
+			expectedErr: "yaml",
+		},
+		{
+			name:        "key_groups",
+			data:        "key: value\nsops:\n  key_groups:\n  - pgp: []\n",
+			expectedErr: "key groups are not supported",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseSOPSDocument([]byte(tc.data))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expectedErr)
+		})
+	}
+}
+
+func TestSOPSDocumentNoKeys(t *testing.T) {
+	document, err := ParseSOPSDocument([]byte("key: value\nsops:\n  kms:\n  - arn: arn:aws:kms:us-east-1:123456789012:key/id\n"))
+	require.NoError(t, err)
+	_, err = document.Decrypt(func(SOPSKey) ([]byte, error) {
+		return nil, errors.New("unexpected call")
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only age and PGP keys are supported")
+}
@@ -21,12 +21,18 @@ import (
 var DefaultTemplateOptions = []string{"missingkey=error"}
 
 const (
+	allowSecretsName = ".chezmoiallowsecrets"
 	ignoreName       = ".chezmoiignore"
 	removeName       = ".chezmoiremove"
 	templatesDirName = ".chezmoitemplates"
+	triggersName     = ".chezmoitriggers"
 	versionName      = ".chezmoiversion"
 )
 
+// ProfilesDirName is the name of the directory in the source state that
+// contains machine profiles.
+const ProfilesDirName = ".chezmoiprofiles"
+
 // An AddOptions contains options for TargetState.Add.
 type AddOptions struct {
 	Empty        bool
@@ -34,6 +40,12 @@ type AddOptions struct {
 	Exact        bool
 	Template     bool
 	AutoTemplate bool
+	// AutoTemplateOptions are the options used when AutoTemplate is true. Its
+	// Templates are set to SecretTemplates.
+	AutoTemplateOptions AutoTemplateOptions
+	// SecretTemplates maps secret values to the template expressions that
+	// replace them.
+	SecretTemplates map[string]string
 }
 
 // An ImportTAROptions contains options for TargetState.ImportTAR.
@@ -55,12 +67,15 @@ type TargetState struct {
 	GPG             *GPG
 	MinVersion      *semver.Version
 	SourceDir       string
+	TargetAllow     *PatternSet
 	TargetIgnore    *PatternSet
 	TargetRemove    *PatternSet
+	TemplateCache   TemplateCache
 	TemplateData    map[string]interface{}
 	TemplateFuncs   template.FuncMap
 	TemplateOptions []string
 	Templates       map[string]*template.Template
+	Triggers        []*Trigger
 	Umask           os.FileMode
 }
 
@@ -116,6 +131,13 @@ func WithTargetRemove(targetRemove *PatternSet) TargetStateOption {
 	}
 }
 
+// WithTemplateCache sets the template cache.
+func WithTemplateCache(templateCache TemplateCache) TargetStateOption {
+	return func(ts *TargetState) {
+		ts.TemplateCache = templateCache
+	}
+}
+
 // WithTemplateData sets the template data.
 func WithTemplateData(templateData map[string]interface{}) TargetStateOption {
 	return func(ts *TargetState) {
@@ -155,6 +177,7 @@ func WithUmask(umask os.FileMode) TargetStateOption {
 func NewTargetState(options ...TargetStateOption) *TargetState {
 	ts := &TargetState{
 		Entries:         make(map[string]Entry),
+		TargetAllow:     NewPatternSet(),
 		TargetIgnore:    NewPatternSet(),
 		TargetRemove:    NewPatternSet(),
 		TemplateOptions: DefaultTemplateOptions,
@@ -251,8 +274,16 @@ func (ts *TargetState) Add(fs vfs.FS, addOptions AddOptions, targetPath string,
 		if err != nil {
 			return err
 		}
-		if addOptions.Template && addOptions.AutoTemplate {
-			contents = autoTemplate(contents, ts.TemplateData)
+		switch {
+		case addOptions.Template && addOptions.AutoTemplate:
+			autoTemplateOptions := addOptions.AutoTemplateOptions
+			autoTemplateOptions.Templates = addOptions.SecretTemplates
+			contents, _, err = autoTemplate(contents, ts.TemplateData, &autoTemplateOptions)
+			if err != nil {
+				return err
+			}
+		case len(addOptions.SecretTemplates) != 0:
+			contents = templatizeSecrets(contents, addOptions.SecretTemplates)
 		}
 		if addOptions.Encrypt {
 			contents, err = ts.GPG.Encrypt(targetPath, contents)
@@ -375,21 +406,7 @@ func (ts *TargetState) Evaluate() error {
 
 // ExecuteTemplateData returns the result of executing template data.
 func (ts *TargetState) ExecuteTemplateData(name string, data []byte) ([]byte, error) {
-	tmpl, err := template.New(name).Option(ts.TemplateOptions...).Funcs(ts.TemplateFuncs).Parse(string(data))
-	if err != nil {
-		return nil, err
-	}
-	for name, t := range ts.Templates {
-		tmpl, err = tmpl.AddParseTree(name, t.Tree)
-		if err != nil {
-			return nil, err
-		}
-	}
-	output := &bytes.Buffer{}
-	if err = tmpl.ExecuteTemplate(output, name, ts.TemplateData); err != nil {
-		return nil, err
-	}
-	return output.Bytes(), nil
+	return ts.executeTemplateData(name, data, false)
 }
 
 // Get returns the state of the given target, or nil if no such target is found.
@@ -443,6 +460,9 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 		// Treat all files and directories beginning with "." specially.
 		if _, name := filepath.Split(relPath); strings.HasPrefix(name, ".") {
 			switch {
+			case info.Name() == allowSecretsName:
+				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
+				return ts.addPatterns(fs, ts.TargetAllow, path, filepath.Join(dns...))
 			case info.Name() == ignoreName:
 				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
 				return ts.addPatterns(fs, ts.TargetIgnore, path, filepath.Join(dns...))
@@ -454,6 +474,9 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 					return err
 				}
 				return filepath.SkipDir
+			case info.Name() == triggersName:
+				dns := dirNames(parseDirNameComponents(splitPathList(relPath)))
+				return ts.addTriggers(fs, path, filepath.Join(dns...))
 			case info.Name() == versionName:
 				data, err := fs.ReadFile(path)
 				if err != nil {
@@ -517,7 +540,7 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 							if err != nil {
 								return nil, err
 							}
-							return ts.ExecuteTemplateData(path, data)
+							return ts.executeTemplateData(path, data, psfp.fileAttributes != nil && psfp.fileAttributes.Encrypted)
 						}
 					}
 				}
@@ -532,6 +555,20 @@ func (ts *TargetState) Populate(fs vfs.FS, options *PopulateOptions) error {
 						Template:         psfp.fileAttributes.Template,
 						evaluateContents: evaluateContents,
 					}
+					if !psfp.fileAttributes.Encrypted && !psfp.fileAttributes.Template {
+						entry.openContents = func() (io.ReadCloser, int64, error) {
+							f, err := fs.Open(path)
+							if err != nil {
+								return nil, 0, err
+							}
+							info, err := f.Stat()
+							if err != nil {
+								f.Close()
+								return nil, 0, err
+							}
+							return f, info.Size(), nil
+						}
+					}
 					entries[psfp.fileAttributes.Name] = entry
 				case psfp.scriptAttributes != nil:
 					entry := &Script{
@@ -766,6 +803,59 @@ func (ts *TargetState) executeTemplate(fs vfs.FS, path string) ([]byte, error) {
 	return ts.ExecuteTemplateData(path, data)
 }
 
+// executeTemplateData returns the result of executing template data, using
+// ts.TemplateCache if it is set. decrypted is true if data was decrypted.
+func (ts *TargetState) executeTemplateData(name string, data []byte, decrypted bool) ([]byte, error) {
+	tmpl, err := template.New(name).Option(ts.TemplateOptions...).Funcs(ts.TemplateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	for name, t := range ts.Templates {
+		tmpl, err = tmpl.AddParseTree(name, t.Tree)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Only compute the lazy template data that the template references. If
+	// the template references secret data then treat its output as if the
+	// template was decrypted.
+	templateData, secret, err := resolveTemplateData(ts.TemplateData, tmpl)
+	if err != nil {
+		return nil, err
+	}
+	decrypted = decrypted || secret
+
+	var key []byte
+	var funcs map[string]struct{}
+	if ts.TemplateCache != nil {
+		key = ts.templateCacheKey(name, data, templateData)
+		funcs = templateFuncs(tmpl)
+	}
+	if key != nil {
+		cachedOutput, err := ts.TemplateCache.Get(key, funcs)
+		if err != nil {
+			return nil, err
+		}
+		if cachedOutput != nil {
+			return cachedOutput, nil
+		}
+	}
+
+	output := &bytes.Buffer{}
+	if err = tmpl.ExecuteTemplate(output, name, templateData); err != nil {
+		return nil, err
+	}
+
+	if key != nil {
+		if err := ts.TemplateCache.Set(key, funcs, decrypted, output.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	return output.Bytes(), nil
+}
+
 func (ts *TargetState) findEntries(dirNames []string) (map[string]Entry, error) {
 	entries := ts.Entries
 	for i, dirName := range dirNames {
@@ -0,0 +1,124 @@
+package chezmoi
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"hash"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// A TemplateCache caches the output of executing templates.
+type TemplateCache interface {
+	// Get returns the cached output associated with key, or nil if there is
+	// none. funcs is the set of template functions that the template may
+	// call.
+	Get(key []byte, funcs map[string]struct{}) ([]byte, error)
+	// Set associates output with key. decrypted is true if the template was
+	// decrypted before it was executed.
+	Set(key []byte, funcs map[string]struct{}, decrypted bool, output []byte) error
+}
+
+// templateCacheKey returns the cache key for executing the template name with
+// data and templateData in ts. It returns nil if the key cannot be computed,
+// for example if templateData cannot be serialized. Names in ts's source
+// directory are keyed relative to it, so that copies of the source directory,
+// for example at other revisions, share cache entries.
+func (ts *TargetState) templateCacheKey(name string, data []byte, templateData map[string]interface{}) []byte {
+	templateDataJSON, err := json.Marshal(templateData)
+	if err != nil {
+		return nil
+	}
+	if ts.SourceDir != "" && filepath.IsAbs(name) {
+		if relName, err := filepath.Rel(ts.SourceDir, name); err == nil && relName != ".." && !strings.HasPrefix(relName, ".."+string(filepath.Separator)) {
+			name = filepath.ToSlash(relName)
+		}
+	}
+	h := sha256.New()
+	for _, b := range [][]byte{
+		[]byte(name),
+		data,
+		templateDataJSON,
+	} {
+		writeLengthPrefixed(h, b)
+	}
+	for _, option := range ts.TemplateOptions {
+		writeLengthPrefixed(h, []byte(option))
+	}
+	templateNames := make([]string, 0, len(ts.Templates))
+	for name := range ts.Templates {
+		templateNames = append(templateNames, name)
+	}
+	sort.Strings(templateNames)
+	for _, name := range templateNames {
+		writeLengthPrefixed(h, []byte(name))
+		writeLengthPrefixed(h, []byte(ts.Templates[name].Tree.Root.String()))
+	}
+	return h.Sum(nil)
+}
+
+// templateFuncs returns the set of identifiers, i.e. functions, that tmpl and
+// any of its associated templates may call.
+func templateFuncs(tmpl *template.Template) map[string]struct{} {
+	funcs := make(map[string]struct{})
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			addTemplateFuncs(funcs, t.Tree.Root)
+		}
+	}
+	return funcs
+}
+
+func addTemplateFuncs(funcs map[string]struct{}, node parse.Node) {
+	switch node := node.(type) {
+	case *parse.ActionNode:
+		addTemplateFuncs(funcs, node.Pipe)
+	case *parse.ChainNode:
+		addTemplateFuncs(funcs, node.Node)
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			addTemplateFuncs(funcs, arg)
+		}
+	case *parse.IdentifierNode:
+		funcs[node.Ident] = struct{}{}
+	case *parse.IfNode:
+		addBranchTemplateFuncs(funcs, &node.BranchNode)
+	case *parse.ListNode:
+		if node == nil {
+			return
+		}
+		for _, n := range node.Nodes {
+			addTemplateFuncs(funcs, n)
+		}
+	case *parse.PipeNode:
+		if node == nil {
+			return
+		}
+		for _, cmd := range node.Cmds {
+			addTemplateFuncs(funcs, cmd)
+		}
+	case *parse.RangeNode:
+		addBranchTemplateFuncs(funcs, &node.BranchNode)
+	case *parse.TemplateNode:
+		addTemplateFuncs(funcs, node.Pipe)
+	case *parse.WithNode:
+		addBranchTemplateFuncs(funcs, &node.BranchNode)
+	}
+}
+
+func addBranchTemplateFuncs(funcs map[string]struct{}, node *parse.BranchNode) {
+	addTemplateFuncs(funcs, node.Pipe)
+	addTemplateFuncs(funcs, node.List)
+	addTemplateFuncs(funcs, node.ElseList)
+}
+
+// writeLengthPrefixed writes b to h, prefixed by its length, so that the
+// boundaries between consecutive writes are unambiguous.
+func writeLengthPrefixed(h hash.Hash, b []byte) {
+	_ = binary.Write(h, binary.LittleEndian, uint64(len(b)))
+	_, _ = h.Write(b)
+}
@@ -0,0 +1,40 @@
+package chezmoi
+
+import (
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	funcMap := template.FuncMap{
+		"a": func() string { return "" },
+		"b": func() string { return "" },
+		"c": func() string { return "" },
+		"d": func() string { return "" },
+		"e": func() string { return "" },
+	}
+	tmpl, err := template.New("tmpl").Funcs(funcMap).Parse(`{{ a }}{{ if b }}{{ range c }}{{ end }}{{ else }}{{ with $x := d | len }}{{ end }}{{ end }}{{ template "other" }}`)
+	require.NoError(t, err)
+	_, err = tmpl.New("other").Funcs(funcMap).Parse(`{{ (e).Field }}`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{
+		"a":   {},
+		"b":   {},
+		"c":   {},
+		"d":   {},
+		"e":   {},
+		"len": {},
+	}, templateFuncs(tmpl))
+}
+
+func TestTemplateCacheKeyRelative(t *testing.T) {
+	key := func(sourceDir string) []byte {
+		ts := NewTargetState(WithSourceDir(sourceDir))
+		return ts.templateCacheKey(filepath.Join(sourceDir, "dot_file.tmpl"), []byte("{{ .x }}"), nil)
+	}
+	assert.Equal(t, key(filepath.Join("/", "home", "user", ".local", "share", "chezmoi")), key(filepath.Join("/", "tmp", "chezmoi-source123")))
+}
@@ -0,0 +1,80 @@
+package chezmoi
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	vfs "github.com/twpayne/go-vfs"
+)
+
+// A Trigger is a command that is run when a target matching Pattern changes.
+type Trigger struct {
+	Pattern string
+	Command string
+}
+
+// Match returns true if targetName or any of its parent directories match t's
+// pattern.
+func (t *Trigger) Match(targetName string) bool {
+	for ; targetName != "." && targetName != string(filepath.Separator); targetName = filepath.Dir(targetName) {
+		if ok, _ := filepath.Match(t.Pattern, targetName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Triggered returns the triggers in ts that match any of targetPaths, in the
+// order in which they were defined. Each trigger is returned at most once.
+func (ts *TargetState) Triggered(targetPaths []string) []*Trigger {
+	var triggered []*Trigger
+TRIGGER:
+	for _, trigger := range ts.Triggers {
+		for _, targetPath := range targetPaths {
+			targetName, err := filepath.Rel(ts.DestDir, targetPath)
+			if err != nil || strings.HasPrefix(targetName, "..") {
+				continue
+			}
+			if trigger.Match(targetName) {
+				triggered = append(triggered, trigger)
+				continue TRIGGER
+			}
+		}
+	}
+	return triggered
+}
+
+func (ts *TargetState) addTriggers(fs vfs.FS, path, relPath string) error {
+	data, err := ts.executeTemplate(fs, path)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(relPath)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		index := strings.IndexFunc(text, unicode.IsSpace)
+		if index == -1 {
+			return fmt.Errorf("%s: %s: missing command", path, text)
+		}
+		pattern := filepath.Join(dir, text[:index])
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, text[:index], err)
+		}
+		ts.Triggers = append(ts.Triggers, &Trigger{
+			Pattern: pattern,
+			Command: strings.TrimSpace(text[index:]),
+		})
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package chezmoi
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriggerMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pattern    string
+		targetName string
+		want       bool
+	}{
+		{
+			pattern:    ".tmux.conf",
+			targetName: ".tmux.conf",
+			want:       true,
+		},
+		{
+			pattern:    ".tmux.conf",
+			targetName: ".bashrc",
+			want:       false,
+		},
+		{
+			pattern:    filepath.Join(".config", "systemd", "user", "*"),
+			targetName: filepath.Join(".config", "systemd", "user", "foo.service"),
+			want:       true,
+		},
+		{
+			pattern:    filepath.Join(".config", "systemd", "user", "*"),
+			targetName: filepath.Join(".config", "systemd", "user", "default.target.wants", "foo.service"),
+			want:       true,
+		},
+		{
+			pattern:    filepath.Join(".config", "systemd", "user", "*"),
+			targetName: filepath.Join(".config", "systemd"),
+			want:       false,
+		},
+	} {
+		trigger := &Trigger{
+			Pattern: tc.pattern,
+		}
+		assert.Equal(t, tc.want, trigger.Match(tc.targetName), "pattern %q, targetName %q", tc.pattern, tc.targetName)
+	}
+}
+
+func TestTargetStateTriggered(t *testing.T) {
+	ts := NewTargetState(
+		WithDestDir(filepath.Join("/", "home", "user")),
+	)
+	ts.Triggers = []*Trigger{
+		{Pattern: ".tmux.conf", Command: "tmux source-file ~/.tmux.conf"},
+		{Pattern: filepath.Join(".config", "systemd", "user", "*"), Command: "systemctl --user daemon-reload"},
+		{Pattern: ".bashrc", Command: "true"},
+	}
+	assert.Equal(t, []*Trigger{ts.Triggers[0], ts.Triggers[1]}, ts.Triggered([]string{
+		filepath.Join("/", "home", "user", ".config", "systemd", "user", "a.service"),
+		filepath.Join("/", "home", "user", ".config", "systemd", "user", "b.service"),
+		filepath.Join("/", "home", "user", ".tmux.conf"),
+		filepath.Join("/", "tmp", ".bashrc"),
+	}))
+}
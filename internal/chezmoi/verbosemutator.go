@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -22,15 +24,18 @@ type VerboseMutator struct {
 	w               io.Writer
 	colored         bool
 	maxDiffDataSize int
+	redactor        *Redactor
 }
 
-// NewVerboseMutator returns a new VerboseMutator.
-func NewVerboseMutator(w io.Writer, m Mutator, colored bool, maxDiffDataSize int) *VerboseMutator {
+// NewVerboseMutator returns a new VerboseMutator. If redactor is not nil then
+// secrets are redacted from the diffs that it writes.
+func NewVerboseMutator(w io.Writer, m Mutator, colored bool, maxDiffDataSize int, redactor *Redactor) *VerboseMutator {
 	return &VerboseMutator{
 		m:               m,
 		w:               w,
 		colored:         colored,
 		maxDiffDataSize: maxDiffDataSize,
+		redactor:        redactor,
 	}
 }
 
@@ -115,14 +120,19 @@ func (m *VerboseMutator) WriteFile(name string, data []byte, perm os.FileMode, c
 	err := m.m.WriteFile(name, data, perm, currData)
 	if err == nil {
 		_, _ = fmt.Fprintln(m.w, action)
-		// Don't print diffs if either file is binary.
-		if isBinary(currData) || isBinary(data) {
-			return nil
+		if m.redactor.IsSecretPath(name) {
+			_, err := fmt.Fprintf(m.w, "Secret files %s and %s differ\n", filepath.Join("a", name), filepath.Join("b", name))
+			return err
+		}
+		currData, data = m.redactor.Redact(currData), m.redactor.Redact(data)
+		// Only summarize the differences if either file is binary.
+		if IsBinary(currData) || IsBinary(data) {
+			return m.writeDiffSummary("Binary files", name, currData, data)
 		}
-		// Don't print diffs if either file is too large.
+		// Only summarize the differences if either file is too large.
 		if m.maxDiffDataSize != 0 {
 			if len(currData) > m.maxDiffDataSize || len(data) > m.maxDiffDataSize {
-				return nil
+				return m.writeDiffSummary("Files", name, currData, data)
 			}
 		}
 		aLines, err := splitLines(currData)
@@ -165,6 +175,19 @@ func (m *VerboseMutator) WriteSymlink(oldname, newname string) error {
 	return err
 }
 
+// writeDiffSummary writes a summary of the differences between currData and
+// data, for when a full diff is not appropriate.
+func (m *VerboseMutator) writeDiffSummary(kind, name string, currData, data []byte) error {
+	currDataSHA256 := sha256.Sum256(currData)
+	dataSHA256 := sha256.Sum256(data)
+	_, err := fmt.Fprintf(m.w, "%s %s (%d bytes, sha256 %s) and %s (%d bytes, sha256 %s) differ\n",
+		kind,
+		filepath.Join("a", name), len(currData), hex.EncodeToString(currDataSHA256[:]),
+		filepath.Join("b", name), len(data), hex.EncodeToString(dataSHA256[:]),
+	)
+	return err
+}
+
 // cmdString returns a string representation of cmd.
 func cmdString(cmd *exec.Cmd) string {
 	s := ShellQuoteArgs(append([]string{cmd.Path}, cmd.Args[1:]...))
@@ -174,7 +197,8 @@ func cmdString(cmd *exec.Cmd) string {
 	return fmt.Sprintf("( cd %s && %s )", MaybeShellQuote(cmd.Dir), s)
 }
 
-func isBinary(data []byte) bool {
+// IsBinary returns whether data looks like binary data.
+func IsBinary(data []byte) bool {
 	return len(data) != 0 && !strings.HasPrefix(http.DetectContentType(data), "text/")
 }
 
@@ -0,0 +1,75 @@
+package chezmoi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ Mutator = &VerboseMutator{}
+
+func TestVerboseMutatorWriteFile(t *testing.T) {
+	redactor := NewRedactor()
+	redactor.AddSecret("s3cr3t")
+	secretFileRedactor := NewRedactor()
+	secretFileRedactor.AddSecretPath("file")
+
+	for _, tc := range []struct {
+		name            string
+		maxDiffDataSize int
+		redactor        *Redactor
+		currData        []byte
+		data            []byte
+		want            string
+	}{
+		{
+			name:     "binary",
+			currData: nil,
+			data:     []byte("\x00\x01\x02\x03"),
+			want: "" +
+				"install -m 644 /dev/null file\n" +
+				"Binary files a/file (0 bytes, sha256 e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855) and b/file (4 bytes, sha256 054edec1d0211f624fed0cbca9d4f9400b0e491c43742af2c5b0abebf0c990d8) differ\n",
+		},
+		{
+			name:            "too_large",
+			maxDiffDataSize: 2,
+			currData:        []byte("foo\n"),
+			data:            []byte("bar\n"),
+			want: "" +
+				"install -m 644 /dev/null file\n" +
+				"Files a/file (4 bytes, sha256 b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c) and b/file (4 bytes, sha256 7d865e959b2466918c9863afca942d0fb89d7c9ac0c99bafc3749504ded97730) differ\n",
+		},
+		{
+			name:     "redacted",
+			redactor: redactor,
+			currData: []byte("password old\n"),
+			data:     []byte("password s3cr3t\n"),
+			want: "" +
+				"install -m 644 /dev/null file\n" +
+				"--- a/file\n" +
+				"+++ b/file\n" +
+				"@@ -1,1 +0,0 @@\n" +
+				"-password old\n" +
+				"@@ -0,0 +1,1 @@\n" +
+				"+password [redacted]\n",
+		},
+		{
+			name:     "secret_file",
+			redactor: secretFileRedactor,
+			currData: []byte("old\n"),
+			data:     []byte("new\n"),
+			want: "" +
+				"install -m 644 /dev/null file\n" +
+				"Secret files a/file and b/file differ\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			m := NewVerboseMutator(b, NullMutator{}, false, tc.maxDiffDataSize, tc.redactor)
+			require.NoError(t, m.WriteFile("file", tc.data, 0644, tc.currData))
+			assert.Equal(t, tc.want, b.String())
+		})
+	}
+}